@@ -1,20 +1,34 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"time"
 
+	"git.containerum.net/ch/solutions/pkg/auth"
+	"git.containerum.net/ch/solutions/pkg/clients"
 	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/memory"
 	"git.containerum.net/ch/solutions/pkg/db/postgres"
+	"git.containerum.net/ch/solutions/pkg/db/sqlite"
+	"git.containerum.net/ch/solutions/pkg/lock"
 	"git.containerum.net/ch/solutions/pkg/server"
 	"git.containerum.net/ch/solutions/pkg/server/impl"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"git.containerum.net/ch/solutions/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 const (
+	configFlag              = "config"
+	configWatchIntervalFlag = "config_watch_interval"
+
 	portFlag         = "port"
+	metricsPortFlag  = "metrics_port"
 	solutionsFlag    = "solutions"
 	debugFlag        = "debug"
 	textlogFlag      = "textlog"
@@ -25,18 +39,85 @@ const (
 	dbPGNameFlag     = "db_pg_dbname"
 	dbPGNoSSLFlag    = "db_pg_nossl"
 	dbMigrationsFlag = "db_migrations"
-	kubeURLFlag      = "kube_url"
-	resourceURLFlag  = "resource_url"
-	corsFlag         = "cors"
+
+	dbSQLiteFileFlag       = "db_sqlite_file"
+	dbSQLiteMigrationsFlag = "db_sqlite_migrations"
+
+	kubeURLFlag     = "kube_url"
+	resourceURLFlag = "resource_url"
+	corsFlag        = "cors"
+
+	corsOriginsFlag       = "cors_origins"
+	corsMethodsFlag       = "cors_methods"
+	corsHeadersFlag       = "cors_headers"
+	corsExposeHeadersFlag = "cors_expose_headers"
+	corsCredentialsFlag   = "cors_credentials"
+	corsMaxAgeFlag        = "cors_max_age"
+
+	authModeFlag            = "auth_mode"
+	authJWKSFlag            = "auth_jwks"
+	authJWKSRefreshFlag     = "auth_jwks_refresh"
+	authIssuerFlag          = "auth_issuer"
+	authAudienceFlag        = "auth_audience"
+	authInsecureFlag        = "auth_insecure"
+	authUserClaimFlag       = "auth_user_claim"
+	authRoleClaimFlag       = "auth_role_claim"
+	authNamespacesClaimFlag = "auth_namespaces_claim"
+
+	reconcileIntervalFlag = "reconcile_interval"
+	reconcileHealFlag     = "reconcile_heal"
+	gcIntervalFlag        = "gc_interval"
+	gcDeleteFlag          = "gc_delete"
+	scheduleIntervalFlag  = "schedule_interval"
+	expiryIntervalFlag    = "expiry_interval"
+	expiryWarningFlag     = "expiry_warning"
+	webhookIntervalFlag   = "webhook_interval"
+	webhookAttemptsFlag   = "webhook_attempts"
+	webhookBackoffFlag    = "webhook_backoff"
+	webhookTimeoutFlag    = "webhook_timeout"
+	webhookNetworksFlag   = "webhook_allowed_networks"
+	webhookSecretKeyFlag  = "webhook_secret_key"
+	gitHookSecretFlag     = "git_hook_secret"
+	readyTimeoutFlag      = "ready_timeout"
+	lockTTLFlag           = "lock_ttl"
+	tracingExporterFlag   = "tracing_exporter"
+	tracingEndpointFlag   = "tracing_otlp_endpoint"
+
+	kubeTimeoutFlag            = "kube_timeout"
+	resourceTimeoutFlag        = "resource_timeout"
+	downloadTimeoutFlag        = "download_timeout"
+	templateTokensFlag         = "template_tokens"
+	templateSourceFlag         = "template_source"
+	clientRetriesFlag          = "client_retries"
+	clientRetryBackoffFlag     = "client_retry_backoff"
+	clientRetryMaxBackoffFlag  = "client_retry_max_backoff"
+	clientBreakerThresholdFlag = "client_breaker_threshold"
+	clientBreakerCooldownFlag  = "client_breaker_cooldown"
 )
 
 var flags = []cli.Flag{
+	cli.StringFlag{
+		EnvVar: "CONFIG",
+		Name:   configFlag,
+		Usage:  "YAML configuration file, overridden by flags and environment",
+	},
+	cli.DurationFlag{
+		EnvVar: "CONFIG_WATCH_INTERVAL",
+		Name:   configWatchIntervalFlag,
+		Value:  10 * time.Second,
+		Usage:  "Interval between configuration file changes checks (0 to reload only on SIGHUP)",
+	},
 	cli.StringFlag{
 		EnvVar: "PORT",
 		Name:   portFlag,
 		Value:  "6767",
 		Usage:  "port for solutions server",
 	},
+	cli.StringFlag{
+		EnvVar: "METRICS_PORT",
+		Name:   metricsPortFlag,
+		Usage:  "port for metrics server, metrics are served on solutions server port if not set",
+	},
 	cli.StringFlag{
 		EnvVar: "SOLUTIONS",
 		Name:   solutionsFlag,
@@ -57,7 +138,7 @@ var flags = []cli.Flag{
 		EnvVar: "DB",
 		Name:   dbFlag,
 		Value:  "postgres",
-		Usage:  "DB for project",
+		Usage:  "DB for project: postgres, sqlite (requires build with cgo), memory (data is lost on exit)",
 	},
 	cli.StringFlag{
 		EnvVar: "PG_LOGIN",
@@ -90,6 +171,18 @@ var flags = []cli.Flag{
 		Value:  "../../pkg/migrations/",
 		Usage:  "Location of DB migrations",
 	},
+	cli.StringFlag{
+		EnvVar: "SQLITE_FILE",
+		Name:   dbSQLiteFileFlag,
+		Value:  "solutions.db",
+		Usage:  "DB file, created if not exists (SQLite)",
+	},
+	cli.StringFlag{
+		EnvVar: "SQLITE_MIGRATIONS_PATH",
+		Name:   dbSQLiteMigrationsFlag,
+		Value:  "../../pkg/migrations/sqlite/",
+		Usage:  "Location of DB migrations (SQLite)",
+	},
 	cli.StringFlag{
 		EnvVar: "KUBE_API_URL",
 		Name:   kubeURLFlag,
@@ -107,14 +200,273 @@ var flags = []cli.Flag{
 		Name:   "cors",
 		Usage:  "enable CORS",
 	},
+	cli.StringFlag{
+		EnvVar: "CORS_ORIGINS",
+		Name:   corsOriginsFlag,
+		Usage:  "Comma-separated origins allowed to make CORS requests, i.e. https://*.example.com (* allows all origins)",
+	},
+	cli.StringFlag{
+		EnvVar: "CORS_METHODS",
+		Name:   corsMethodsFlag,
+		Value:  "GET,POST,PUT,DELETE,HEAD",
+		Usage:  "Comma-separated methods allowed in CORS requests",
+	},
+	cli.StringFlag{
+		EnvVar: "CORS_HEADERS",
+		Name:   corsHeadersFlag,
+		Value:  "Origin,Content-Length,Content-Type,Authorization,X-User-ID,X-User-Role,X-User-Namespace,If-Match",
+		Usage:  "Comma-separated headers allowed in CORS requests in addition to Authorization and If-Match",
+	},
+	cli.StringFlag{
+		EnvVar: "CORS_EXPOSE_HEADERS",
+		Name:   corsExposeHeadersFlag,
+		Value:  "ETag,X-Request-ID",
+		Usage:  "Comma-separated response headers exposed to CORS requests",
+	},
+	cli.BoolFlag{
+		EnvVar: "CORS_CREDENTIALS",
+		Name:   corsCredentialsFlag,
+		Usage:  "Allow credentials in CORS requests (not allowed for all origins)",
+	},
+	cli.DurationFlag{
+		EnvVar: "CORS_MAX_AGE",
+		Name:   corsMaxAgeFlag,
+		Value:  12 * time.Hour,
+		Usage:  "Time while browsers may cache CORS preflight requests results",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_MODE",
+		Name:   authModeFlag,
+		Value:  "headers",
+		Usage:  "Users authentication: headers (identity headers set by API gateway), jwt (bearer tokens)",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_JWKS",
+		Name:   authJWKSFlag,
+		Usage:  "JSON Web Key Set file or URL verifying tokens (discovered from OpenID configuration of issuer if empty)",
+	},
+	cli.DurationFlag{
+		EnvVar: "AUTH_JWKS_REFRESH",
+		Name:   authJWKSRefreshFlag,
+		Value:  time.Hour,
+		Usage:  "Interval between JSON Web Key Set reloads (0 to disable)",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_ISSUER",
+		Name:   authIssuerFlag,
+		Usage:  "Tokens issuer, checked against iss claim if set",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_AUDIENCE",
+		Name:   authAudienceFlag,
+		Usage:  "Tokens audience, required in aud claim if set",
+	},
+	cli.BoolFlag{
+		EnvVar: "AUTH_INSECURE",
+		Name:   authInsecureFlag,
+		Usage:  "Allow loading issuer configuration and JSON Web Key Set by http (https is required otherwise)",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_USER_CLAIM",
+		Name:   authUserClaimFlag,
+		Value:  auth.DefaultConfig.UserClaim,
+		Usage:  "Token claim containing user ID",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_ROLE_CLAIM",
+		Name:   authRoleClaimFlag,
+		Value:  auth.DefaultConfig.RoleClaim,
+		Usage:  "Token claim containing user role or roles list, nested claims are separated by dots (users without admin role have user role)",
+	},
+	cli.StringFlag{
+		EnvVar: "AUTH_NAMESPACES_CLAIM",
+		Name:   authNamespacesClaimFlag,
+		Value:  auth.DefaultConfig.NamespacesClaim,
+		Usage:  "Token claim containing namespace ID to access level object or list of X-User-Namespace header objects",
+	},
+	cli.DurationFlag{
+		EnvVar: "RECONCILE_INTERVAL",
+		Name:   reconcileIntervalFlag,
+		Value:  10 * time.Minute,
+		Usage:  "Interval between solutions drift checks (0 to disable)",
+	},
+	cli.BoolFlag{
+		EnvVar: "RECONCILE_HEAL",
+		Name:   reconcileHealFlag,
+		Usage:  "Recreate missing solution resources during drift checks",
+	},
+	cli.DurationFlag{
+		EnvVar: "GC_INTERVAL",
+		Name:   gcIntervalFlag,
+		Usage:  "Interval between orphan resources collections (0 to disable)",
+	},
+	cli.BoolFlag{
+		EnvVar: "GC_DELETE",
+		Name:   gcDeleteFlag,
+		Usage:  "Delete orphan resources during scheduled collections (they are only reported otherwise)",
+	},
+	cli.DurationFlag{
+		EnvVar: "SCHEDULE_INTERVAL",
+		Name:   scheduleIntervalFlag,
+		Value:  time.Minute,
+		Usage:  "Interval between solutions start/stop schedule checks (0 to disable)",
+	},
+	cli.DurationFlag{
+		EnvVar: "EXPIRY_INTERVAL",
+		Name:   expiryIntervalFlag,
+		Value:  time.Minute,
+		Usage:  "Interval between expired solutions checks (0 to disable)",
+	},
+	cli.DurationFlag{
+		EnvVar: "EXPIRY_WARNING",
+		Name:   expiryWarningFlag,
+		Value:  time.Hour,
+		Usage:  "Warn about solution expiry this time before it expires (0 to disable)",
+	},
+	cli.DurationFlag{
+		EnvVar: "WEBHOOK_INTERVAL",
+		Name:   webhookIntervalFlag,
+		Value:  10 * time.Second,
+		Usage:  "Interval between pending webhook deliveries checks (0 to disable)",
+	},
+	cli.IntFlag{
+		EnvVar: "WEBHOOK_ATTEMPTS",
+		Name:   webhookAttemptsFlag,
+		Value:  8,
+		Usage:  "Max webhook delivery attempts",
+	},
+	cli.DurationFlag{
+		EnvVar: "WEBHOOK_BACKOFF",
+		Name:   webhookBackoffFlag,
+		Value:  30 * time.Second,
+		Usage:  "Delay before second webhook delivery attempt, doubled on every next attempt",
+	},
+	cli.DurationFlag{
+		EnvVar: "WEBHOOK_TIMEOUT",
+		Name:   webhookTimeoutFlag,
+		Value:  10 * time.Second,
+		Usage:  "Webhook request timeout",
+	},
+	cli.StringFlag{
+		EnvVar: "WEBHOOK_ALLOWED_NETWORKS",
+		Name:   webhookNetworksFlag,
+		Usage:  "Comma-separated networks (CIDR) webhooks may be sent to in addition to public addresses, i.e. cluster network of in-cluster receivers",
+	},
+	cli.StringFlag{
+		EnvVar: "WEBHOOK_SECRET_KEY",
+		Name:   webhookSecretKeyFlag,
+		Usage:  "Key encrypting webhook secrets stored in DB (secrets are stored in plaintext if empty)",
+	},
+	cli.StringFlag{
+		EnvVar: "GIT_HOOK_SECRET",
+		Name:   gitHookSecretFlag,
+		Usage:  "Secret of template repositories push webhooks (git webhooks are disabled if empty)",
+	},
+	cli.DurationFlag{
+		EnvVar: "READY_TIMEOUT",
+		Name:   readyTimeoutFlag,
+		Value:  2 * time.Second,
+		Usage:  "Timeout of every dependency check in readiness probe",
+	},
+	cli.DurationFlag{
+		EnvVar: "LOCK_TTL",
+		Name:   lockTTLFlag,
+		Value:  lock.DefaultTTL,
+		Usage:  "Time while solution lock of crashed replica blocks solution operations",
+	},
+	cli.StringFlag{
+		EnvVar: "TRACING_EXPORTER",
+		Name:   tracingExporterFlag,
+		Usage:  "Tracing spans exporter: otlp, stdout (tracing is disabled if empty)",
+	},
+	cli.StringFlag{
+		EnvVar: "TRACING_OTLP_ENDPOINT",
+		Name:   tracingEndpointFlag,
+		Value:  "http://localhost:4318/v1/traces",
+		Usage:  "OTLP/HTTP collector traces endpoint",
+	},
+	cli.DurationFlag{
+		EnvVar: "KUBE_API_TIMEOUT",
+		Name:   kubeTimeoutFlag,
+		Value:  clients.DefaultTransportConfig.Timeout,
+		Usage:  "Kube-API request attempt timeout",
+	},
+	cli.DurationFlag{
+		EnvVar: "RESOURCE_TIMEOUT",
+		Name:   resourceTimeoutFlag,
+		Value:  clients.DefaultTransportConfig.Timeout,
+		Usage:  "Resource service request attempt timeout",
+	},
+	cli.DurationFlag{
+		EnvVar: "DOWNLOAD_TIMEOUT",
+		Name:   downloadTimeoutFlag,
+		Value:  clients.DefaultTransportConfig.Timeout,
+		Usage:  "Template files download attempt timeout",
+	},
+	cli.StringFlag{
+		EnvVar: "TEMPLATE_TOKENS",
+		Name:   templateTokensFlag,
+		Usage:  "Comma-separated host=token pairs, token is sent on template files download from host",
+	},
+	cli.StringFlag{
+		EnvVar: "TEMPLATE_SOURCE",
+		Name:   templateSourceFlag,
+		Value:  server.DefaultTemplateSource,
+		Usage:  "Server template files are downloaded from as {source}/{owner}/{repo}/{ref}/{file}",
+	},
+	cli.IntFlag{
+		EnvVar: "CLIENT_RETRIES",
+		Name:   clientRetriesFlag,
+		Value:  clients.DefaultTransportConfig.Retries,
+		Usage:  "Max retries of failed idempotent requests to other services (0 to disable)",
+	},
+	cli.DurationFlag{
+		EnvVar: "CLIENT_RETRY_BACKOFF",
+		Name:   clientRetryBackoffFlag,
+		Value:  clients.DefaultTransportConfig.RetryBackoff,
+		Usage:  "Delay before first retry of request to other service, doubled on every next retry",
+	},
+	cli.DurationFlag{
+		EnvVar: "CLIENT_RETRY_MAX_BACKOFF",
+		Name:   clientRetryMaxBackoffFlag,
+		Value:  clients.DefaultTransportConfig.RetryMaxBackoff,
+		Usage:  "Max delay between retries of request to other service",
+	},
+	cli.IntFlag{
+		EnvVar: "CLIENT_BREAKER_THRESHOLD",
+		Name:   clientBreakerThresholdFlag,
+		Value:  clients.DefaultTransportConfig.BreakerThreshold,
+		Usage:  "Count of consecutive failed requests to other service which opens circuit breaker (0 to disable)",
+	},
+	cli.DurationFlag{
+		EnvVar: "CLIENT_BREAKER_COOLDOWN",
+		Name:   clientBreakerCooldownFlag,
+		Value:  clients.DefaultTransportConfig.BreakerCooldown,
+		Usage:  "Time while requests to other service are rejected after circuit breaker opened",
+	},
 }
 
-func setupLogs(c *cli.Context) {
+// secretFlags are not displayed on startup
+var secretFlags = map[string]bool{
+	dbPGPasswordFlag:     true,
+	gitHookSecretFlag:    true,
+	templateTokensFlag:   true,
+	webhookSecretKeyFlag: true,
+}
+
+func setupGinMode(c *cli.Context) {
 	if c.Bool("debug") {
 		gin.SetMode(gin.DebugMode)
-		logrus.SetLevel(logrus.DebugLevel)
 	} else {
 		gin.SetMode(gin.ReleaseMode)
+	}
+}
+
+// setupLogs sets logs level and format. It is called again on configuration reload.
+func setupLogs(c *cli.Context) {
+	if c.Bool("debug") {
+		logrus.SetLevel(logrus.DebugLevel)
+	} else {
 		logrus.SetLevel(logrus.InfoLevel)
 	}
 
@@ -134,6 +486,58 @@ func getSolutionsSrv(c *cli.Context, services server.Services) (server.Solutions
 	}
 }
 
+func setupTracing(c *cli.Context) error {
+	switch c.String(tracingExporterFlag) {
+	case "":
+		return nil
+	case "stdout":
+		tracing.Init(c.App.Name, tracing.NewStdoutExporter(os.Stdout))
+	case "otlp":
+		tracing.Init(c.App.Name, tracing.NewOTLPExporter(c.String(tracingEndpointFlag), 10*time.Second))
+	default:
+		return errors.New("invalid tracing exporter")
+	}
+	return nil
+}
+
+// getVerifier returns tokens verifier in jwt authentication mode and nil in headers mode
+func getVerifier(c *cli.Context) (*auth.Verifier, error) {
+	switch c.String(authModeFlag) {
+	case "headers":
+		return nil, nil
+	case "jwt":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return auth.NewVerifier(ctx, auth.Config{
+			JWKS:            c.String(authJWKSFlag),
+			Issuer:          c.String(authIssuerFlag),
+			Audience:        c.String(authAudienceFlag),
+			Insecure:        c.Bool(authInsecureFlag),
+			UserClaim:       c.String(authUserClaimFlag),
+			RoleClaim:       c.String(authRoleClaimFlag),
+			NamespacesClaim: c.String(authNamespacesClaimFlag),
+		})
+	default:
+		return nil, errors.New("invalid auth mode")
+	}
+}
+
+// getWebhookPolicy returns policy of webhooks destinations allowing public addresses and configured networks
+func getWebhookPolicy(s *settings) (clients.DestinationPolicy, error) {
+	networks, err := clients.ParseNetworks(s.list(webhookNetworksFlag))
+	if err != nil {
+		return clients.DestinationPolicy{}, fmt.Errorf("invalid %s: %v", webhookNetworksFlag, err)
+	}
+	return clients.DestinationPolicy{AllowedNetworks: networks}, nil
+}
+
+func getSecretBox(c *cli.Context) (*utils.SecretBox, error) {
+	if c.String(webhookSecretKeyFlag) == "" {
+		logrus.Warnf("%s is not set, webhook secrets are stored in plaintext", webhookSecretKeyFlag)
+	}
+	return utils.NewSecretBox(c.String(webhookSecretKeyFlag))
+}
+
 func getDB(c *cli.Context) (db.DB, error) {
 	switch c.String(dbFlag) {
 	case "postgres":
@@ -142,6 +546,10 @@ func getDB(c *cli.Context) (db.DB, error) {
 			url = url + "?sslmode=disable"
 		}
 		return postgres.DBConnect(url, c.String(dbMigrationsFlag))
+	case "sqlite":
+		return sqlite.DBConnect(c.String(dbSQLiteFileFlag), c.String(dbSQLiteMigrationsFlag))
+	case "memory":
+		return memory.NewDB(), nil
 	default:
 		return nil, errors.New("invalid db")
 	}
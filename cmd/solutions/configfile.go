@@ -0,0 +1,376 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/clients"
+	"git.containerum.net/ch/solutions/pkg/router/middleware"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// Client names in "clients" section of configuration file
+const (
+	clientKubeAPI  = "kube_api"
+	clientResource = "resource"
+	clientDownload = "download"
+	clientWebhook  = "webhook"
+)
+
+// clientTimeoutFlags are flags setting request timeouts of clients
+var clientTimeoutFlags = map[string]string{
+	clientKubeAPI:  kubeTimeoutFlag,
+	clientResource: resourceTimeoutFlag,
+	clientDownload: downloadTimeoutFlag,
+	clientWebhook:  webhookTimeoutFlag,
+}
+
+// configFile is a content of YAML configuration file. Top-level keys are flag names, e.g.
+//
+//	db: sqlite
+//	kube_timeout: 5s
+//	cors_origins: [https://web.containerum.io]
+//	template_tokens:
+//	  raw.githubusercontent.com: token
+//
+// Section "clients" sets transport of single client (kube_api, resource, download, webhook)
+// overriding shared client_* flags:
+//
+//	clients:
+//	  download:
+//	    timeout: 30s
+//	    retries: 5
+type configFile struct {
+	Clients map[string]clientConfig `yaml:"clients"`
+	Flags   map[string]interface{}  `yaml:",inline"`
+}
+
+// clientConfig overrides fields of clients.TransportConfig
+type clientConfig struct {
+	Timeout          *duration `yaml:"timeout"`
+	Retries          *int      `yaml:"retries"`
+	RetryBackoff     *duration `yaml:"retry_backoff"`
+	RetryMaxBackoff  *duration `yaml:"retry_max_backoff"`
+	BreakerThreshold *int      `yaml:"breaker_threshold"`
+	BreakerCooldown  *duration `yaml:"breaker_cooldown"`
+}
+
+// duration is time.Duration in Go format, i.e. "1m30s"
+type duration time.Duration
+
+func (d *duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	value, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	*d = duration(value)
+	return nil
+}
+
+func readConfigFile(path string) (*configFile, error) {
+	var file configFile
+	if path == "" {
+		return &file, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	for name := range file.Clients {
+		if _, ok := clientTimeoutFlags[name]; !ok {
+			return nil, fmt.Errorf("invalid config file %s: unknown client %s", path, name)
+		}
+	}
+	return &file, nil
+}
+
+// listFlags are comma-separated lists in flags and environment and YAML lists in configuration file
+var listFlags = map[string]bool{
+	corsOriginsFlag:       true,
+	corsMethodsFlag:       true,
+	corsHeadersFlag:       true,
+	corsExposeHeadersFlag: true,
+	webhookNetworksFlag:   true,
+}
+
+// fileScalar returns scalar value set in configuration file
+func fileScalar(value interface{}) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}, map[interface{}]interface{}:
+		return "", errors.New("scalar expected")
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+// fileList returns list set in configuration file. Scalar is a list of single item.
+func fileList(value interface{}) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		item, err := fileScalar(value)
+		if err != nil || item == "" {
+			return nil, err
+		}
+		return []string{item}, nil
+	}
+	items := make([]string, 0, len(list))
+	for _, value := range list {
+		item, err := fileScalar(value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// fileMap returns map of scalars set in configuration file
+func fileMap(value interface{}) (map[string]string, error) {
+	object, ok := value.(map[interface{}]interface{})
+	if !ok && value != nil {
+		return nil, errors.New("map expected")
+	}
+	result := make(map[string]string, len(object))
+	for k, v := range object {
+		item, err := fileScalar(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", k, err)
+		}
+		result[fmt.Sprint(k)] = item
+	}
+	return result, nil
+}
+
+// explicitFlags returns names of flags set in command line or environment
+func explicitFlags(c *cli.Context) map[string]bool {
+	explicit := make(map[string]bool)
+	for _, f := range flags {
+		if c.IsSet(f.GetName()) {
+			explicit[f.GetName()] = true
+		}
+	}
+	return explicit
+}
+
+// settings are flags merged with configuration file
+type settings struct {
+	*cli.Context
+	file     *configFile
+	explicit map[string]bool
+
+	lists          map[string][]string // list flag -> list set in configuration file
+	templateTokens map[string]string   // host -> token
+}
+
+// loadSettings reads configuration file set in c and merges it with flags.
+// Explicitly set flags override file values, file values override flags defaults.
+// Template tokens of file are merged with explicitly set tokens, explicit token of host overrides file one.
+func loadSettings(c *cli.Context, explicit map[string]bool) (*settings, error) {
+	path := c.String(configFlag)
+	file, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// flags set is filled with defaults and environment
+	set := flag.NewFlagSet(c.App.Name, flag.ContinueOnError)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	s := &settings{
+		Context:  cli.NewContext(c.App, set, nil),
+		file:     file,
+		explicit: explicit,
+		lists:    make(map[string][]string),
+	}
+	fileTokens := make(map[string]string)
+	for name, value := range file.Flags {
+		if set.Lookup(name) == nil || name == configFlag {
+			return nil, fmt.Errorf("invalid config file %s: unknown setting %s", path, name)
+		}
+		switch {
+		case name == templateTokensFlag:
+			fileTokens, err = fileMap(value)
+		case explicit[name]:
+			continue
+		case listFlags[name]:
+			s.lists[name], err = fileList(value)
+		default:
+			var scalar string
+			if scalar, err = fileScalar(value); err == nil {
+				err = set.Set(name, scalar)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %s: invalid %s value: %v", path, name, err)
+		}
+	}
+	for name := range explicit {
+		if err := set.Set(name, c.String(name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.templateTokens, err = parseTemplateTokens(s.String(templateTokensFlag)); err != nil {
+		return nil, err
+	}
+	for host, token := range fileTokens {
+		if _, ok := s.templateTokens[host]; !ok {
+			s.templateTokens[host] = token
+		}
+	}
+	if s.Bool(corsFlag) {
+		if err := s.corsConfig().Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func parseTemplateTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range splitList(value) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.New("invalid template_tokens: host=token pairs expected")
+		}
+		tokens[parts[0]] = parts[1]
+	}
+	return tokens, nil
+}
+
+// splitList splits comma-separated list skipping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// list returns list flag value: explicitly set comma-separated list, list set in configuration file or default
+func (s *settings) list(name string) []string {
+	if list, ok := s.lists[name]; ok {
+		return list
+	}
+	return splitList(s.String(name))
+}
+
+// value returns setting in flag format, lists are joined with comma
+func (s *settings) value(name string) string {
+	switch {
+	case name == templateTokensFlag:
+		pairs := make([]string, 0, len(s.templateTokens))
+		for host, token := range s.templateTokens {
+			pairs = append(pairs, host+"="+token)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	case listFlags[name]:
+		return strings.Join(s.list(name), ",")
+	default:
+		return s.String(name)
+	}
+}
+
+func (s *settings) corsConfig() middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowOrigins:     s.list(corsOriginsFlag),
+		AllowMethods:     s.list(corsMethodsFlag),
+		AllowHeaders:     s.list(corsHeadersFlag),
+		ExposeHeaders:    s.list(corsExposeHeadersFlag),
+		AllowCredentials: s.Bool(corsCredentialsFlag),
+		MaxAge:           s.Duration(corsMaxAgeFlag),
+	}
+}
+
+// transportConfig returns client transport config.
+// Settings of client section of configuration file override shared client_* flags unless they are set explicitly.
+func (s *settings) transportConfig(client string) clients.TransportConfig {
+	timeoutFlag := clientTimeoutFlags[client]
+	config := clients.TransportConfig{Timeout: s.Duration(timeoutFlag)}
+	if client != clientWebhook {
+		// failed webhooks deliveries are retried by service itself
+		config.Retries = s.Int(clientRetriesFlag)
+		config.RetryBackoff = s.Duration(clientRetryBackoffFlag)
+		config.RetryMaxBackoff = s.Duration(clientRetryMaxBackoffFlag)
+		config.BreakerThreshold = s.Int(clientBreakerThresholdFlag)
+		config.BreakerCooldown = s.Duration(clientBreakerCooldownFlag)
+	}
+
+	section, ok := s.file.Clients[client]
+	if !ok {
+		return config
+	}
+	overrides := func(flagName string) bool {
+		return !s.explicit[flagName] || (client == clientWebhook && flagName != timeoutFlag)
+	}
+	if section.Timeout != nil && overrides(timeoutFlag) {
+		config.Timeout = time.Duration(*section.Timeout)
+	}
+	if section.Retries != nil && overrides(clientRetriesFlag) {
+		config.Retries = *section.Retries
+	}
+	if section.RetryBackoff != nil && overrides(clientRetryBackoffFlag) {
+		config.RetryBackoff = time.Duration(*section.RetryBackoff)
+	}
+	if section.RetryMaxBackoff != nil && overrides(clientRetryMaxBackoffFlag) {
+		config.RetryMaxBackoff = time.Duration(*section.RetryMaxBackoff)
+	}
+	if section.BreakerThreshold != nil && overrides(clientBreakerThresholdFlag) {
+		config.BreakerThreshold = *section.BreakerThreshold
+	}
+	if section.BreakerCooldown != nil && overrides(clientBreakerCooldownFlag) {
+		config.BreakerCooldown = time.Duration(*section.BreakerCooldown)
+	}
+	return config
+}
+
+// print displays all settings. Secrets are masked.
+func (s *settings) print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.TabIndent|tabwriter.Debug)
+	for _, f := range flags {
+		value := s.value(f.GetName())
+		if secretFlags[f.GetName()] && value != "" {
+			value = "******"
+		}
+		fmt.Fprintf(w, "Flag: %s\t Value: %s\n", f.GetName(), value)
+	}
+	for _, client := range []string{clientKubeAPI, clientResource, clientDownload, clientWebhook} {
+		fmt.Fprintf(w, "Client: %s\t Value: %+v\n", client, s.transportConfig(client))
+	}
+	w.Flush()
+}
+
+// modTime returns configuration file modification time, zero if file is not set
+func (s *settings) modTime() time.Time {
+	path := s.String(configFlag)
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
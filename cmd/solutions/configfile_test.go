@@ -0,0 +1,139 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/clients"
+	"github.com/urfave/cli"
+)
+
+const testConfig = `
+kube_timeout: 5s
+client_retries: 5
+cors_methods: [PUT]
+cors_origins:
+  - https://a.example.com
+  - https://b.example.com
+webhook_allowed_networks: 10.0.0.0/8
+template_tokens:
+  raw.githubusercontent.com: "to,ken=1"
+  example.com: file
+`
+
+func writeConfig(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Unable to write config file: %v", err)
+	}
+}
+
+// runApp runs application with args and configuration file content and calls f in its action
+func runApp(t *testing.T, args []string, content string, f func(c *cli.Context)) {
+	file, err := ioutil.TempFile("", "solutions-config")
+	if err != nil {
+		t.Fatalf("Unable to create config file: %v", err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+	writeConfig(t, file.Name(), content)
+
+	app := cli.NewApp()
+	app.Flags = flags
+	app.Action = func(c *cli.Context) error {
+		f(c)
+		return nil
+	}
+	if err := app.Run(append([]string{"solutions", "--" + configFlag, file.Name()}, args...)); err != nil {
+		t.Fatalf("Unable to run app: %v", err)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	os.Setenv("CORS_METHODS", "GET,POST")
+	defer os.Unsetenv("CORS_METHODS")
+	os.Setenv("TEMPLATE_TOKENS", "example.com=env")
+	defer os.Unsetenv("TEMPLATE_TOKENS")
+
+	runApp(t, []string{"--" + kubeTimeoutFlag, "7s"}, testConfig, func(c *cli.Context) {
+		s, err := loadSettings(c, explicitFlags(c))
+		if err != nil {
+			t.Fatalf("Unable to load settings: %v", err)
+		}
+		// flag and environment override file
+		if timeout := s.Duration(kubeTimeoutFlag); timeout != 7*time.Second {
+			t.Errorf("Unexpected %s from flag: %v", kubeTimeoutFlag, timeout)
+		}
+		if methods := s.list(corsMethodsFlag); !reflect.DeepEqual(methods, []string{"GET", "POST"}) {
+			t.Errorf("Unexpected %s from environment: %v", corsMethodsFlag, methods)
+		}
+		// file overrides default
+		if retries := s.Int(clientRetriesFlag); retries != 5 {
+			t.Errorf("Unexpected %s from file: %v", clientRetriesFlag, retries)
+		}
+		if origins := s.list(corsOriginsFlag); !reflect.DeepEqual(origins, []string{"https://a.example.com", "https://b.example.com"}) {
+			t.Errorf("Unexpected %s from file: %v", corsOriginsFlag, origins)
+		}
+		if networks := s.list(webhookNetworksFlag); !reflect.DeepEqual(networks, []string{"10.0.0.0/8"}) {
+			t.Errorf("Unexpected %s from file: %v", webhookNetworksFlag, networks)
+		}
+		// defaults
+		if headers := s.list(corsExposeHeadersFlag); !reflect.DeepEqual(headers, []string{"ETag", "X-Request-ID"}) {
+			t.Errorf("Unexpected default %s: %v", corsExposeHeadersFlag, headers)
+		}
+		if timeout := s.Duration(resourceTimeoutFlag); timeout != clients.DefaultTransportConfig.Timeout {
+			t.Errorf("Unexpected default %s: %v", resourceTimeoutFlag, timeout)
+		}
+		// file tokens are merged with environment tokens, values are not split
+		expectedTokens := map[string]string{"raw.githubusercontent.com": "to,ken=1", "example.com": "env"}
+		if !reflect.DeepEqual(s.templateTokens, expectedTokens) {
+			t.Errorf("Unexpected template tokens: %v", s.templateTokens)
+		}
+	})
+}
+
+func TestLoadSettingsInvalid(t *testing.T) {
+	for _, content := range []string{
+		"unknown: 1",
+		"config: other.yaml",
+		"kube_timeout: 1",
+		"kube_timeout: [1s]",
+		"cors_origins: {a: b}",
+		"cors_origins: [[a]]",
+		"template_tokens: [a]",
+		"template_tokens: {a: [b]}",
+		"clients: {other: {retries: 1}}",
+		"kube_timeout: 5s\nkube_timeout: 6s",
+		"cors: true",
+	} {
+		runApp(t, nil, content, func(c *cli.Context) {
+			if _, err := loadSettings(c, explicitFlags(c)); err == nil {
+				t.Errorf("Invalid config is loaded: %q", content)
+			}
+		})
+	}
+}
+
+func TestReloadKeepsPreviousSettings(t *testing.T) {
+	runApp(t, nil, testConfig, func(c *cli.Context) {
+		s, err := loadSettings(c, explicitFlags(c))
+		if err != nil {
+			t.Fatalf("Unable to load settings: %v", err)
+		}
+		r := newReloader(c, s)
+
+		writeConfig(t, c.String(configFlag), "client_retries: [2]")
+		r.reload()
+		if r.current != s {
+			t.Fatalf("Invalid config is applied")
+		}
+
+		writeConfig(t, c.String(configFlag), "client_retries: 2")
+		r.reload()
+		if retries := r.current.Int(clientRetriesFlag); retries != 2 {
+			t.Fatalf("Config is not reloaded: %s is %d", clientRetriesFlag, retries)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/clients"
+	"git.containerum.net/ch/solutions/pkg/router/middleware"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// reloadableFlags are applied to running server on configuration reload. Other flags require restart.
+var reloadableFlags = map[string]bool{
+	debugFlag:                  true,
+	textlogFlag:                true,
+	corsOriginsFlag:            true,
+	corsMethodsFlag:            true,
+	corsHeadersFlag:            true,
+	corsExposeHeadersFlag:      true,
+	corsCredentialsFlag:        true,
+	corsMaxAgeFlag:             true,
+	templateTokensFlag:         true,
+	kubeTimeoutFlag:            true,
+	resourceTimeoutFlag:        true,
+	downloadTimeoutFlag:        true,
+	webhookTimeoutFlag:         true,
+	clientRetriesFlag:          true,
+	clientRetryBackoffFlag:     true,
+	clientRetryMaxBackoffFlag:  true,
+	clientBreakerThresholdFlag: true,
+	clientBreakerCooldownFlag:  true,
+}
+
+// reloader applies configuration file changes to running server
+type reloader struct {
+	c        *cli.Context
+	explicit map[string]bool
+	current  *settings
+	modTime  time.Time
+
+	clients map[string]clients.Reconfigurable // client name -> client
+	tokens  clients.SourceTokensSetter
+	cors    *middleware.CORS // nil if CORS is disabled
+}
+
+func newReloader(c *cli.Context, s *settings) *reloader {
+	return &reloader{
+		c:        c,
+		explicit: s.explicit,
+		current:  s,
+		modTime:  s.modTime(),
+		clients:  make(map[string]clients.Reconfigurable),
+	}
+}
+
+// apply sets reloadable settings
+func (r *reloader) apply(s *settings) {
+	setupLogs(s.Context)
+	for name, client := range r.clients {
+		client.SetTransportConfig(s.transportConfig(name))
+	}
+	if r.tokens != nil {
+		r.tokens.SetSourceTokens(s.templateTokens)
+	}
+	if r.cors != nil {
+		// config is validated on load
+		r.cors.Set(s.corsConfig())
+	}
+}
+
+// reload reads configuration file again. Previous settings are kept if file is invalid.
+func (r *reloader) reload() {
+	s, err := loadSettings(r.c, r.explicit)
+	if err != nil {
+		log.WithError(err).Errorln("Unable to reload configuration, previous configuration is kept")
+		return
+	}
+	for _, f := range flags {
+		name := f.GetName()
+		if !reloadableFlags[name] && s.value(name) != r.current.value(name) {
+			log.WithField("flag", name).Warnln("Setting is changed, it is applied after restart")
+		}
+	}
+	r.apply(s)
+	r.current = s
+	log.Infoln("Configuration reloaded")
+}
+
+// watch reloads configuration on SIGHUP or configuration file change until ctx is done.
+// File is checked every interval (0 disables checks).
+func (r *reloader) watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var check <-chan time.Time
+	if interval > 0 && r.current.String(configFlag) != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Infoln("SIGHUP received, reloading configuration")
+			r.reload()
+		case <-check:
+			// file is reloaded once per change even if it is invalid
+			if modTime := r.current.modTime(); !modTime.Equal(r.modTime) {
+				r.modTime = modTime
+				log.Infoln("Configuration file changed, reloading configuration")
+				r.reload()
+			}
+		}
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"text/tabwriter"
+	"sync"
 	"time"
 
 	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/lock"
+	"git.containerum.net/ch/solutions/pkg/metrics"
 	"git.containerum.net/ch/solutions/pkg/router"
+	"git.containerum.net/ch/solutions/pkg/router/middleware"
 	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"git.containerum.net/ch/solutions/pkg/utils"
 
 	log "github.com/sirupsen/logrus"
 
@@ -20,42 +25,157 @@ import (
 	"github.com/urfave/cli"
 )
 
+// dbMetricsTTL is a period metrics computed by database queries are cached for
+const dbMetricsTTL = 30 * time.Second
+
 func getService(service interface{}, err error) interface{} {
 	exitOnErr(err)
 	return service
 }
 
 func initServer(c *cli.Context) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.TabIndent|tabwriter.Debug)
-	for _, f := range c.GlobalFlagNames() {
-		fmt.Fprintf(w, "Flag: %s\t Value: %s\n", f, c.String(f))
-	}
-	w.Flush()
+	s, err := loadSettings(c, explicitFlags(c))
+	exitOnErr(err)
+	s.print(os.Stdout)
+
+	setupGinMode(s.Context)
+	setupLogs(s.Context)
+	exitOnErr(setupTracing(s.Context))
+
+	database := getService(getDB(s.Context)).(db.DB)
+	metrics.OnScrapeCached(dbMetricsTTL, func(ctx context.Context) {
+		counts, err := database.CountActiveSolutions(ctx)
+		if err != nil {
+			log.WithError(err).Errorln("Unable to count active solutions")
+			return
+		}
+		metrics.ActiveSolutions.Reset()
+		for template, count := range counts {
+			metrics.ActiveSolutions.Set(float64(count), template)
+		}
+	})
 
-	setupLogs(c)
+	downloadClient := clients.NewHTTPDownloadClient(s.transportConfig(clientDownload), s.Bool(debugFlag))
+	resourceClient := clients.NewHTTPResourceClient(s.String(resourceURLFlag), s.transportConfig(clientResource), s.Bool(debugFlag))
+	kubeAPIClient := clients.NewHTTPKubeAPIClient(s.String(kubeURLFlag), s.transportConfig(clientKubeAPI), s.Bool(debugFlag))
+	webhookPolicy, err := getWebhookPolicy(s)
+	exitOnErr(err)
+	webhookClient := clients.NewHTTPWebhookClient(s.transportConfig(clientWebhook), webhookPolicy, s.Bool(debugFlag))
+	secrets, err := getSecretBox(s.Context)
+	exitOnErr(err)
 
-	solutionssrv, err := getSolutionsSrv(c, server.Services{
-		DB:             getService(getDB(c)).(db.DB),
-		DownloadClient: clients.NewHTTPDownloadClient(c.Bool(debugFlag)),
-		ResourceClient: clients.NewHTTPResourceClient(c.String(resourceURLFlag), c.Bool(debugFlag)),
-		KubeAPIClient:  clients.NewHTTPKubeAPIClient(c.String(kubeURLFlag), c.Bool(debugFlag)),
+	locker := lock.NewLocker(database, s.Duration(lockTTLFlag))
+	solutionssrv, err := getSolutionsSrv(s.Context, server.Services{
+		DB:             database,
+		DownloadClient: downloadClient,
+		ResourceClient: resourceClient,
+		KubeAPIClient:  kubeAPIClient,
+		WebhookClient:  webhookClient,
+		Locker:         locker,
+		Secrets:        secrets,
+		TemplateSource: s.String(templateSourceFlag),
 	})
 	exitOnErr(err)
 
+	// status is filled with dependencies checks results on request
 	status := model.ServiceStatus{
-		Name:     c.App.Name,
-		Version:  c.App.Version,
-		StatusOK: true,
+		Name:    s.App.Name,
+		Version: s.App.Version,
+	}
+
+	var cors *middleware.CORS
+	if s.Bool(corsFlag) {
+		cors = getService(middleware.NewCORS(s.corsConfig())).(*middleware.CORS)
 	}
 
-	app := router.CreateRouter(&solutionssrv, &status, c.Bool(corsFlag))
+	verifier, err := getVerifier(s.Context)
+	exitOnErr(err)
+
+	metricsPort := s.String(metricsPortFlag)
+	app := router.CreateRouter(&solutionssrv, &status, cors, verifier, s.String(gitHookSecretFlag), s.Duration(readyTimeoutFlag), metricsPort == "")
+
+	reload := newReloader(c, s)
+	reload.clients[clientDownload] = downloadClient.(clients.Reconfigurable)
+	reload.clients[clientResource] = resourceClient.(clients.Reconfigurable)
+	reload.clients[clientKubeAPI] = kubeAPIClient.(clients.Reconfigurable)
+	reload.clients[clientWebhook] = webhookClient.(clients.Reconfigurable)
+	reload.tokens = downloadClient.(clients.SourceTokensSetter)
+	reload.cors = cors
+	reload.apply(s)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// jobs checking all solutions run on leader replica only, so replicas don't multiply load and race each other
+	go locker.RunAsLeader(jobsCtx, func(ctx context.Context) {
+		var wg sync.WaitGroup
+		runPeriodically := func(interval time.Duration, f func(ctx context.Context)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				utils.RunPeriodically(ctx, interval, f)
+			}()
+		}
+		runPeriodically(s.Duration(reconcileIntervalFlag), func(ctx context.Context) {
+			if _, err := solutionssrv.ReconcileSolutions(utils.AdminContext(ctx), s.Bool(reconcileHealFlag)); err != nil {
+				log.WithError(err).Errorln("Solutions reconciliation failed")
+			}
+		})
+		runPeriodically(s.Duration(gcIntervalFlag), func(ctx context.Context) {
+			if _, err := solutionssrv.CollectOrphanResources(utils.AdminContext(ctx), !s.Bool(gcDeleteFlag)); err != nil {
+				log.WithError(err).Errorln("Orphan resources collection failed")
+			}
+		})
+		scheduleCheckedAt := time.Now()
+		runPeriodically(s.Duration(scheduleIntervalFlag), func(ctx context.Context) {
+			now := time.Now()
+			if err := solutionssrv.ApplySolutionsSchedule(utils.AdminContext(ctx), scheduleCheckedAt, now); err != nil {
+				log.WithError(err).Errorln("Solutions schedule check failed")
+			}
+			scheduleCheckedAt = now
+		})
+		runPeriodically(s.Duration(expiryIntervalFlag), func(ctx context.Context) {
+			if err := solutionssrv.ExpireSolutions(utils.AdminContext(ctx), time.Now(), s.Duration(expiryWarningFlag)); err != nil {
+				log.WithError(err).Errorln("Expired solutions check failed")
+			}
+		})
+		wg.Wait()
+	})
+	go utils.RunPeriodically(jobsCtx, s.Duration(webhookIntervalFlag), func(ctx context.Context) {
+		if err := solutionssrv.DeliverWebhooks(ctx, time.Now(), s.Int(webhookAttemptsFlag), s.Duration(webhookBackoffFlag)); err != nil {
+			log.WithError(err).Errorln("Webhooks delivery failed")
+		}
+	})
+	go solutionssrv.RunTrackingUpgrades(jobsCtx)
+	go reload.watch(jobsCtx, s.Duration(configWatchIntervalFlag))
+	if verifier != nil {
+		go utils.RunPeriodically(jobsCtx, s.Duration(authJWKSRefreshFlag), func(ctx context.Context) {
+			if err := verifier.Refresh(ctx); err != nil {
+				log.WithError(err).Errorln("Unable to reload JSON Web Key Set")
+			}
+		})
+	}
 
 	// for graceful shutdown
 	srv := &http.Server{
-		Addr:    ":" + c.String(portFlag),
+		Addr:    ":" + s.String(portFlag),
 		Handler: app,
 	}
 
+	// metrics are served on separate port, so they may be not exposed with API
+	var metricsSrv *http.Server
+	if metricsPort != "" {
+		metricsSrv = &http.Server{
+			Addr:    ":" + metricsPort,
+			Handler: router.CreateMetricsRouter(),
+		}
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != http.ErrServerClosed {
+				exitOnErr(err)
+			}
+		}()
+	}
+
 	go exitOnErr(srv.ListenAndServe())
 
 	// Wait for interrupt signal to gracefully shutdown the server with
@@ -68,7 +188,15 @@ func initServer(c *cli.Context) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return srv.Shutdown(ctx)
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	return tracing.Shutdown(ctx)
 }
 
 func exitOnErr(err error) {
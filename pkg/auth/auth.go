@@ -0,0 +1,402 @@
+// Package auth authenticates users by JSON Web Tokens signed by OpenID Connect provider
+// or other issuer publishing JSON Web Key Set.
+//
+// Tokens are signed with RSA (RS256, RS384, RS512, PS256, PS384, PS512) or ECDSA (ES256, ES384, ES512) keys.
+// User identity is derived from token claims: user ID, role and namespaces access.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	// hash functions of signature algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"git.containerum.net/ch/solutions/pkg/utils"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Roles derived from role claim
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Leeway is a max clocks skew of token issuer and service
+const Leeway = time.Minute
+
+// maxNumericDate limits seconds of date claims, so they can be converted to time without overflow
+const maxNumericDate = 1 << 62
+
+// minRefreshInterval limits keys reloading on tokens signed by unknown keys
+const minRefreshInterval = time.Minute
+
+// Config describes tokens issuer and claims
+type Config struct {
+	// JWKS is a JSON Web Key Set file or URL. It is discovered from issuer OpenID configuration if empty.
+	JWKS string
+	// Issuer is checked against "iss" claim if not empty
+	Issuer string
+	// Audience must be in "aud" claim if not empty
+	Audience string
+	// Insecure allows loading issuer configuration and JWKS by http, i.e. in tests.
+	// Otherwise https is required, because keys loaded by http may be replaced in transit.
+	Insecure bool
+
+	// UserClaim contains user ID, i.e. "sub"
+	UserClaim string
+	// RoleClaim contains user role ("admin" or "user") or list of roles. Nested claims are separated by dots, i.e. "realm_access.roles".
+	// Users without admin role have "user" role.
+	RoleClaim string
+	// NamespacesClaim contains namespaces available for user: object with namespace ID keys and access level values
+	// or list of objects in "X-User-Namespace" header format.
+	NamespacesClaim string
+}
+
+// DefaultConfig is a claims configuration used if claims are not configured explicitly
+var DefaultConfig = Config{
+	UserClaim:       "sub",
+	RoleClaim:       "role",
+	NamespacesClaim: "namespaces",
+}
+
+// Identity is an authenticated user
+type Identity struct {
+	UserID     string
+	Role       string
+	Namespaces []kube_types.UserHeaderData
+}
+
+// Verifier verifies tokens with keys of issuer
+type Verifier struct {
+	config Config
+	client *http.Client
+	log    *logrus.Entry
+
+	refreshMu sync.Mutex // keys are loaded once by concurrent requests signed by new key
+	jwksURL   string     // discovered JWKS URL
+
+	mu   sync.RWMutex
+	keys []publicKey
+	// refreshedAt is a time of last keys refresh attempt, successful or not
+	refreshedAt time.Time
+}
+
+// NewVerifier loads issuer keys and returns tokens verifier
+func NewVerifier(ctx context.Context, config Config) (*Verifier, error) {
+	if config.JWKS == "" && config.Issuer == "" {
+		return nil, errors.New("JWKS or issuer is required to verify tokens")
+	}
+	v := &Verifier{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		log:    logrus.WithField("component", "auth"),
+	}
+	v.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return v.checkURL(req.URL.String())
+	}
+	if err := v.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Refresh reloads issuer keys, so rotated keys are used. Previous keys are kept on error.
+func (v *Verifier) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+	return v.refresh(ctx)
+}
+
+// refresh reloads keys, refreshMu must be held
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.refreshedAt = time.Now()
+	v.mu.Unlock()
+
+	source := v.config.JWKS
+	if source == "" {
+		if v.jwksURL == "" {
+			url, err := v.discoverJWKS(ctx)
+			if err != nil {
+				return fmt.Errorf("unable to discover JWKS: %v", err)
+			}
+			v.jwksURL = url
+		}
+		source = v.jwksURL
+	}
+	keys, err := v.loadKeys(ctx, source)
+	if err != nil {
+		return fmt.Errorf("unable to load JWKS: %v", err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.keys = keys
+	return nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks token signature, expiration, issuer and audience and returns user identity from token claims
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	if err := v.verifySignature(ctx, header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %v", err)
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return v.identity(claims)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// verifySignature checks signature with key from token header or with every compatible key if token has no key ID.
+// Keys are reloaded once if key is unknown, because issuer may rotate them.
+func (v *Verifier) verifySignature(ctx context.Context, header tokenHeader, signed string, signature []byte) error {
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+	hasher := alg.hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	for attempt := 0; ; attempt++ {
+		found := false
+		for _, key := range v.currentKeys() {
+			if (header.Kid != "" && key.id != header.Kid) || (key.alg != "" && key.alg != header.Alg) {
+				continue
+			}
+			found = true
+			if alg.verify(key.key, alg.hash, digest, signature) {
+				return nil
+			}
+		}
+		if found || attempt > 0 || !v.refreshUnknownKey(ctx) {
+			if !found {
+				return fmt.Errorf("token is signed by unknown key %q", header.Kid)
+			}
+			return errors.New("invalid token signature")
+		}
+	}
+}
+
+// refreshUnknownKey reloads keys unless refresh was attempted in last minRefreshInterval.
+// Failed attempts are limited too, so tokens with unknown keys don't flood unavailable issuer.
+// Returns false if keys are not reloaded.
+func (v *Verifier) refreshUnknownKey(ctx context.Context) bool {
+	requestedAt := time.Now()
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+	v.mu.RLock()
+	refreshedAt := v.refreshedAt
+	v.mu.RUnlock()
+	// keys are refreshed by concurrent request while waiting for lock
+	if refreshedAt.After(requestedAt) {
+		return true
+	}
+	if time.Since(refreshedAt) <= minRefreshInterval {
+		return false
+	}
+	if err := v.refresh(ctx); err != nil {
+		utils.LogEntry(ctx, v.log).WithError(err).Warnln("Unable to reload keys")
+		return false
+	}
+	return true
+}
+
+func (v *Verifier) currentKeys() []publicKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keys
+}
+
+type verifyFunc func(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) bool
+
+// algorithms are supported JWS algorithms (RFC 7518, section 3.1)
+var algorithms = map[string]struct {
+	hash   crypto.Hash
+	verify verifyFunc
+}{
+	"RS256": {crypto.SHA256, verifyPKCS1},
+	"RS384": {crypto.SHA384, verifyPKCS1},
+	"RS512": {crypto.SHA512, verifyPKCS1},
+	"PS256": {crypto.SHA256, verifyPSS},
+	"PS384": {crypto.SHA384, verifyPSS},
+	"PS512": {crypto.SHA512, verifyPSS},
+	"ES256": {crypto.SHA256, verifyECDSA},
+	"ES384": {crypto.SHA384, verifyECDSA},
+	"ES512": {crypto.SHA512, verifyECDSA},
+}
+
+func verifyPKCS1(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) bool {
+	rsaKey, ok := key.(*rsa.PublicKey)
+	return ok && rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature) == nil
+}
+
+func verifyPSS(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) bool {
+	rsaKey, ok := key.(*rsa.PublicKey)
+	return ok && rsa.VerifyPSS(rsaKey, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+}
+
+// verifyECDSA checks signature which is concatenation of R and S (RFC 7518, section 3.4)
+func verifyECDSA(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) bool {
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	size := (ecKey.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	return ecdsa.Verify(ecKey, digest, r, s)
+}
+
+// checkClaims checks registered claims. Token must have expiration time.
+func (v *Verifier) checkClaims(claims map[string]interface{}, now time.Time) error {
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("token has no valid expiration time")
+	}
+	if now.After(exp.Add(Leeway)) {
+		return errors.New("token is expired")
+	}
+	if claim, set := claims["nbf"]; set {
+		nbf, ok := numericDate(claim)
+		if !ok {
+			return errors.New("token has invalid not before time")
+		}
+		if now.Add(Leeway).Before(nbf) {
+			return errors.New("token is not valid yet")
+		}
+	}
+	if v.config.Issuer != "" && claims["iss"] != v.config.Issuer {
+		return fmt.Errorf("token is issued by %v", claims["iss"])
+	}
+	if v.config.Audience != "" && !containsString(claims["aud"], v.config.Audience) {
+		return errors.New("token is issued for other audience")
+	}
+	return nil
+}
+
+func numericDate(claim interface{}) (time.Time, bool) {
+	number, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) || math.Abs(seconds) > maxNumericDate {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// containsString checks if claim is value or list containing value
+func containsString(claim interface{}, value string) bool {
+	switch claim := claim.(type) {
+	case string:
+		return claim == value
+	case []interface{}:
+		for _, item := range claim {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claim returns nested claim by path separated by dots
+func claim(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+func (v *Verifier) identity(claims map[string]interface{}) (*Identity, error) {
+	userID, _ := claim(claims, v.config.UserClaim).(string)
+	if userID == "" {
+		return nil, fmt.Errorf("token has no %s claim", v.config.UserClaim)
+	}
+	identity := &Identity{UserID: userID, Role: RoleUser}
+	if containsString(claim(claims, v.config.RoleClaim), RoleAdmin) {
+		identity.Role = RoleAdmin
+		return identity, nil
+	}
+
+	identity.Namespaces = []kube_types.UserHeaderData{}
+	switch namespaces := claim(claims, v.config.NamespacesClaim).(type) {
+	case nil:
+	case map[string]interface{}:
+		for id, access := range namespaces {
+			level, ok := access.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s claim: access level of %s is not a string", v.config.NamespacesClaim, id)
+			}
+			identity.Namespaces = append(identity.Namespaces, kube_types.UserHeaderData{ID: id, Label: id, Access: kube_types.AccessLevel(level)})
+		}
+	case []interface{}:
+		data, _ := json.Marshal(namespaces)
+		if err := json.Unmarshal(data, &identity.Namespaces); err != nil {
+			return nil, fmt.Errorf("invalid %s claim: %v", v.config.NamespacesClaim, err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s claim", v.config.NamespacesClaim)
+	}
+	return identity, nil
+}
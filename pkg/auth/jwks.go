@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// jsonWebKey is a public key from JSON Web Key Set (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	id  string
+	alg string // algorithm key is restricted to, any compatible one if empty
+	key crypto.PublicKey
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// checkURL returns error if url is not https and insecure URLs are not allowed
+func (v *Verifier) checkURL(url string) error {
+	if !v.config.Insecure && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("%s is not https URL", url)
+	}
+	return nil
+}
+
+// fetch reads response body of GET request
+func (v *Verifier) fetch(ctx context.Context, url string) ([]byte, error) {
+	if err := v.checkURL(url); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// discoverJWKS returns JWKS URL from OpenID Connect provider configuration
+func (v *Verifier) discoverJWKS(ctx context.Context) (string, error) {
+	data, err := v.fetch(ctx, strings.TrimSuffix(v.config.Issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return "", err
+	}
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return "", fmt.Errorf("invalid OpenID configuration: %v", err)
+	}
+	if discovery.JWKSURI == "" {
+		return "", fmt.Errorf("OpenID configuration of %s has no jwks_uri", v.config.Issuer)
+	}
+	return discovery.JWKSURI, nil
+}
+
+// loadKeys reads JWKS from file or URL. Keys which are not supported are skipped.
+func (v *Verifier) loadKeys(ctx context.Context, source string) ([]publicKey, error) {
+	var data []byte
+	var err error
+	if isURL(source) {
+		data, err = v.fetch(ctx, source)
+	} else {
+		data, err = ioutil.ReadFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+	var keys []publicKey
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			v.log.WithError(err).WithField("kid", jwk.Kid).Warnln("Skipping JWKS key")
+			continue
+		}
+		keys = append(keys, publicKey{id: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s has no supported signature keys", source)
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", jwk.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter %q", value)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,57 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDestinationPolicyCheckURL(t *testing.T) {
+	policy := DestinationPolicy{AllowedNetworks: mustParseNetworks("10.1.0.0/16")}
+	for url, allowed := range map[string]bool{
+		"https://93.184.216.34/hook":          true,
+		"http://[2606:2800:220:1::248]/hook":  true,
+		"http://10.1.2.3:8080/hook":           true,
+		"http://10.2.0.1/hook":                false,
+		"http://127.0.0.1/hook":               false,
+		"http://[::1]/hook":                   false,
+		"http://[::ffff:127.0.0.1]/hook":      false,
+		"http://169.254.169.254/latest":       false,
+		"http://172.16.0.1/hook":              false,
+		"http://192.168.1.1/hook":             false,
+		"http://0.0.0.0/hook":                 false,
+		"http://[fd00::1]/hook":               false,
+		"http://[fe80::1]/hook":               false,
+		"http://localhost/hook":               false,
+		"http:///hook":                        false,
+		"http://100.64.0.1/hook":              false,
+		"http://224.0.0.1/hook":               false,
+		"http://[64:ff9b::7f00:1]/hook":       false,
+		"http://[2001:db8::1]:8080/hook":      false,
+		"http://255.255.255.255/hook":         false,
+		"https://93.184.216.34:8443/hook?x=1": true,
+	} {
+		if err := policy.CheckURL(context.Background(), url); (err == nil) != allowed {
+			t.Errorf("URL %s: expected allowed %v, got error %v", url, allowed, err)
+		}
+	}
+}
+
+func TestDestinationPolicyTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: DestinationPolicy{}.Transport()}
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("Request to loopback address is sent")
+	}
+
+	client = &http.Client{Transport: DestinationPolicy{AllowedNetworks: mustParseNetworks("127.0.0.0/8")}.Transport()}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request to allowed network failed: %v", err)
+	}
+	resp.Body.Close()
+}
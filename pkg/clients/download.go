@@ -4,9 +4,15 @@ import (
 	"context"
 
 	"errors"
+	"fmt"
+	"net/http"
 
+	"net/url"
+	"sync"
 	"time"
 
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	solutils "git.containerum.net/ch/solutions/pkg/utils"
 	"github.com/go-resty/resty"
 	"github.com/sirupsen/logrus"
 )
@@ -14,34 +20,88 @@ import (
 // DownloadClient is an interface to resource-service.
 type DownloadClient interface {
 	DownloadFile(ctx context.Context, url string) ([]byte, error)
+	// Ping checks if files server is available
+	Ping(ctx context.Context, url string) error
+}
+
+// SourceTokensSetter is implemented by download clients authorizing requests to private template sources
+type SourceTokensSetter interface {
+	// SetSourceTokens replaces tokens sent in "Authorization: Bearer" header to template source hosts (host -> token)
+	SetSourceTokens(tokens map[string]string)
 }
 
 type httpDownloadClient struct {
-	rest *resty.Client
-	log  *logrus.Entry
+	rest      *resty.Client
+	transport *retryTransport
+	log       *logrus.Entry
+
+	mu     sync.RWMutex
+	tokens map[string]string // host -> token
 }
 
 // NewHTTPDownloadClient returns client for resource-service working via restful api
-func NewHTTPDownloadClient(debug bool) DownloadClient {
+func NewHTTPDownloadClient(config TransportConfig, debug bool) DownloadClient {
 	log := logrus.WithField("component", "download_client")
+	transport := newTransport("download_client", nil, config, log)
 	client := resty.New().
 		SetLogger(log.WriterLevel(logrus.DebugLevel)).
 		SetDebug(debug).
-		SetTimeout(3 * time.Second)
+		SetTransport(transport)
 	return &httpDownloadClient{
-		rest: client,
-		log:  log,
+		rest:      client,
+		transport: transport,
+		log:       log,
 	}
 }
 
-func (c *httpDownloadClient) DownloadFile(ctx context.Context, url string) ([]byte, error) {
-	c.log.WithField("URL", url).Infoln("Downloading file")
+func (c *httpDownloadClient) SetTransportConfig(config TransportConfig) {
+	c.transport.SetTransportConfig(config)
+}
+
+func (c *httpDownloadClient) SetSourceTokens(tokens map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = tokens
+}
 
-	resp, err := c.rest.R().
-		SetContext(ctx).
-		Get(url)
+// request returns request authorized to file host if token for host is set
+func (c *httpDownloadClient) request(ctx context.Context, fileURL string) *resty.Request {
+	req := c.rest.R().SetContext(ctx)
+	u, err := url.Parse(fileURL)
 	if err != nil {
-		return nil, err
+		return req
+	}
+	c.mu.RLock()
+	token := c.tokens[u.Host]
+	c.mu.RUnlock()
+	if token != "" {
+		req.SetAuthToken(token)
+	}
+	return req
+}
+
+func (c *httpDownloadClient) logger(ctx context.Context) *logrus.Entry {
+	return solutils.LogEntry(ctx, c.log)
+}
+
+func (c *httpDownloadClient) DownloadFile(ctx context.Context, fileURL string) (_ []byte, err error) {
+	c.logger(ctx).WithField("URL", fileURL).Infoln("Downloading file")
+
+	var host string
+	if u, parseErr := url.Parse(fileURL); parseErr == nil {
+		host = u.Host
+	}
+	defer func(start time.Time) {
+		metrics.DownloadDuration.ObserveSince(start, host)
+		if err != nil {
+			metrics.DownloadErrors.Inc(host)
+		}
+	}(time.Now())
+
+	resp, err := c.request(ctx, fileURL).
+		Get(fileURL)
+	if err != nil {
+		return nil, requestError(err)
 	}
 
 	if resp.StatusCode() > 399 {
@@ -49,3 +109,19 @@ func (c *httpDownloadClient) DownloadFile(ctx context.Context, url string) ([]by
 	}
 	return resp.Body(), nil
 }
+
+func (c *httpDownloadClient) Ping(ctx context.Context, url string) error {
+	c.logger(ctx).WithField("URL", url).Debugln("Checking files server")
+
+	resp, err := c.request(ctx, url).
+		Head(url)
+	if err != nil {
+		return requestError(err)
+	}
+
+	// any response except server error means that server works
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		return fmt.Errorf("files server responded with status %d", resp.StatusCode())
+	}
+	return nil
+}
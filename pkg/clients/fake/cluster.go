@@ -0,0 +1,312 @@
+package fake
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerum/cherry"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// clusterSID is service ID of fake cluster errors
+const clusterSID = "FakeCluster"
+
+// ErrNamespaceNotExist is returned if namespace doesn't exist in fake cluster
+func ErrNamespaceNotExist() *cherry.Err {
+	return &cherry.Err{Message: "Namespace does not exist", StatusHTTP: 404, ID: cherry.ErrID{SID: clusterSID, Kind: 1}}
+}
+
+// ErrResourceNotExist is returned if deployment or service doesn't exist in fake cluster
+func ErrResourceNotExist() *cherry.Err {
+	return &cherry.Err{Message: "Resource does not exist", StatusHTTP: 404, ID: cherry.ErrID{SID: clusterSID, Kind: 2}}
+}
+
+// ErrResourceAlreadyExists is returned if created deployment or service already exists in fake cluster
+func ErrResourceAlreadyExists() *cherry.Err {
+	return &cherry.Err{Message: "Resource already exists", StatusHTTP: 409, ID: cherry.ErrID{SID: clusterSID, Kind: 3}}
+}
+
+type namespace struct {
+	deployments []kube_types.Deployment
+	services    []kube_types.Service
+}
+
+// Cluster is an in-memory storage of namespaces, deployments and services.
+// Resources belong to solution if their SolutionID is solution name, as in real cluster.
+type Cluster struct {
+	mu         sync.RWMutex
+	namespaces map[string]*namespace
+}
+
+// NewCluster returns cluster with namespaces
+func NewCluster(namespaces ...string) *Cluster {
+	c := &Cluster{namespaces: make(map[string]*namespace)}
+	for _, ns := range namespaces {
+		c.AddNamespace(ns)
+	}
+	return c
+}
+
+// AddNamespace creates namespace if it doesn't exist
+func (c *Cluster) AddNamespace(ns string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.namespaces[ns] == nil {
+		c.namespaces[ns] = &namespace{}
+	}
+}
+
+// DeleteNamespace deletes namespace with all its resources
+func (c *Cluster) DeleteNamespace(ns string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.namespaces, ns)
+}
+
+// Namespaces returns sorted namespaces list
+func (c *Cluster) Namespaces() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ret := make([]string, 0, len(c.namespaces))
+	for ns := range c.namespaces {
+		ret = append(ret, ns)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Deployments returns deployments of solution in namespace, all namespace deployments if solution is empty
+func (c *Cluster) Deployments(ns, solution string) ([]kube_types.Deployment, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := c.namespaces[ns]
+	if n == nil {
+		return nil, ErrNamespaceNotExist().AddDetailF("namespace %s", ns)
+	}
+	ret := make([]kube_types.Deployment, 0)
+	for _, d := range n.deployments {
+		if solution == "" || d.SolutionID == solution {
+			ret = append(ret, copyDeployment(d))
+		}
+	}
+	return ret, nil
+}
+
+// Services returns services of solution in namespace, all namespace services if solution is empty
+func (c *Cluster) Services(ns, solution string) ([]kube_types.Service, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := c.namespaces[ns]
+	if n == nil {
+		return nil, ErrNamespaceNotExist().AddDetailF("namespace %s", ns)
+	}
+	ret := make([]kube_types.Service, 0)
+	for _, svc := range n.services {
+		if solution == "" || svc.SolutionID == solution {
+			ret = append(ret, copyService(svc))
+		}
+	}
+	return ret, nil
+}
+
+// Deployment returns namespace deployment by name
+func (c *Cluster) Deployment(ns, name string) (*kube_types.Deployment, error) {
+	deploys, err := c.Deployments(ns, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range deploys {
+		if deploys[i].Name == name {
+			return &deploys[i], nil
+		}
+	}
+	return nil, ErrResourceNotExist().AddDetailF("deployment %s", name)
+}
+
+// Service returns namespace service by name
+func (c *Cluster) Service(ns, name string) (*kube_types.Service, error) {
+	services, err := c.Services(ns, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range services {
+		if services[i].Name == name {
+			return &services[i], nil
+		}
+	}
+	return nil, ErrResourceNotExist().AddDetailF("service %s", name)
+}
+
+// update runs f with namespace locked for writing
+func (c *Cluster) update(ns string, f func(n *namespace) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.namespaces[ns]
+	if n == nil {
+		return ErrNamespaceNotExist().AddDetailF("namespace %s", ns)
+	}
+	return f(n)
+}
+
+// PutDeployment creates or replaces deployment as is, i.e. to simulate manual changes in cluster
+func (c *Cluster) PutDeployment(ns string, deployment kube_types.Deployment) error {
+	deployment = copyDeployment(deployment)
+	deployment.Namespace = ns
+	return c.update(ns, func(n *namespace) error {
+		for i := range n.deployments {
+			if n.deployments[i].Name == deployment.Name {
+				n.deployments[i] = deployment
+				return nil
+			}
+		}
+		n.deployments = append(n.deployments, deployment)
+		return nil
+	})
+}
+
+// PutService creates or replaces service as is, i.e. to simulate manual changes in cluster
+func (c *Cluster) PutService(ns string, service kube_types.Service) error {
+	service = copyService(service)
+	service.Namespace = ns
+	return c.update(ns, func(n *namespace) error {
+		for i := range n.services {
+			if n.services[i].Name == service.Name {
+				n.services[i] = service
+				return nil
+			}
+		}
+		n.services = append(n.services, service)
+		return nil
+	})
+}
+
+func (c *Cluster) createDeployment(ns string, deployment kube_types.Deployment) error {
+	deployment = copyDeployment(deployment)
+	deployment.Namespace = ns
+	deployment.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	deployment.Active = true
+	return c.update(ns, func(n *namespace) error {
+		for _, d := range n.deployments {
+			if d.Name == deployment.Name {
+				return ErrResourceAlreadyExists().AddDetailF("deployment %s", deployment.Name)
+			}
+		}
+		n.deployments = append(n.deployments, deployment)
+		return nil
+	})
+}
+
+func (c *Cluster) createService(ns string, service kube_types.Service) error {
+	service = copyService(service)
+	service.Namespace = ns
+	service.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	return c.update(ns, func(n *namespace) error {
+		for _, svc := range n.services {
+			if svc.Name == service.Name {
+				return ErrResourceAlreadyExists().AddDetailF("service %s", service.Name)
+			}
+		}
+		n.services = append(n.services, service)
+		return nil
+	})
+}
+
+func (c *Cluster) updateDeployment(ns, name string, f func(d *kube_types.Deployment)) error {
+	return c.update(ns, func(n *namespace) error {
+		for i := range n.deployments {
+			if n.deployments[i].Name == name {
+				f(&n.deployments[i])
+				return nil
+			}
+		}
+		return ErrResourceNotExist().AddDetailF("deployment %s", name)
+	})
+}
+
+func (c *Cluster) updateService(ns, name string, f func(svc *kube_types.Service)) error {
+	return c.update(ns, func(n *namespace) error {
+		for i := range n.services {
+			if n.services[i].Name == name {
+				f(&n.services[i])
+				return nil
+			}
+		}
+		return ErrResourceNotExist().AddDetailF("service %s", name)
+	})
+}
+
+func (c *Cluster) deleteSolutionDeployments(ns, solution string) error {
+	return c.update(ns, func(n *namespace) error {
+		var deploys []kube_types.Deployment
+		for _, d := range n.deployments {
+			if d.SolutionID != solution {
+				deploys = append(deploys, d)
+			}
+		}
+		n.deployments = deploys
+		return nil
+	})
+}
+
+func (c *Cluster) deleteSolutionServices(ns, solution string) error {
+	return c.update(ns, func(n *namespace) error {
+		var services []kube_types.Service
+		for _, svc := range n.services {
+			if svc.SolutionID != solution {
+				services = append(services, svc)
+			}
+		}
+		n.services = services
+		return nil
+	})
+}
+
+func (c *Cluster) deleteDeployment(ns, name string) error {
+	return c.update(ns, func(n *namespace) error {
+		for i := range n.deployments {
+			if n.deployments[i].Name == name {
+				n.deployments = append(n.deployments[:i], n.deployments[i+1:]...)
+				return nil
+			}
+		}
+		return ErrResourceNotExist().AddDetailF("deployment %s", name)
+	})
+}
+
+func (c *Cluster) deleteService(ns, name string) error {
+	return c.update(ns, func(n *namespace) error {
+		for i := range n.services {
+			if n.services[i].Name == name {
+				n.services = append(n.services[:i], n.services[i+1:]...)
+				return nil
+			}
+		}
+		return ErrResourceNotExist().AddDetailF("service %s", name)
+	})
+}
+
+// copyDeployment returns deep copy of deployment, so stored resources are not shared with callers
+func copyDeployment(deployment kube_types.Deployment) kube_types.Deployment {
+	var ret kube_types.Deployment
+	clone(deployment, &ret)
+	return ret
+}
+
+// copyService returns deep copy of service, so stored resources are not shared with callers
+func copyService(service kube_types.Service) kube_types.Service {
+	var ret kube_types.Service
+	clone(service, &ret)
+	return ret
+}
+
+func clone(src, dst interface{}) {
+	data, err := json.Marshal(src)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		panic(err)
+	}
+}
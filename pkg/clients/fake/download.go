@@ -0,0 +1,141 @@
+package fake
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Hosts which are served by TemplateSource in the same way as by GitHub
+const (
+	RawFilesHost = "raw.githubusercontent.com"
+	APIHost      = "api.github.com"
+)
+
+// ErrFileNotFound is returned by TemplateSource if requested file doesn't exist
+var ErrFileNotFound = errors.New("unable to download file")
+
+type repository struct {
+	// branch -> commit
+	branches map[string]string
+	// commit -> file name -> content
+	commits map[string]map[string]string
+}
+
+// TemplateSource is a fake download client serving template repositories.
+// It serves raw files (https://raw.githubusercontent.com/{owner}/{repo}/{ref}/{file})
+// and branch commits (https://api.github.com/repos/{owner}/{repo}/commits/{ref}).
+// Ref may be branch name or commit returned by Push.
+type TemplateSource struct {
+	Recorder
+	mu    sync.RWMutex
+	repos map[string]*repository
+}
+
+// NewTemplateSource returns template source without repositories
+func NewTemplateSource() *TemplateSource {
+	return &TemplateSource{repos: make(map[string]*repository)}
+}
+
+// Push saves repository ("owner/repo") files as new branch commit and returns commit SHA.
+// Files of previous branch commit are not kept, so every push should contain all files.
+func (s *TemplateSource) Push(repo, branch string, files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00", repo, branch)
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s\x00%s\x00", name, files[name])
+	}
+	commit := hex.EncodeToString(hash.Sum(nil))
+
+	copied := make(map[string]string, len(files))
+	for name, content := range files {
+		copied[name] = content
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(repo)
+	r := s.repos[key]
+	if r == nil {
+		r = &repository{branches: make(map[string]string), commits: make(map[string]map[string]string)}
+		s.repos[key] = r
+	}
+	r.branches[branch] = commit
+	r.commits[commit] = copied
+	return commit
+}
+
+// Commit returns commit which repository branch points to
+func (s *TemplateSource) Commit(repo, branch string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r := s.repos[strings.ToLower(repo)]
+	if r == nil {
+		return "", false
+	}
+	commit, ok := r.branches[branch]
+	return commit, ok
+}
+
+// files returns repository files at ref
+func (s *TemplateSource) files(repo, ref string) (map[string]string, string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r := s.repos[strings.ToLower(repo)]
+	if r == nil {
+		return nil, "", false
+	}
+	if commit, ok := r.branches[ref]; ok {
+		ref = commit
+	}
+	files, ok := r.commits[ref]
+	return files, ref, ok
+}
+
+func (s *TemplateSource) DownloadFile(ctx context.Context, fileURL string) ([]byte, error) {
+	if err := s.record(ctx, "DownloadFile", fileURL); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case u.Host == RawFilesHost && len(path) >= 4:
+		files, _, ok := s.files(path[0]+"/"+path[1], path[2])
+		if !ok {
+			return nil, ErrFileNotFound
+		}
+		content, ok := files[strings.Join(path[3:], "/")]
+		if !ok {
+			return nil, ErrFileNotFound
+		}
+		return []byte(content), nil
+	case u.Host == APIHost && len(path) == 5 && path[0] == "repos" && path[3] == "commits":
+		_, commit, ok := s.files(path[1]+"/"+path[2], path[4])
+		if !ok {
+			return nil, ErrFileNotFound
+		}
+		return json.Marshal(map[string]string{"sha": commit})
+	default:
+		return nil, ErrFileNotFound
+	}
+}
+
+func (s *TemplateSource) Ping(ctx context.Context, url string) error {
+	return s.record(ctx, "Ping", url)
+}
@@ -0,0 +1,57 @@
+package fake
+
+import "strings"
+
+// Fixture template repository
+const (
+	FixtureRepo  = "containerum/fixture-solution"
+	FixtureURL   = "https://github.com/" + FixtureRepo
+	FixtureImage = "nginx:1.15"
+)
+
+const fixtureConfig = `{
+  "env": {
+    "PORT": "8080",
+    "PASSWORD": "{{rand_string 16}}"
+  },
+  "run": [
+    {"config_file": "web.json", "type": "deployment"},
+    {"config_file": "web-service.json", "type": "service"}
+  ]
+}`
+
+const fixtureDeployment = `{
+  "name": "web",
+  "replicas": 1,
+  "containers": [
+    {
+      "name": "web",
+      "image": "{image}",
+      "limits": {"cpu": 100, "memory": 128},
+      "env": [{"name": "PASSWORD", "value": "{{.PASSWORD}}"}]
+    }
+  ]
+}`
+
+const fixtureService = `{
+  "name": "web",
+  "deploy": "web",
+  "ports": [
+    {"name": "http", "port": {{.PORT}}, "target_port": 80, "protocol": "TCP"}
+  ]
+}`
+
+// FixtureFiles returns files of template with one deployment running image and one service.
+// Different images may be used to simulate template updates.
+func FixtureFiles(image string) map[string]string {
+	return map[string]string{
+		".containerum.json": fixtureConfig,
+		"web.json":          strings.Replace(fixtureDeployment, "{image}", image, 1),
+		"web-service.json":  fixtureService,
+	}
+}
+
+// PushFixture pushes fixture template running FixtureImage to FixtureRepo branch and returns commit SHA
+func (s *TemplateSource) PushFixture(branch string) string {
+	return s.Push(FixtureRepo, branch, FixtureFiles(FixtureImage))
+}
@@ -0,0 +1,77 @@
+package fake
+
+import (
+	"context"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// KubeAPIClient is a fake kube-api client listing resources of Cluster
+type KubeAPIClient struct {
+	Recorder
+	cluster *Cluster
+}
+
+// NewKubeAPIClient returns fake kube-api client working with cluster
+func NewKubeAPIClient(cluster *Cluster) *KubeAPIClient {
+	return &KubeAPIClient{cluster: cluster}
+}
+
+func (c *KubeAPIClient) GetUserDeployments(ctx context.Context, namespace, solutionName string) (*kube_types.DeploymentsList, error) {
+	if err := c.record(ctx, "GetUserDeployments", namespace, solutionName); err != nil {
+		return nil, err
+	}
+	deploys, err := c.cluster.Deployments(namespace, solutionName)
+	if err != nil {
+		return nil, err
+	}
+	return &kube_types.DeploymentsList{Deployments: deploys}, nil
+}
+
+func (c *KubeAPIClient) GetUserServices(ctx context.Context, namespace, solutionName string) (*kube_types.ServicesList, error) {
+	if err := c.record(ctx, "GetUserServices", namespace, solutionName); err != nil {
+		return nil, err
+	}
+	services, err := c.cluster.Services(namespace, solutionName)
+	if err != nil {
+		return nil, err
+	}
+	return &kube_types.ServicesList{Services: services}, nil
+}
+
+func (c *KubeAPIClient) GetNamespaceDeployments(ctx context.Context, namespace string) (*kube_types.DeploymentsList, error) {
+	if err := c.record(ctx, "GetNamespaceDeployments", namespace); err != nil {
+		return nil, err
+	}
+	deploys, err := c.cluster.Deployments(namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	return &kube_types.DeploymentsList{Deployments: deploys}, nil
+}
+
+func (c *KubeAPIClient) GetNamespaceServices(ctx context.Context, namespace string) (*kube_types.ServicesList, error) {
+	if err := c.record(ctx, "GetNamespaceServices", namespace); err != nil {
+		return nil, err
+	}
+	services, err := c.cluster.Services(namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	return &kube_types.ServicesList{Services: services}, nil
+}
+
+func (c *KubeAPIClient) GetNamespaces(ctx context.Context) (*kube_types.NamespacesList, error) {
+	if err := c.record(ctx, "GetNamespaces"); err != nil {
+		return nil, err
+	}
+	ret := kube_types.NamespacesList{Namespaces: make([]kube_types.Namespace, 0)}
+	for _, ns := range c.cluster.Namespaces() {
+		ret.Namespaces = append(ret.Namespaces, kube_types.Namespace{ID: ns, Label: ns})
+	}
+	return &ret, nil
+}
+
+func (c *KubeAPIClient) Ping(ctx context.Context) error {
+	return c.record(ctx, "Ping")
+}
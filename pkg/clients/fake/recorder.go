@@ -0,0 +1,122 @@
+// Package fake contains in-memory implementations of clients interfaces for tests and local development.
+//
+// Every fake records its calls and can be configured to fail:
+//
+//	cluster := fake.NewCluster("ns")
+//	resource := fake.NewResourceClient(cluster)
+//	resource.FailNext("CreateService", errors.New("boom"))
+//	...
+//	calls := resource.CallsTo("CreateDeployment")
+//
+// ResourceClient and KubeAPIClient share Cluster, so resources created with one of them
+// are returned by another one. TemplateSource serves template repositories instead of GitHub.
+package fake
+
+import (
+	"context"
+	"sync"
+)
+
+// AnyMethod may be passed to Fail and FailNext to fail calls of all methods
+const AnyMethod = "*"
+
+// Call is a recorded fake client method call
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+type failure struct {
+	err error
+	// number of calls to fail, 0 means all calls
+	times int
+}
+
+// Recorder records calls of fake client and injects failures.
+// It's embedded to all fakes.
+type Recorder struct {
+	mu       sync.Mutex
+	calls    []Call
+	failures map[string][]*failure
+}
+
+// Calls returns all recorded calls in call order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call{}, r.calls...)
+}
+
+// CallsTo returns recorded calls of method in call order
+func (r *Recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ret []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			ret = append(ret, call)
+		}
+	}
+	return ret
+}
+
+// Fail makes all following calls of method return err until Recover is called
+func (r *Recorder) Fail(method string, err error) {
+	r.addFailure(method, &failure{err: err})
+}
+
+// FailNext makes next call of method return err.
+// Failures are queued, so calling it twice fails two calls.
+func (r *Recorder) FailNext(method string, err error) {
+	r.addFailure(method, &failure{err: err, times: 1})
+}
+
+func (r *Recorder) addFailure(method string, f *failure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures == nil {
+		r.failures = make(map[string][]*failure)
+	}
+	r.failures[method] = append(r.failures[method], f)
+}
+
+// Recover removes all failures of method
+func (r *Recorder) Recover(method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, method)
+}
+
+// Reset removes all recorded calls and failures
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+	r.failures = nil
+}
+
+// record saves call and returns error if call should fail.
+// Method specific failures take precedence over AnyMethod ones.
+func (r *Recorder) record(ctx context.Context, method string, args ...interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, key := range []string{method, AnyMethod} {
+		failures := r.failures[key]
+		if len(failures) == 0 {
+			continue
+		}
+		f := failures[0]
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				r.failures[key] = failures[1:]
+			}
+		}
+		return f.err
+	}
+	return nil
+}
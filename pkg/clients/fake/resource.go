@@ -0,0 +1,95 @@
+package fake
+
+import (
+	"context"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// ResourceClient is a fake resource-service client creating resources in Cluster
+type ResourceClient struct {
+	Recorder
+	cluster *Cluster
+}
+
+// NewResourceClient returns fake resource-service client working with cluster
+func NewResourceClient(cluster *Cluster) *ResourceClient {
+	return &ResourceClient{cluster: cluster}
+}
+
+func (c *ResourceClient) CreateDeployment(ctx context.Context, namespace string, deployment kube_types.Deployment) error {
+	if err := c.record(ctx, "CreateDeployment", namespace, deployment); err != nil {
+		return err
+	}
+	return c.cluster.createDeployment(namespace, deployment)
+}
+
+func (c *ResourceClient) CreateService(ctx context.Context, namespace string, service kube_types.Service) error {
+	if err := c.record(ctx, "CreateService", namespace, service); err != nil {
+		return err
+	}
+	return c.cluster.createService(namespace, service)
+}
+
+func (c *ResourceClient) DeleteDeployments(ctx context.Context, namespace, solutionName string) error {
+	if err := c.record(ctx, "DeleteDeployments", namespace, solutionName); err != nil {
+		return err
+	}
+	return c.cluster.deleteSolutionDeployments(namespace, solutionName)
+}
+
+func (c *ResourceClient) DeleteServices(ctx context.Context, namespace, solutionName string) error {
+	if err := c.record(ctx, "DeleteServices", namespace, solutionName); err != nil {
+		return err
+	}
+	return c.cluster.deleteSolutionServices(namespace, solutionName)
+}
+
+func (c *ResourceClient) DeleteDeployment(ctx context.Context, namespace, deployment string) error {
+	if err := c.record(ctx, "DeleteDeployment", namespace, deployment); err != nil {
+		return err
+	}
+	return c.cluster.deleteDeployment(namespace, deployment)
+}
+
+func (c *ResourceClient) DeleteService(ctx context.Context, namespace, service string) error {
+	if err := c.record(ctx, "DeleteService", namespace, service); err != nil {
+		return err
+	}
+	return c.cluster.deleteService(namespace, service)
+}
+
+func (c *ResourceClient) SetDeploymentReplicas(ctx context.Context, namespace, deployment string, replicas int) error {
+	if err := c.record(ctx, "SetDeploymentReplicas", namespace, deployment, replicas); err != nil {
+		return err
+	}
+	return c.cluster.updateDeployment(namespace, deployment, func(d *kube_types.Deployment) {
+		d.Replicas = replicas
+	})
+}
+
+func (c *ResourceClient) UpdateDeployment(ctx context.Context, namespace string, deployment kube_types.Deployment) error {
+	if err := c.record(ctx, "UpdateDeployment", namespace, deployment); err != nil {
+		return err
+	}
+	deployment = copyDeployment(deployment)
+	return c.cluster.updateDeployment(namespace, deployment.Name, func(d *kube_types.Deployment) {
+		deployment.Namespace, deployment.CreatedAt, deployment.Active = d.Namespace, d.CreatedAt, d.Active
+		*d = deployment
+	})
+}
+
+func (c *ResourceClient) UpdateService(ctx context.Context, namespace string, service kube_types.Service) error {
+	if err := c.record(ctx, "UpdateService", namespace, service); err != nil {
+		return err
+	}
+	service = copyService(service)
+	return c.cluster.updateService(namespace, service.Name, func(svc *kube_types.Service) {
+		service.Namespace, service.CreatedAt = svc.Namespace, svc.CreatedAt
+		*svc = service
+	})
+}
+
+func (c *ResourceClient) Ping(ctx context.Context) error {
+	return c.record(ctx, "Ping")
+}
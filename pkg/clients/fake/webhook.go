@@ -0,0 +1,22 @@
+package fake
+
+import "context"
+
+// WebhookClient is a fake webhook client which records sent webhooks without sending them
+type WebhookClient struct {
+	Recorder
+}
+
+// NewWebhookClient returns fake webhook client
+func NewWebhookClient() *WebhookClient {
+	return &WebhookClient{}
+}
+
+// CheckURL allows any URL unless failure is injected
+func (c *WebhookClient) CheckURL(ctx context.Context, url string) error {
+	return c.record(ctx, "CheckURL", url)
+}
+
+func (c *WebhookClient) SendWebhook(ctx context.Context, url string, headers map[string]string, payload []byte) error {
+	return c.record(ctx, "SendWebhook", url, headers, append([]byte{}, payload...))
+}
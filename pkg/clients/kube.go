@@ -2,16 +2,16 @@ package clients
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	kube_types "github.com/containerum/kube-client/pkg/model"
 
 	"github.com/containerum/cherry"
 	utils "github.com/containerum/utils/httputil"
 
-	"time"
-
+	solutils "git.containerum.net/ch/solutions/pkg/utils"
 	"github.com/go-resty/resty"
-	"github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,32 +19,48 @@ import (
 type KubeAPIClient interface {
 	GetUserDeployments(ctx context.Context, namespace, solutionName string) (*kube_types.DeploymentsList, error)
 	GetUserServices(ctx context.Context, namespace, solutionName string) (*kube_types.ServicesList, error)
+	GetNamespaceDeployments(ctx context.Context, namespace string) (*kube_types.DeploymentsList, error)
+	GetNamespaceServices(ctx context.Context, namespace string) (*kube_types.ServicesList, error)
+	GetNamespaces(ctx context.Context) (*kube_types.NamespacesList, error)
+	// Ping checks if kube-api is available
+	Ping(ctx context.Context) error
 }
 
 type httpKubeAPIClient struct {
-	rest *resty.Client
-	log  *logrus.Entry
+	rest      *resty.Client
+	transport *retryTransport
+	log       *logrus.Entry
 }
 
 // NewHTTPKubeAPIClient returns client for resource-service working via restful api
-func NewHTTPKubeAPIClient(serverURL string, debug bool) KubeAPIClient {
+func NewHTTPKubeAPIClient(serverURL string, config TransportConfig, debug bool) KubeAPIClient {
 	log := logrus.WithField("component", "kube_api_client")
+	transport := newTransport("kube_api_client", nil, config, log)
 	client := resty.New().
 		SetHostURL(serverURL).
 		SetLogger(log.WriterLevel(logrus.DebugLevel)).
 		SetDebug(debug).
-		SetTimeout(3 * time.Second).
+		SetTransport(transport).
 		SetError(cherry.Err{})
-	client.JSONMarshal = jsoniter.Marshal
-	client.JSONUnmarshal = jsoniter.Unmarshal
+	client.JSONMarshal = json.Marshal
+	client.JSONUnmarshal = json.Unmarshal
 	return &httpKubeAPIClient{
-		rest: client,
-		log:  log,
+		rest:      client,
+		transport: transport,
+		log:       log,
 	}
 }
 
+func (c *httpKubeAPIClient) SetTransportConfig(config TransportConfig) {
+	c.transport.SetTransportConfig(config)
+}
+
+func (c *httpKubeAPIClient) logger(ctx context.Context) *logrus.Entry {
+	return solutils.LogEntry(ctx, c.log)
+}
+
 func (c *httpKubeAPIClient) GetUserDeployments(ctx context.Context, namespace, solutionName string) (*kube_types.DeploymentsList, error) {
-	c.log.Info("Getting solution deployments")
+	c.logger(ctx).Info("Getting solution deployments")
 	headersMap := utils.RequestHeadersMap(ctx)
 
 	var dlist kube_types.DeploymentsList
@@ -57,7 +73,7 @@ func (c *httpKubeAPIClient) GetUserDeployments(ctx context.Context, namespace, s
 		}).
 		Get("/namespaces/{namespace}/solutions/{solution}/deployments")
 	if err != nil {
-		return nil, err
+		return nil, requestError(err)
 	}
 	if resp.Error() != nil {
 		return nil, resp.Error().(*cherry.Err)
@@ -67,7 +83,7 @@ func (c *httpKubeAPIClient) GetUserDeployments(ctx context.Context, namespace, s
 }
 
 func (c *httpKubeAPIClient) GetUserServices(ctx context.Context, namespace, solutionName string) (*kube_types.ServicesList, error) {
-	c.log.Info("Getting solution services")
+	c.logger(ctx).Info("Getting solution services")
 	headersMap := utils.RequestHeadersMap(ctx)
 
 	var slist kube_types.ServicesList
@@ -81,7 +97,7 @@ func (c *httpKubeAPIClient) GetUserServices(ctx context.Context, namespace, solu
 		}).
 		Get("/namespaces/{namespace}/solutions/{solution}/services")
 	if err != nil {
-		return nil, err
+		return nil, requestError(err)
 	}
 	if resp.Error() != nil {
 		return nil, resp.Error().(*cherry.Err)
@@ -89,3 +105,79 @@ func (c *httpKubeAPIClient) GetUserServices(ctx context.Context, namespace, solu
 
 	return &slist, nil
 }
+
+func (c *httpKubeAPIClient) GetNamespaceDeployments(ctx context.Context, namespace string) (*kube_types.DeploymentsList, error) {
+	c.logger(ctx).Info("Getting namespace deployments")
+	headersMap := utils.RequestHeadersMap(ctx)
+
+	var dlist kube_types.DeploymentsList
+	resp, err := c.rest.R().SetContext(ctx).
+		SetResult(&dlist).
+		SetHeaders(headersMap).
+		SetPathParams(map[string]string{
+			"namespace": namespace,
+		}).
+		Get("/namespaces/{namespace}/deployments")
+	if err != nil {
+		return nil, requestError(err)
+	}
+	if resp.Error() != nil {
+		return nil, resp.Error().(*cherry.Err)
+	}
+
+	return &dlist, nil
+}
+
+func (c *httpKubeAPIClient) GetNamespaceServices(ctx context.Context, namespace string) (*kube_types.ServicesList, error) {
+	c.logger(ctx).Info("Getting namespace services")
+	headersMap := utils.RequestHeadersMap(ctx)
+
+	var slist kube_types.ServicesList
+	resp, err := c.rest.R().SetContext(ctx).
+		SetResult(&slist).
+		SetHeaders(headersMap).
+		SetPathParams(map[string]string{
+			"namespace": namespace,
+		}).
+		Get("/namespaces/{namespace}/services")
+	if err != nil {
+		return nil, requestError(err)
+	}
+	if resp.Error() != nil {
+		return nil, resp.Error().(*cherry.Err)
+	}
+
+	return &slist, nil
+}
+
+func (c *httpKubeAPIClient) GetNamespaces(ctx context.Context) (*kube_types.NamespacesList, error) {
+	c.logger(ctx).Info("Getting namespaces")
+	headersMap := utils.RequestHeadersMap(ctx)
+
+	var nslist kube_types.NamespacesList
+	resp, err := c.rest.R().SetContext(ctx).
+		SetResult(&nslist).
+		SetHeaders(headersMap).
+		Get("/namespaces")
+	if err != nil {
+		return nil, requestError(err)
+	}
+	if resp.Error() != nil {
+		return nil, resp.Error().(*cherry.Err)
+	}
+
+	return &nslist, nil
+}
+
+func (c *httpKubeAPIClient) Ping(ctx context.Context) error {
+	c.logger(ctx).Debugln("Checking service status")
+	resp, err := c.rest.R().SetContext(ctx).
+		Get("/status")
+	if err != nil {
+		return requestError(err)
+	}
+	if resp.StatusCode() > 399 {
+		return fmt.Errorf("service status is %s", resp.Status())
+	}
+	return nil
+}
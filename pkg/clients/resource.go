@@ -2,17 +2,17 @@ package clients
 
 import (
 	"context"
+	"encoding/json"
 
 	"fmt"
 
-	"time"
-
+	solutils "git.containerum.net/ch/solutions/pkg/utils"
 	"github.com/containerum/cherry"
 	kube_types "github.com/containerum/kube-client/pkg/model"
 	"github.com/containerum/utils/httputil"
 	utils "github.com/containerum/utils/httputil"
 	"github.com/go-resty/resty"
-	"github.com/json-iterator/go"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,43 +22,62 @@ type ResourceClient interface {
 	CreateService(ctx context.Context, namespace string, service kube_types.Service) error
 	DeleteDeployments(ctx context.Context, namespace, solutionName string) error
 	DeleteServices(ctx context.Context, namespace, solutionName string) error
+	DeleteDeployment(ctx context.Context, namespace, deployment string) error
+	DeleteService(ctx context.Context, namespace, service string) error
+	SetDeploymentReplicas(ctx context.Context, namespace, deployment string, replicas int) error
+	UpdateDeployment(ctx context.Context, namespace string, deployment kube_types.Deployment) error
+	UpdateService(ctx context.Context, namespace string, service kube_types.Service) error
+	// Ping checks if resource-service is available
+	Ping(ctx context.Context) error
 }
 
 type httpResourceClient struct {
-	rest *resty.Client
-	log  *logrus.Entry
+	rest      *resty.Client
+	transport *retryTransport
+	log       *logrus.Entry
 }
 
 // NewHTTPResourceClient returns client for resource-service working via restful api
-func NewHTTPResourceClient(serverURL string, debug bool) ResourceClient {
+func NewHTTPResourceClient(serverURL string, config TransportConfig, debug bool) ResourceClient {
 	log := logrus.WithField("component", "resource_client")
+	transport := newTransport("resource_client", nil, config, log)
 	client := resty.New().
 		SetHostURL(serverURL).
 		SetLogger(log.WriterLevel(logrus.DebugLevel)).
 		SetDebug(debug).
-		SetTimeout(3*time.Second).
+		SetTransport(transport).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Accept", "application/json").
 		SetError(cherry.Err{})
-	client.JSONMarshal = jsoniter.Marshal
-	client.JSONUnmarshal = jsoniter.Unmarshal
+	client.JSONMarshal = json.Marshal
+	client.JSONUnmarshal = json.Unmarshal
 	return &httpResourceClient{
-		rest: client,
-		log:  log,
+		rest:      client,
+		transport: transport,
+		log:       log,
 	}
 }
 
+func (c *httpResourceClient) SetTransportConfig(config TransportConfig) {
+	c.transport.SetTransportConfig(config)
+}
+
+func (c *httpResourceClient) logger(ctx context.Context) *logrus.Entry {
+	return solutils.LogEntry(ctx, c.log)
+}
+
 func (c *httpResourceClient) CreateDeployment(ctx context.Context, namespace string, deployment kube_types.Deployment) error {
-	c.log.Info("Creating deployment")
+	c.logger(ctx).Info("Creating deployment")
 	resp, err := c.rest.R().SetContext(ctx).
 		SetBody(deployment).
 		SetHeaders(httputil.RequestXHeadersMap(ctx)).
+		SetHeader(IdempotencyKeyHeader, uuid.New().String()).
 		SetPathParams(map[string]string{
 			"namespace": namespace,
 		}).
 		Post("/namespaces/{namespace}/deployments")
 	if err != nil {
-		return err
+		return requestError(err)
 	}
 	if resp.Error() != nil {
 		return resp.Error().(*cherry.Err)
@@ -67,16 +86,17 @@ func (c *httpResourceClient) CreateDeployment(ctx context.Context, namespace str
 }
 
 func (c *httpResourceClient) CreateService(ctx context.Context, namespace string, service kube_types.Service) error {
-	c.log.Info("Creating service")
+	c.logger(ctx).Info("Creating service")
 	resp, err := c.rest.R().SetContext(ctx).
 		SetBody(service).
 		SetHeaders(httputil.RequestXHeadersMap(ctx)).
+		SetHeader(IdempotencyKeyHeader, uuid.New().String()).
 		SetPathParams(map[string]string{
 			"namespace": namespace,
 		}).
 		Post("/namespaces/{namespace}/services")
 	if err != nil {
-		return err
+		return requestError(err)
 	}
 	if resp.Error() != nil {
 		return resp.Error().(*cherry.Err)
@@ -85,12 +105,12 @@ func (c *httpResourceClient) CreateService(ctx context.Context, namespace string
 }
 
 func (c *httpResourceClient) DeleteDeployments(ctx context.Context, namespace, solutionName string) error {
-	c.log.Info("Deleting deployments")
+	c.logger(ctx).Info("Deleting deployments")
 	resp, err := c.rest.R().SetContext(ctx).
 		SetHeaders(utils.RequestHeadersMap(ctx)).
 		Delete(fmt.Sprintf("/namespaces/%s/solutions/%s/deployments", namespace, solutionName))
 	if err != nil {
-		return err
+		return requestError(err)
 	}
 	if resp.Error() != nil {
 		return resp.Error().(*cherry.Err)
@@ -99,15 +119,121 @@ func (c *httpResourceClient) DeleteDeployments(ctx context.Context, namespace, s
 }
 
 func (c *httpResourceClient) DeleteServices(ctx context.Context, namespace, solutionName string) error {
-	c.log.Info("Deleting services")
+	c.logger(ctx).Info("Deleting services")
 	resp, err := c.rest.R().SetContext(ctx).
 		SetHeaders(utils.RequestHeadersMap(ctx)).
 		Delete(fmt.Sprintf("/namespaces/%s/solutions/%s/services", namespace, solutionName))
 	if err != nil {
-		return err
+		return requestError(err)
+	}
+	if resp.Error() != nil {
+		return resp.Error().(*cherry.Err)
+	}
+	return nil
+}
+
+func (c *httpResourceClient) DeleteDeployment(ctx context.Context, namespace, deployment string) error {
+	c.logger(ctx).Info("Deleting deployment")
+	resp, err := c.rest.R().SetContext(ctx).
+		SetHeaders(utils.RequestHeadersMap(ctx)).
+		SetPathParams(map[string]string{
+			"namespace":  namespace,
+			"deployment": deployment,
+		}).
+		Delete("/namespaces/{namespace}/deployments/{deployment}")
+	if err != nil {
+		return requestError(err)
+	}
+	if resp.Error() != nil {
+		return resp.Error().(*cherry.Err)
+	}
+	return nil
+}
+
+func (c *httpResourceClient) DeleteService(ctx context.Context, namespace, service string) error {
+	c.logger(ctx).Info("Deleting service")
+	resp, err := c.rest.R().SetContext(ctx).
+		SetHeaders(utils.RequestHeadersMap(ctx)).
+		SetPathParams(map[string]string{
+			"namespace": namespace,
+			"service":   service,
+		}).
+		Delete("/namespaces/{namespace}/services/{service}")
+	if err != nil {
+		return requestError(err)
+	}
+	if resp.Error() != nil {
+		return resp.Error().(*cherry.Err)
+	}
+	return nil
+}
+
+func (c *httpResourceClient) SetDeploymentReplicas(ctx context.Context, namespace, deployment string, replicas int) error {
+	c.logger(ctx).Info("Scaling deployment")
+	resp, err := c.rest.R().SetContext(ctx).
+		SetBody(kube_types.UpdateReplicas{Replicas: replicas}).
+		SetHeaders(httputil.RequestXHeadersMap(ctx)).
+		SetPathParams(map[string]string{
+			"namespace":  namespace,
+			"deployment": deployment,
+		}).
+		Put("/namespaces/{namespace}/deployments/{deployment}/replicas")
+	if err != nil {
+		return requestError(err)
+	}
+	if resp.Error() != nil {
+		return resp.Error().(*cherry.Err)
+	}
+	return nil
+}
+
+func (c *httpResourceClient) UpdateDeployment(ctx context.Context, namespace string, deployment kube_types.Deployment) error {
+	c.logger(ctx).Info("Updating deployment")
+	resp, err := c.rest.R().SetContext(ctx).
+		SetBody(deployment).
+		SetHeaders(httputil.RequestXHeadersMap(ctx)).
+		SetPathParams(map[string]string{
+			"namespace":  namespace,
+			"deployment": deployment.Name,
+		}).
+		Put("/namespaces/{namespace}/deployments/{deployment}")
+	if err != nil {
+		return requestError(err)
+	}
+	if resp.Error() != nil {
+		return resp.Error().(*cherry.Err)
+	}
+	return nil
+}
+
+func (c *httpResourceClient) UpdateService(ctx context.Context, namespace string, service kube_types.Service) error {
+	c.logger(ctx).Info("Updating service")
+	resp, err := c.rest.R().SetContext(ctx).
+		SetBody(service).
+		SetHeaders(httputil.RequestXHeadersMap(ctx)).
+		SetPathParams(map[string]string{
+			"namespace": namespace,
+			"service":   service.Name,
+		}).
+		Put("/namespaces/{namespace}/services/{service}")
+	if err != nil {
+		return requestError(err)
 	}
 	if resp.Error() != nil {
 		return resp.Error().(*cherry.Err)
 	}
 	return nil
 }
+
+func (c *httpResourceClient) Ping(ctx context.Context) error {
+	c.logger(ctx).Debugln("Checking service status")
+	resp, err := c.rest.R().SetContext(ctx).
+		Get("/status")
+	if err != nil {
+		return requestError(err)
+	}
+	if resp.StatusCode() > 399 {
+		return fmt.Errorf("service status is %s", resp.Status())
+	}
+	return nil
+}
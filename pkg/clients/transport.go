@@ -0,0 +1,311 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	solutils "git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/containerum/cherry"
+	"github.com/sirupsen/logrus"
+)
+
+// IdempotencyKeyHeader marks non-idempotent requests (i.e. creations) which are safe to retry.
+// Upstream is expected to apply request only once for the same key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// TransportConfig describes timeouts, retries and circuit breaking of client requests
+type TransportConfig struct {
+	// Timeout limits every request attempt (including response body reading)
+	Timeout time.Duration
+	// Retries is a max count of retries of failed idempotent requests (0 disables retries)
+	Retries int
+	// RetryBackoff is a delay before first retry, doubled on every next retry up to RetryMaxBackoff.
+	// Actual delay is randomized between half and full value.
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// BreakerThreshold is a count of consecutive failed requests to upstream which opens circuit (0 disables breaker)
+	BreakerThreshold int
+	// BreakerCooldown is a time while requests to upstream are rejected after circuit was opened.
+	// Single trial request is allowed after cooldown, circuit is closed if it succeeds.
+	BreakerCooldown time.Duration
+}
+
+// DefaultTransportConfig is a transport config used if clients are not configured explicitly
+var DefaultTransportConfig = TransportConfig{
+	Timeout:          3 * time.Second,
+	Retries:          3,
+	RetryBackoff:     100 * time.Millisecond,
+	RetryMaxBackoff:  2 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// Reconfigurable is implemented by clients which transport config may be replaced at runtime
+type Reconfigurable interface {
+	// SetTransportConfig applies config to new requests. Circuit breakers states are reset.
+	SetTransportConfig(config TransportConfig)
+}
+
+type retryTransport struct {
+	component string
+	base      http.RoundTripper
+	log       *logrus.Entry
+
+	mu       sync.Mutex
+	config   TransportConfig
+	breakers map[string]*circuitBreaker // host -> breaker
+}
+
+// newTransport returns http.RoundTripper applying config to every request sent with base (default transport if nil).
+// Every attempt is traced separately.
+func newTransport(component string, base http.RoundTripper, config TransportConfig, log *logrus.Entry) *retryTransport {
+	return &retryTransport{
+		component: component,
+		config:    config,
+		base:      tracing.NewTransport(component, base),
+		log:       log,
+		breakers:  make(map[string]*circuitBreaker),
+	}
+}
+
+func (t *retryTransport) SetTransportConfig(config TransportConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = config
+	t.breakers = make(map[string]*circuitBreaker)
+}
+
+// breaker returns host circuit breaker and config which request is sent with
+func (t *retryTransport) breaker(host string) (*circuitBreaker, TransportConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cb, ok := t.breakers[host]
+	if !ok {
+		cb = &circuitBreaker{threshold: t.config.BreakerThreshold, cooldown: t.config.BreakerCooldown}
+		t.breakers[host] = cb
+	}
+	return cb, t.config
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	cb, config := t.breaker(req.URL.Host)
+	retries := 0
+	if retryable(req) {
+		retries = config.Retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if !cb.allow(time.Now()) {
+			return nil, solerrors.ErrUpstreamUnavailable().
+				AddDetailF("circuit breaker for %s is open", req.URL.Host).
+				WithField("upstream", req.URL.Host)
+		}
+
+		resp, err := t.attempt(req, attempt, config.Timeout)
+		if ctx.Err() != nil {
+			// request was canceled by caller, it says nothing about upstream
+			cb.release()
+			return resp, err
+		}
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		opened := cb.done(!failed, time.Now())
+		if opened {
+			metrics.ClientCircuitOpens.Inc(t.component, req.URL.Host)
+			solutils.LogEntry(ctx, t.log).WithField("upstream", req.URL.Host).Warnln("Circuit breaker opened")
+		}
+
+		// there is no sense to retry if circuit is opened, so last upstream error is returned
+		if attempt >= retries || opened || !retryableResult(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			// drain body to reuse connection
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		delay := backoff(config, attempt)
+		entry := solutils.LogEntry(ctx, t.log).WithField("URL", req.URL.String()).WithField("delay", delay)
+		if err != nil {
+			entry = entry.WithError(err)
+		} else {
+			entry = entry.WithField("status", resp.StatusCode)
+		}
+		entry.Warnln("Request failed, retrying")
+		metrics.ClientRetries.Inc(t.component)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// attempt sends request once with attempt timeout.
+// Timeout is released when response body is closed.
+func (t *retryTransport) attempt(req *http.Request, attempt int, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	}
+
+	attemptReq := req.WithContext(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+
+	resp, err := t.base.RoundTrip(attemptReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns delay before retry with jitter
+func backoff(config TransportConfig, attempt int) time.Duration {
+	delay := config.RetryBackoff << uint(attempt)
+	if delay <= 0 || (config.RetryMaxBackoff > 0 && delay > config.RetryMaxBackoff) {
+		delay = config.RetryMaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// retryable checks if request may be sent again: its method is idempotent or it has idempotency key,
+// and its body may be recreated
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// retryableResult checks if request failed because of transient error
+func retryableResult(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker rejects requests to upstream after threshold of consecutive failures
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// allow checks if request may be sent now
+func (cb *circuitBreaker) allow(now time.Time) bool {
+	if cb.threshold <= 0 {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case breakerOpen:
+		if now.Sub(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		// only one trial request is allowed until its result is known
+		cb.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	}
+	return true
+}
+
+// release finishes request without result, so next trial request may be sent in half-open state
+func (cb *circuitBreaker) release() {
+	if cb.threshold <= 0 {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == breakerHalfOpen {
+		cb.state = breakerOpen
+	}
+}
+
+// done records request result. Returns true if circuit was opened.
+func (cb *circuitBreaker) done(success bool, now time.Time) bool {
+	if cb.threshold <= 0 {
+		return false
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if success {
+		cb.state = breakerClosed
+		cb.failures = 0
+		return false
+	}
+	cb.failures++
+	if cb.state == breakerHalfOpen || (cb.state == breakerClosed && cb.failures >= cb.threshold) {
+		cb.state = breakerOpen
+		cb.openedAt = now
+		return true
+	}
+	return false
+}
+
+// requestError returns solutions error produced by transport (i.e. open circuit) instead of *url.Error wrapping it
+func requestError(err error) error {
+	if uerr, ok := err.(*url.Error); ok {
+		if cherr, ok := uerr.Err.(*cherry.Err); ok {
+			return cherr
+		}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	solutils "git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/go-resty/resty"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookClient is an interface to webhook receivers.
+type WebhookClient interface {
+	// CheckURL returns error if webhooks can't be sent to URL because of destination policy
+	CheckURL(ctx context.Context, url string) error
+	SendWebhook(ctx context.Context, url string, headers map[string]string, payload []byte) error
+}
+
+type httpWebhookClient struct {
+	rest      *resty.Client
+	transport *retryTransport
+	policy    DestinationPolicy
+	log       *logrus.Entry
+}
+
+// NewHTTPWebhookClient returns client sending webhooks as JSON POST requests to addresses allowed by policy.
+// Failed deliveries are retried by caller, so config usually sets only timeout.
+func NewHTTPWebhookClient(config TransportConfig, policy DestinationPolicy, debug bool) WebhookClient {
+	log := logrus.WithField("component", "webhook_client")
+	transport := newTransport("webhook_client", policy.Transport(), config, log)
+	client := resty.New().
+		SetLogger(log.WriterLevel(logrus.DebugLevel)).
+		SetDebug(debug).
+		SetTransport(transport).
+		SetHeader("Content-Type", "application/json")
+	return &httpWebhookClient{
+		rest:      client,
+		transport: transport,
+		policy:    policy,
+		log:       log,
+	}
+}
+
+func (c *httpWebhookClient) SetTransportConfig(config TransportConfig) {
+	c.transport.SetTransportConfig(config)
+}
+
+func (c *httpWebhookClient) logger(ctx context.Context) *logrus.Entry {
+	return solutils.LogEntry(ctx, c.log)
+}
+
+func (c *httpWebhookClient) CheckURL(ctx context.Context, url string) error {
+	return c.policy.CheckURL(ctx, url)
+}
+
+func (c *httpWebhookClient) SendWebhook(ctx context.Context, url string, headers map[string]string, payload []byte) error {
+	c.logger(ctx).WithField("URL", url).Infoln("Sending webhook")
+
+	resp, err := c.rest.R().
+		SetContext(ctx).
+		SetHeaders(headers).
+		SetBody(payload).
+		Post(url)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 299 {
+		return fmt.Errorf("webhook receiver responded with status %s", resp.Status())
+	}
+	return nil
+}
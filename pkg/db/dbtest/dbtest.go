@@ -0,0 +1,143 @@
+// Package dbtest contains conformance tests which every db.DB implementation must pass,
+// so all storage backends behave in the same way. Implementation test should call RunConformance:
+//
+//	func TestConformance(t *testing.T) {
+//		dbtest.RunConformance(t, func(t *testing.T) db.DB {
+//			return memory.NewDB()
+//		})
+//	}
+//
+// Tests don't expect empty database: every test creates templates, solutions, etc. with unique names,
+// so single database (i.e. postgres database with applied migrations) may be shared by all tests.
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/google/uuid"
+)
+
+// Factory returns database for test. Tests don't close database.
+type Factory func(t *testing.T) db.DB
+
+// RunConformance runs all conformance tests against databases returned by newDB
+func RunConformance(t *testing.T, newDB Factory) {
+	for _, test := range []struct {
+		name string
+		run  func(t *testing.T, database db.DB)
+	}{
+		{"Templates", testTemplates},
+		{"TemplatesActivation", testTemplatesActivation},
+		{"TemplateInUse", testTemplateInUse},
+		{"Solutions", testSolutions},
+		{"SolutionsUniqueName", testSolutionsUniqueName},
+		{"SolutionsSoftDelete", testSolutionsSoftDelete},
+		{"SolutionsCompleteDelete", testSolutionsCompleteDelete},
+		{"SolutionsState", testSolutionsState},
+		{"SolutionsVersion", testSolutionsVersion},
+		{"SolutionsSettings", testSolutionsSettings},
+		{"SolutionsExpiry", testSolutionsExpiry},
+		{"SolutionResources", testSolutionResources},
+		{"SolutionLeases", testSolutionLeases},
+		{"Events", testEvents},
+		{"Webhooks", testWebhooks},
+		{"WebhookDeliveries", testWebhookDeliveries},
+		{"TransactionCommit", testTransactionCommit},
+		{"TransactionRollback", testTransactionRollback},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newDB(t))
+		})
+	}
+}
+
+var errTest = errors.New("test error")
+
+func testTransactionCommit(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	name := uniqueName("solution")
+	namespace := uniqueName("ns")
+
+	err := database.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := tx.AddSolution(ctx, kube_types.Solution{Name: name, Namespace: namespace, Branch: "master"},
+			newID(), tmpl.ID, newID(), "{}", nil); err != nil {
+			return err
+		}
+		// changes are visible inside transaction
+		_, err := tx.GetSolution(ctx, namespace, name)
+		return err
+	})
+	expectNoError(t, err)
+
+	_, err = database.GetSolution(ctx, namespace, name)
+	expectNoError(t, err)
+}
+
+func testTransactionRollback(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	name := uniqueName("solution")
+	namespace := uniqueName("ns")
+
+	err := database.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := tx.AddSolution(ctx, kube_types.Solution{Name: name, Namespace: namespace, Branch: "master"},
+			newID(), tmpl.ID, newID(), "{}", nil); err != nil {
+			return err
+		}
+		if err := tx.DeactivateTemplate(ctx, tmpl.Name); err != nil {
+			return err
+		}
+		return errTest
+	})
+	if err != errTest {
+		t.Fatalf("Transaction error is not forwarded: %v", err)
+	}
+
+	_, err = database.GetSolution(ctx, namespace, name)
+	expectError(t, err, solerrors.ErrSolutionNotExist())
+	_, err = database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+}
+
+func newID() string {
+	return uuid.New().String()
+}
+
+func uniqueName(prefix string) string {
+	return prefix + "-" + uuid.New().String()[:8]
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+// expectError checks that err is expected solutions error.
+// Any error is expected if expected is nil.
+func expectError(t *testing.T, err error, expected *cherry.Err) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	if expected != nil && !cherry.Equals(err, expected) {
+		t.Fatalf("Expected %v, got %v", expected, err)
+	}
+}
+
+// expectDBError checks that err is expected db error (i.e. db.ErrUniqueViolation)
+func expectDBError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if err != expected {
+		t.Fatalf("Expected %v, got %v", expected, err)
+	}
+}
@@ -0,0 +1,74 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+)
+
+func testEvents(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	namespace := uniqueName("ns")
+	start := time.Now().Add(-time.Second)
+
+	first := model.Event{ID: newID(), Action: model.EventRunSolution, Outcome: "success", UserID: newID(), UserRole: "user",
+		Namespace: namespace, Solution: "first", Template: "template", RequestID: "request"}
+	expectNoError(t, database.AddEvent(ctx, first))
+	// events are ordered by creation time
+	time.Sleep(10 * time.Millisecond)
+	second := model.Event{ID: newID(), Action: model.EventDeleteSolution, Outcome: "failure", Error: "error",
+		Namespace: namespace, Solution: "second"}
+	expectNoError(t, database.AddEvent(ctx, second))
+
+	events, err := database.GetEvents(ctx, model.EventsFilter{Namespace: namespace})
+	expectNoError(t, err)
+	if len(events.Events) != 2 || events.Events[0].ID != second.ID || events.Events[1].ID != first.ID {
+		t.Fatalf("Unexpected events %+v", events.Events)
+	}
+	got := events.Events[1]
+	if _, err := time.Parse(time.RFC3339, got.Time); err != nil {
+		t.Fatalf("Invalid event time %q", got.Time)
+	}
+	got.Time = ""
+	if got != first {
+		t.Fatalf("Unexpected event %+v, expected %+v", got, first)
+	}
+
+	for _, test := range []struct {
+		filter   model.EventsFilter
+		expected []string
+	}{
+		{model.EventsFilter{Namespace: namespace, Limit: 1}, []string{second.ID}},
+		{model.EventsFilter{Namespace: namespace, Solution: "first"}, []string{first.ID}},
+		{model.EventsFilter{Namespace: namespace, Action: model.EventDeleteSolution}, []string{second.ID}},
+		{model.EventsFilter{Namespace: namespace, Outcome: "success"}, []string{first.ID}},
+		{model.EventsFilter{Namespace: namespace, UserID: first.UserID}, []string{first.ID}},
+		{model.EventsFilter{Namespace: namespace, Template: "template"}, []string{first.ID}},
+		{model.EventsFilter{Namespace: namespace, Since: start}, []string{second.ID, first.ID}},
+		{model.EventsFilter{Namespace: namespace, Until: start}, nil},
+		{model.EventsFilter{Namespace: uniqueName("ns")}, nil},
+	} {
+		events, err := database.GetEvents(ctx, test.filter)
+		expectNoError(t, err)
+		if events.Events == nil {
+			t.Fatalf("Nil events list")
+		}
+		var ids []string
+		for _, event := range events.Events {
+			ids = append(ids, event.ID)
+		}
+		if len(ids) != len(test.expected) {
+			t.Fatalf("Filter %+v: expected events %v, got %v", test.filter, test.expected, ids)
+		}
+		for i := range ids {
+			if ids[i] != test.expected[i] {
+				t.Fatalf("Filter %+v: expected events %v, got %v", test.filter, test.expected, ids)
+			}
+		}
+	}
+
+	expectDBError(t, database.AddEvent(ctx, first), db.ErrUniqueViolation)
+}
@@ -0,0 +1,34 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+)
+
+func testSolutionLeases(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	namespace, name := uniqueName("ns"), uniqueName("solution")
+	holder, other := newID(), newID()
+	now := time.Now()
+
+	// lease of not existing solution may be taken and prolonged by holder
+	expectNoError(t, database.AcquireSolutionLease(ctx, namespace, name, holder, now, now.Add(time.Minute)))
+	expectNoError(t, database.AcquireSolutionLease(ctx, namespace, name, holder, now, now.Add(2*time.Minute)))
+	expectDBError(t, database.AcquireSolutionLease(ctx, namespace, name, other, now.Add(time.Minute), now.Add(2*time.Minute)), db.ErrLeaseConflict)
+	// leases of other solutions are independent
+	expectNoError(t, database.AcquireSolutionLease(ctx, namespace, uniqueName("solution"), other, now, now.Add(time.Minute)))
+
+	// expired lease is taken over, so previous holder loses it
+	expectNoError(t, database.AcquireSolutionLease(ctx, namespace, name, other, now.Add(2*time.Minute), now.Add(3*time.Minute)))
+	expectDBError(t, database.AcquireSolutionLease(ctx, namespace, name, holder, now.Add(2*time.Minute), now.Add(3*time.Minute)), db.ErrLeaseConflict)
+
+	// lease is released by its holder only
+	expectNoError(t, database.ReleaseSolutionLease(ctx, namespace, name, holder))
+	expectDBError(t, database.AcquireSolutionLease(ctx, namespace, name, holder, now, now.Add(time.Minute)), db.ErrLeaseConflict)
+	expectNoError(t, database.ReleaseSolutionLease(ctx, namespace, name, other))
+	expectNoError(t, database.AcquireSolutionLease(ctx, namespace, name, holder, now, now.Add(time.Minute)))
+	expectNoError(t, database.ReleaseSolutionLease(ctx, namespace, name, holder))
+}
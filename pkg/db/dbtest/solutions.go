@@ -0,0 +1,379 @@
+package dbtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// addSolution adds solution of template in unique namespace
+func addSolution(t *testing.T, database db.DB, tmpl *kube_types.SolutionTemplate, userID string) kube_types.Solution {
+	t.Helper()
+	solution := kube_types.Solution{
+		Name:      uniqueName("solution"),
+		Namespace: uniqueName("ns"),
+		Branch:    "master",
+		Template:  tmpl.Name,
+	}
+	expectNoError(t, database.AddSolution(context.Background(), solution, userID, tmpl.ID, newID(), `{"KEY":"value"}`, nil))
+	return solution
+}
+
+func findSolution(list *model.SolutionsList, namespace, name string) *model.Solution {
+	for i := range list.Solutions {
+		if list.Solutions[i].Namespace == namespace && list.Solutions[i].Name == name {
+			return &list.Solutions[i]
+		}
+	}
+	return nil
+}
+
+func testSolutions(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	userID := newID()
+	solutionID := newID()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	solution := kube_types.Solution{Name: uniqueName("solution"), Namespace: uniqueName("ns"), Branch: "develop"}
+	expectNoError(t, database.AddSolution(ctx, solution, userID, tmpl.ID, solutionID, `{"KEY":"value"}`, &expiresAt))
+
+	got, err := database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	expected := model.Solution{
+		Solution: kube_types.Solution{
+			ID:        solutionID,
+			Branch:    "develop",
+			Env:       map[string]string{"KEY": "value"},
+			URL:       tmpl.URL + "/tree/develop",
+			Template:  tmpl.Name,
+			Name:      solution.Name,
+			Namespace: solution.Namespace,
+		},
+		Status:    model.SolutionRunning,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		ETag:      got.ETag,
+	}
+	if got.ETag == "" {
+		t.Fatalf("Solution has no entity tag")
+	}
+	if !reflect.DeepEqual(*got, expected) {
+		t.Fatalf("Unexpected solution:\n%+v\nexpected:\n%+v", *got, expected)
+	}
+
+	_, err = database.GetSolution(ctx, solution.Namespace, uniqueName("solution"))
+	expectError(t, err, solerrors.ErrSolutionNotExist())
+
+	for name, list := range map[string]func() (*model.SolutionsList, error){
+		"all":  func() (*model.SolutionsList, error) { return database.GetAllSolutionsList(ctx) },
+		"user": func() (*model.SolutionsList, error) { return database.GetSolutionsList(ctx, userID) },
+		"namespace": func() (*model.SolutionsList, error) {
+			return database.GetNamespaceSolutionsList(ctx, solution.Namespace)
+		},
+	} {
+		solutions, err := list()
+		expectNoError(t, err)
+		if listed := findSolution(solutions, solution.Namespace, solution.Name); listed == nil || !reflect.DeepEqual(*listed, expected) {
+			t.Fatalf("Solution is not listed in %s solutions list", name)
+		}
+	}
+
+	solutions, err := database.GetSolutionsList(ctx, newID())
+	expectNoError(t, err)
+	if solutions.Solutions == nil || len(solutions.Solutions) != 0 {
+		t.Fatalf("Expected empty list for other user, got %+v", solutions.Solutions)
+	}
+
+	// solutions are still listed if template is deactivated
+	expectNoError(t, database.DeactivateTemplate(ctx, tmpl.Name))
+	_, err = database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+}
+
+func testSolutionsUniqueName(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	solution := addSolution(t, database, tmpl, newID())
+
+	// name must be unique in namespace
+	expectDBError(t, database.AddSolution(ctx, solution, newID(), tmpl.ID, newID(), "{}", nil), db.ErrUniqueViolation)
+
+	other := solution
+	other.Namespace = uniqueName("ns")
+	expectNoError(t, database.AddSolution(ctx, other, newID(), tmpl.ID, newID(), "{}", nil))
+
+	// template must exist
+	other.Name = uniqueName("solution")
+	expectDBError(t, database.AddSolution(ctx, other, newID(), newID(), newID(), "{}", nil), db.ErrForeignKeyViolation)
+}
+
+func testSolutionsSoftDelete(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	userID := newID()
+	solution := addSolution(t, database, tmpl, userID)
+
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	expectError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name), solerrors.ErrSolutionNotExist())
+
+	_, err := database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectError(t, err, solerrors.ErrSolutionNotExist())
+	solutions, err := database.GetSolutionsList(ctx, userID)
+	expectNoError(t, err)
+	if len(solutions.Solutions) != 0 {
+		t.Fatalf("Deleted solution is listed")
+	}
+	expectError(t, database.SetSolutionTrackBranch(ctx, solution.Namespace, solution.Name, true), solerrors.ErrSolutionNotExist())
+
+	// name may be reused after deletion
+	expectNoError(t, database.AddSolution(ctx, solution, userID, tmpl.ID, newID(), "{}", nil))
+	_, err = database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+}
+
+func testSolutionsCompleteDelete(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	userID := newID()
+
+	solution := addSolution(t, database, tmpl, userID)
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	// soft deleted solution may be deleted completely
+	expectNoError(t, database.CompletelyDeleteSolution(ctx, solution.Namespace, solution.Name))
+	expectError(t, database.CompletelyDeleteSolution(ctx, solution.Namespace, solution.Name), solerrors.ErrSolutionNotExist())
+}
+
+func testSolutionsState(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	solution := addSolution(t, database, tmpl, newID())
+	replicas := map[string]int{"web": 2, "db": 1}
+
+	_, err := database.StartSolution(ctx, solution.Namespace, solution.Name)
+	expectError(t, err, solerrors.ErrInvalidSolutionState())
+
+	expectNoError(t, database.StopSolution(ctx, solution.Namespace, solution.Name, replicas))
+	expectError(t, database.StopSolution(ctx, solution.Namespace, solution.Name, replicas), solerrors.ErrInvalidSolutionState())
+	got, err := database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if got.Status != model.SolutionStopped {
+		t.Fatalf("Expected status %s, got %s", model.SolutionStopped, got.Status)
+	}
+
+	started, err := database.StartSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if !reflect.DeepEqual(started, replicas) {
+		t.Fatalf("Expected replicas %v, got %v", replicas, started)
+	}
+
+	// replicas are forgotten after start
+	expectNoError(t, database.StopSolution(ctx, solution.Namespace, solution.Name, nil))
+	started, err = database.StartSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if started == nil || len(started) != 0 {
+		t.Fatalf("Expected no replicas, got %v", started)
+	}
+
+	expectError(t, database.StopSolution(ctx, solution.Namespace, uniqueName("solution"), nil), solerrors.ErrInvalidSolutionState())
+}
+
+func testSolutionsVersion(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	userID := newID()
+	solution := addSolution(t, database, tmpl, userID)
+
+	etag := func() string {
+		got, err := database.GetSolution(ctx, solution.Namespace, solution.Name)
+		expectNoError(t, err)
+		return got.ETag
+	}
+
+	created := etag()
+	if etag() != created {
+		t.Fatalf("Entity tag is changed without modification")
+	}
+	expectNoError(t, database.SetSolutionSchedule(ctx, solution.Namespace, solution.Name, model.SolutionSchedule{Stop: "0 20 * * *"}))
+	scheduled := etag()
+	if scheduled == created {
+		t.Fatalf("Entity tag is not changed by schedule update")
+	}
+	expectNoError(t, database.StopSolution(ctx, solution.Namespace, solution.Name, nil))
+	if etag() == scheduled {
+		t.Fatalf("Entity tag is not changed by stop")
+	}
+
+	// entity tag of solution created with the same name must not match old one
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	expectNoError(t, database.AddSolution(ctx, solution, userID, tmpl.ID, newID(), "{}", nil))
+	if etag() == created {
+		t.Fatalf("Recreated solution has entity tag of old one")
+	}
+
+	// solution lock is available in transaction only
+	expectDBError(t, database.LockSolution(ctx, solution.Namespace, solution.Name), db.ErrNotInTransaction)
+	expectNoError(t, database.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.LockSolution(ctx, solution.Namespace, solution.Name)
+	}))
+}
+
+func testSolutionsSettings(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	solution := addSolution(t, database, tmpl, newID())
+
+	schedule := model.SolutionSchedule{Start: "0 8 * * 1-5", Stop: "0 20 * * 1-5"}
+	expectNoError(t, database.SetSolutionSchedule(ctx, solution.Namespace, solution.Name, schedule))
+	expectError(t, database.SetSolutionSchedule(ctx, solution.Namespace, uniqueName("solution"), schedule), solerrors.ErrSolutionNotExist())
+	got, err := database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if got.StartSchedule != schedule.Start || got.StopSchedule != schedule.Stop {
+		t.Fatalf("Schedule is not set: %+v", got)
+	}
+
+	expectNoError(t, database.SetSolutionTrackBranch(ctx, solution.Namespace, solution.Name, true))
+	got, err = database.GetSolution(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if !got.TrackBranch {
+		t.Fatalf("Branch tracking is not set")
+	}
+	tracking, err := database.GetTrackingSolutions(ctx, tmpl.Name, solution.Branch)
+	expectNoError(t, err)
+	if len(tracking.Solutions) != 1 || tracking.Solutions[0].Name != solution.Name || tracking.Solutions[0].Namespace != solution.Namespace {
+		t.Fatalf("Unexpected tracking solutions %+v", tracking.Solutions)
+	}
+	tracking, err = database.GetTrackingSolutions(ctx, tmpl.Name, "other")
+	expectNoError(t, err)
+	if len(tracking.Solutions) != 0 {
+		t.Fatalf("Solutions tracking other branch are returned")
+	}
+
+	counts, err := database.CountActiveSolutions(ctx)
+	expectNoError(t, err)
+	if counts[tmpl.Name] != 1 {
+		t.Fatalf("Expected 1 active solution of template, got %d", counts[tmpl.Name])
+	}
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	counts, err = database.CountActiveSolutions(ctx)
+	expectNoError(t, err)
+	if _, ok := counts[tmpl.Name]; ok {
+		t.Fatalf("Deleted solutions are counted")
+	}
+	tracking, err = database.GetTrackingSolutions(ctx, tmpl.Name, solution.Branch)
+	expectNoError(t, err)
+	if len(tracking.Solutions) != 0 {
+		t.Fatalf("Deleted solutions are tracking branch")
+	}
+}
+
+func testSolutionsExpiry(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	solution := addSolution(t, database, tmpl, newID())
+	// far in the past, so solutions of other tests don't interfere
+	expiresAt := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expectNoError(t, database.SetSolutionExpiry(ctx, solution.Namespace, solution.Name, expiresAt))
+	expectError(t, database.SetSolutionExpiry(ctx, solution.Namespace, uniqueName("solution"), expiresAt), solerrors.ErrSolutionNotExist())
+
+	expired, err := database.GetExpiredSolutions(ctx, expiresAt.Add(-time.Second))
+	expectNoError(t, err)
+	if findSolution(expired, solution.Namespace, solution.Name) != nil {
+		t.Fatalf("Solution is expired before expiration time")
+	}
+	expired, err = database.GetExpiredSolutions(ctx, expiresAt)
+	expectNoError(t, err)
+	if listed := findSolution(expired, solution.Namespace, solution.Name); listed == nil || listed.ExpiresAt != expiresAt.Format(time.RFC3339) {
+		t.Fatalf("Solution is not expired at expiration time")
+	}
+
+	warned, err := database.MarkSolutionsExpiryWarned(ctx, expiresAt, expiresAt.Add(time.Hour))
+	expectNoError(t, err)
+	if findSolution(warned, solution.Namespace, solution.Name) != nil {
+		t.Fatalf("Expired solution is warned")
+	}
+	warned, err = database.MarkSolutionsExpiryWarned(ctx, expiresAt.Add(-time.Hour), expiresAt)
+	expectNoError(t, err)
+	if findSolution(warned, solution.Namespace, solution.Name) == nil {
+		t.Fatalf("Solution is not warned")
+	}
+	warned, err = database.MarkSolutionsExpiryWarned(ctx, expiresAt.Add(-time.Hour), expiresAt)
+	expectNoError(t, err)
+	if findSolution(warned, solution.Namespace, solution.Name) != nil {
+		t.Fatalf("Solution is warned twice")
+	}
+
+	// setting expiry resets warning
+	expectNoError(t, database.SetSolutionExpiry(ctx, solution.Namespace, solution.Name, expiresAt))
+	warned, err = database.MarkSolutionsExpiryWarned(ctx, expiresAt.Add(-time.Hour), expiresAt)
+	expectNoError(t, err)
+	if findSolution(warned, solution.Namespace, solution.Name) == nil {
+		t.Fatalf("Solution is not warned after expiry change")
+	}
+
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	expired, err = database.GetExpiredSolutions(ctx, expiresAt)
+	expectNoError(t, err)
+	if findSolution(expired, solution.Namespace, solution.Name) != nil {
+		t.Fatalf("Deleted solution is expired")
+	}
+}
+
+func testSolutionResources(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	solution := addSolution(t, database, tmpl, newID())
+
+	resources, err := database.GetSolutionResources(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if len(resources.Resources) != 0 {
+		t.Fatalf("New solution has resources %+v", resources.Resources)
+	}
+
+	deploy := model.SolutionResource{Kind: model.KindDeployment, Name: "app", Status: model.ResourceCreated, ManifestHash: "deploy-hash"}
+	expectNoError(t, database.SaveSolutionResource(ctx, solution.Namespace, solution.Name, deploy))
+	time.Sleep(10 * time.Millisecond)
+	svc := model.SolutionResource{Kind: model.KindService, Name: "app", Status: model.ResourceCreated, ManifestHash: "svc-hash"}
+	expectNoError(t, database.SaveSolutionResource(ctx, solution.Namespace, solution.Name, svc))
+	expectError(t, database.SaveSolutionResource(ctx, solution.Namespace, uniqueName("solution"), svc), solerrors.ErrSolutionNotExist())
+
+	// saving recorded resource updates its status and manifest hash
+	deploy.Status = model.ResourceFailed
+	deploy.ManifestHash = "deploy-hash-updated"
+	expectNoError(t, database.SaveSolutionResource(ctx, solution.Namespace, solution.Name, deploy))
+
+	resources, err = database.GetSolutionResources(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if len(resources.Resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %+v", resources.Resources)
+	}
+	for i, expected := range []model.SolutionResource{deploy, svc} {
+		got := resources.Resources[i]
+		if got.Kind != expected.Kind || got.Name != expected.Name || got.Status != expected.Status || got.ManifestHash != expected.ManifestHash {
+			t.Fatalf("Expected resource %+v, got %+v", expected, got)
+		}
+		if _, err := time.Parse(time.RFC3339, got.CreatedAt); err != nil {
+			t.Fatalf("Invalid resource creation time: %v", err)
+		}
+	}
+	_, err = database.GetSolutionResources(ctx, solution.Namespace, uniqueName("solution"))
+	expectError(t, err, solerrors.ErrSolutionNotExist())
+
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	_, err = database.GetSolutionResources(ctx, solution.Namespace, solution.Name)
+	expectError(t, err, solerrors.ErrSolutionNotExist())
+
+	// new solution with same name doesn't have resources of deleted one
+	expectNoError(t, database.AddSolution(ctx, solution, newID(), tmpl.ID, newID(), "{}", nil))
+	resources, err = database.GetSolutionResources(ctx, solution.Namespace, solution.Name)
+	expectNoError(t, err)
+	if len(resources.Resources) != 0 {
+		t.Fatalf("Resources of deleted solution are returned: %+v", resources.Resources)
+	}
+}
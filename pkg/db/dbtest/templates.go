@@ -0,0 +1,153 @@
+package dbtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+func newTemplate() kube_types.SolutionTemplate {
+	name := uniqueName("template")
+	return kube_types.SolutionTemplate{
+		Name:   name,
+		Limits: &kube_types.SolutionLimits{CPU: 100, RAM: 128},
+		Images: []string{"nginx", "redis"},
+		URL:    "https://github.com/containerum/" + name,
+	}
+}
+
+// createTemplate creates active template and returns it with ID
+func createTemplate(t *testing.T, database db.DB) *kube_types.SolutionTemplate {
+	t.Helper()
+	ctx := context.Background()
+	tmpl := newTemplate()
+	expectNoError(t, database.CreateTemplate(ctx, tmpl))
+	ret, err := database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+	return &ret.SolutionTemplate
+}
+
+func findTemplate(list *kube_types.SolutionsTemplatesList, name string) *kube_types.SolutionTemplate {
+	for i := range list.Solutions {
+		if list.Solutions[i].Name == name {
+			return &list.Solutions[i]
+		}
+	}
+	return nil
+}
+
+func testTemplates(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := newTemplate()
+	expectNoError(t, database.CreateTemplate(ctx, tmpl))
+
+	got, err := database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+	if got.ID == "" || got.Name != tmpl.Name || got.URL != tmpl.URL || !reflect.DeepEqual(got.Images, tmpl.Images) ||
+		got.Limits == nil || *got.Limits != *tmpl.Limits || !got.Active || got.ETag == "" {
+		t.Fatalf("Unexpected template %+v", got)
+	}
+	etag := got.ETag
+
+	// active template name must be unique
+	expectDBError(t, database.CreateTemplate(ctx, tmpl), db.ErrUniqueViolation)
+
+	tmpl.URL += "-updated"
+	tmpl.Images = []string{"nginx"}
+	tmpl.Limits = &kube_types.SolutionLimits{CPU: 200, RAM: 256}
+	expectNoError(t, database.UpdateTemplate(ctx, tmpl))
+	got, err = database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+	if got.URL != tmpl.URL || !reflect.DeepEqual(got.Images, tmpl.Images) || *got.Limits != *tmpl.Limits {
+		t.Fatalf("Template is not updated: %+v", got)
+	}
+	if got.ETag == etag {
+		t.Fatalf("Template entity tag is not changed by update")
+	}
+
+	// template lock is available in transaction only
+	expectDBError(t, database.LockTemplate(ctx, tmpl.Name), db.ErrNotInTransaction)
+	expectNoError(t, database.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.LockTemplate(ctx, tmpl.Name)
+	}))
+	expectError(t, database.UpdateTemplate(ctx, newTemplate()), solerrors.ErrTemplateNotExist())
+
+	list, err := database.GetTemplatesList(ctx, false)
+	expectNoError(t, err)
+	if listed := findTemplate(list, tmpl.Name); listed == nil || !listed.Active {
+		t.Fatalf("Active template is not listed")
+	}
+
+	expectNoError(t, database.DeleteTemplate(ctx, tmpl.Name))
+	_, err = database.GetTemplate(ctx, tmpl.Name)
+	expectError(t, err, solerrors.ErrTemplateNotExist())
+	expectError(t, database.DeleteTemplate(ctx, tmpl.Name), solerrors.ErrSolutionNotExist())
+}
+
+func testTemplatesActivation(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+
+	expectNoError(t, database.DeactivateTemplate(ctx, tmpl.Name))
+	expectError(t, database.DeactivateTemplate(ctx, tmpl.Name), solerrors.ErrSolutionNotExist())
+
+	// inactive templates are available for admins only
+	_, err := database.GetTemplate(ctx, tmpl.Name)
+	expectError(t, err, solerrors.ErrTemplateNotExist())
+	list, err := database.GetTemplatesList(ctx, false)
+	expectNoError(t, err)
+	if findTemplate(list, tmpl.Name) != nil {
+		t.Fatalf("Inactive template is listed for user")
+	}
+	list, err = database.GetTemplatesList(ctx, true)
+	expectNoError(t, err)
+	if listed := findTemplate(list, tmpl.Name); listed == nil || listed.Active {
+		t.Fatalf("Inactive template is not listed for admin")
+	}
+
+	expectNoError(t, database.ActivateTemplate(ctx, tmpl.Name))
+	expectError(t, database.ActivateTemplate(ctx, tmpl.Name), solerrors.ErrTemplateNotExist())
+	activated, err := database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+
+	// template with the same name may be created while old one is inactive,
+	// but only one of them may be active
+	expectNoError(t, database.DeactivateTemplate(ctx, tmpl.Name))
+	expectNoError(t, database.CreateTemplate(ctx, *tmpl))
+	expectDBError(t, database.ActivateTemplate(ctx, tmpl.Name), db.ErrUniqueViolation)
+	// entity tag of template created with the same name must not match old one
+	recreated, err := database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+	if recreated.ETag == activated.ETag {
+		t.Fatalf("Recreated template has entity tag of old one")
+	}
+
+	// all templates with name are deleted
+	expectNoError(t, database.DeleteTemplate(ctx, tmpl.Name))
+	list, err = database.GetTemplatesList(ctx, true)
+	expectNoError(t, err)
+	if findTemplate(list, tmpl.Name) != nil {
+		t.Fatalf("Template is not deleted")
+	}
+}
+
+func testTemplateInUse(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	tmpl := createTemplate(t, database)
+	solution := addSolution(t, database, tmpl, newID())
+
+	expectDBError(t, database.DeleteTemplate(ctx, tmpl.Name), db.ErrForeignKeyViolation)
+	_, err := database.GetTemplate(ctx, tmpl.Name)
+	expectNoError(t, err)
+
+	// soft deleted solutions still reference template
+	expectNoError(t, database.DeleteSolution(ctx, solution.Namespace, solution.Name))
+	expectDBError(t, database.DeleteTemplate(ctx, tmpl.Name), db.ErrForeignKeyViolation)
+
+	expectNoError(t, database.CompletelyDeleteSolution(ctx, solution.Namespace, solution.Name))
+	expectNoError(t, database.DeleteTemplate(ctx, tmpl.Name))
+}
@@ -0,0 +1,191 @@
+package dbtest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+)
+
+// claimLimit is big enough to claim deliveries created by test along with deliveries of other tests
+const claimLimit = 1000
+
+func findWebhook(list *model.WebhooksList, webhookID string) *model.Webhook {
+	for i := range list.Webhooks {
+		if list.Webhooks[i].ID == webhookID {
+			return &list.Webhooks[i]
+		}
+	}
+	return nil
+}
+
+func findDelivery(list *model.WebhookDeliveriesList, deliveryID string) *model.WebhookDelivery {
+	for i := range list.Deliveries {
+		if list.Deliveries[i].ID == deliveryID {
+			return &list.Deliveries[i]
+		}
+	}
+	return nil
+}
+
+func createWebhook(t *testing.T, database db.DB, namespace string) model.Webhook {
+	t.Helper()
+	webhook := model.Webhook{
+		ID:        newID(),
+		Namespace: namespace,
+		URL:       "http://example.com/hook",
+		Secret:    "secret",
+		Events:    []string{model.EventRunSolution, model.EventDeleteSolution},
+	}
+	expectNoError(t, database.CreateWebhook(context.Background(), webhook))
+	return webhook
+}
+
+func addDelivery(t *testing.T, database db.DB, webhook model.Webhook) model.WebhookDelivery {
+	t.Helper()
+	delivery := model.WebhookDelivery{
+		ID:        newID(),
+		WebhookID: webhook.ID,
+		EventID:   newID(),
+		Action:    model.EventRunSolution,
+		URL:       webhook.URL,
+		Payload:   json.RawMessage(`{"action":"run_solution"}`),
+		Signature: "signature",
+	}
+	expectNoError(t, database.AddWebhookDelivery(context.Background(), delivery))
+	return delivery
+}
+
+func testWebhooks(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	namespace := uniqueName("ns")
+	webhook := createWebhook(t, database, namespace)
+	global := createWebhook(t, database, "")
+
+	expectDBError(t, database.CreateWebhook(ctx, webhook), db.ErrUniqueViolation)
+
+	got, err := database.GetWebhook(ctx, namespace, webhook.ID)
+	expectNoError(t, err)
+	if _, err := time.Parse(time.RFC3339, got.CreatedAt); err != nil {
+		t.Fatalf("Invalid webhook creation time %q", got.CreatedAt)
+	}
+	got.CreatedAt = ""
+	if !reflect.DeepEqual(*got, webhook) {
+		t.Fatalf("Unexpected webhook %+v, expected %+v", *got, webhook)
+	}
+	_, err = database.GetWebhook(ctx, uniqueName("ns"), webhook.ID)
+	expectError(t, err, solerrors.ErrWebhookNotExist())
+	_, err = database.GetWebhook(ctx, namespace, newID())
+	expectError(t, err, solerrors.ErrWebhookNotExist())
+
+	webhooks, err := database.GetWebhooksList(ctx, namespace)
+	expectNoError(t, err)
+	if len(webhooks.Webhooks) != 1 || webhooks.Webhooks[0].ID != webhook.ID {
+		t.Fatalf("Unexpected namespace webhooks %+v", webhooks.Webhooks)
+	}
+	webhooks, err = database.GetWebhooksList(ctx, "")
+	expectNoError(t, err)
+	if findWebhook(webhooks, global.ID) == nil || findWebhook(webhooks, webhook.ID) != nil {
+		t.Fatalf("Unexpected global webhooks %+v", webhooks.Webhooks)
+	}
+	webhooks, err = database.GetEventWebhooks(ctx, namespace)
+	expectNoError(t, err)
+	if findWebhook(webhooks, global.ID) == nil || findWebhook(webhooks, webhook.ID) == nil {
+		t.Fatalf("Event webhooks must contain namespace and global webhooks")
+	}
+	webhooks, err = database.GetWebhooksList(ctx, uniqueName("ns"))
+	expectNoError(t, err)
+	if webhooks.Webhooks == nil || len(webhooks.Webhooks) != 0 {
+		t.Fatalf("Expected empty webhooks list, got %+v", webhooks.Webhooks)
+	}
+
+	expectError(t, database.DeleteWebhook(ctx, uniqueName("ns"), webhook.ID), solerrors.ErrWebhookNotExist())
+	expectNoError(t, database.DeleteWebhook(ctx, namespace, webhook.ID))
+	expectError(t, database.DeleteWebhook(ctx, namespace, webhook.ID), solerrors.ErrWebhookNotExist())
+	expectNoError(t, database.DeleteWebhook(ctx, "", global.ID))
+}
+
+func testWebhookDeliveries(t *testing.T, database db.DB) {
+	ctx := context.Background()
+	webhook := createWebhook(t, database, uniqueName("ns"))
+
+	// webhook must exist
+	expectDBError(t, database.AddWebhookDelivery(ctx, model.WebhookDelivery{ID: newID(), WebhookID: newID(), EventID: newID()}), db.ErrForeignKeyViolation)
+
+	first := addDelivery(t, database, webhook)
+	time.Sleep(10 * time.Millisecond)
+	second := addDelivery(t, database, webhook)
+	expectDBError(t, database.AddWebhookDelivery(ctx, first), db.ErrUniqueViolation)
+
+	deliveries, err := database.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	expectNoError(t, err)
+	if len(deliveries.Deliveries) != 2 || deliveries.Deliveries[0].ID != second.ID || deliveries.Deliveries[1].ID != first.ID {
+		t.Fatalf("Unexpected deliveries %+v", deliveries.Deliveries)
+	}
+	got := deliveries.Deliveries[1]
+	if got.Status != model.DeliveryPending || got.Attempts != 0 || got.CreatedAt == "" || got.NextAttemptAt == "" ||
+		got.Action != first.Action || got.URL != first.URL || got.EventID != first.EventID ||
+		string(got.Payload) != string(first.Payload) || got.Signature != first.Signature {
+		t.Fatalf("Unexpected delivery %+v", got)
+	}
+	deliveries, err = database.GetWebhookDeliveries(ctx, webhook.ID, 1)
+	expectNoError(t, err)
+	if len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].ID != second.ID {
+		t.Fatalf("Deliveries limit is not applied: %+v", deliveries.Deliveries)
+	}
+
+	// claimed deliveries are leased and not claimed again until lease expires
+	now := time.Now().Add(time.Second)
+	lease := time.Minute
+	claimed, err := database.ClaimWebhookDeliveries(ctx, now, lease, claimLimit)
+	expectNoError(t, err)
+	if findDelivery(claimed, first.ID) == nil || findDelivery(claimed, second.ID) == nil {
+		t.Fatalf("Pending deliveries are not claimed")
+	}
+	claimed, err = database.ClaimWebhookDeliveries(ctx, now, lease, claimLimit)
+	expectNoError(t, err)
+	if findDelivery(claimed, first.ID) != nil {
+		t.Fatalf("Leased delivery is claimed")
+	}
+
+	expectNoError(t, database.SetWebhookDeliveryResult(ctx, first.ID, model.DeliveryDelivered, 1, "", now))
+	expectNoError(t, database.SetWebhookDeliveryResult(ctx, second.ID, model.DeliveryFailed, 5, "timeout", now))
+	claimed, err = database.ClaimWebhookDeliveries(ctx, now.Add(2*lease), lease, claimLimit)
+	expectNoError(t, err)
+	if findDelivery(claimed, first.ID) != nil || findDelivery(claimed, second.ID) != nil {
+		t.Fatalf("Finished deliveries are claimed")
+	}
+	deliveries, err = database.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	expectNoError(t, err)
+	if delivered := findDelivery(deliveries, first.ID); delivered.Status != model.DeliveryDelivered ||
+		delivered.Attempts != 1 || delivered.DeliveredAt == "" {
+		t.Fatalf("Unexpected delivered delivery %+v", delivered)
+	}
+	if failed := findDelivery(deliveries, second.ID); failed.Status != model.DeliveryFailed ||
+		failed.Attempts != 5 || failed.LastError != "timeout" || failed.DeliveredAt != "" {
+		t.Fatalf("Unexpected failed delivery %+v", failed)
+	}
+
+	expectNoError(t, database.RedeliverWebhook(ctx, webhook.ID, second.ID))
+	expectError(t, database.RedeliverWebhook(ctx, webhook.ID, newID()), solerrors.ErrWebhookDeliveryNotExist())
+	expectError(t, database.RedeliverWebhook(ctx, newID(), second.ID), solerrors.ErrWebhookDeliveryNotExist())
+	deliveries, err = database.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	expectNoError(t, err)
+	if redelivered := findDelivery(deliveries, second.ID); redelivered.Status != model.DeliveryPending ||
+		redelivered.Attempts != 0 || redelivered.LastError != "" {
+		t.Fatalf("Unexpected redelivered delivery %+v", redelivered)
+	}
+
+	// deliveries are deleted with webhook
+	expectNoError(t, database.DeleteWebhook(ctx, webhook.Namespace, webhook.ID))
+	deliveries, err = database.GetWebhookDeliveries(ctx, webhook.ID, 10)
+	expectNoError(t, err)
+	if len(deliveries.Deliveries) != 0 {
+		t.Fatalf("Deliveries are not deleted with webhook")
+	}
+}
@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type memDB struct {
+	log *logrus.Entry
+
+	// writeMu serializes writers: single modifications and whole transactions.
+	// It's nil in transaction because transaction holds writer lock of parent DB.
+	writeMu *sync.Mutex
+	mu      *sync.RWMutex
+	data    *tables
+}
+
+// NewDB returns database keeping data in memory. Data is lost when process exits.
+// It's intended for tests and local development and behaves like postgres database (see postgres.DBConnect)
+// with all migrations applied, including default templates.
+func NewDB() db.DB {
+	log := logrus.WithField("component", "db")
+	log.Infoln("Using in-memory database")
+
+	data := &tables{}
+	for _, t := range defaultTemplates {
+		row := *t
+		row.ID = uuid.New().String()
+		row.Active = true
+		row.Images = copyStrings(t.Images)
+		data.templates = append(data.templates, &row)
+	}
+
+	return &memDB{
+		log:     log,
+		writeMu: &sync.Mutex{},
+		mu:      &sync.RWMutex{},
+		data:    data,
+	}
+}
+
+// defaultTemplates are templates inserted by migrations
+var defaultTemplates = []*templateRow{
+	{Name: "mariadb-solution", CPU: 100, RAM: 128, Images: []string{"mariadb"}, URL: "https://github.com/containerum/mariadb-solution"},
+	{Name: "postgresql-solution", CPU: 100, RAM: 128, Images: []string{"postgres"}, URL: "https://github.com/containerum/postgresql-solution"},
+	{Name: "grafana-xxl-solution", CPU: 100, RAM: 128, Images: []string{"grafana-xxl"}, URL: "https://github.com/containerum/grafana-xxl-solution"},
+	{Name: "rabbitmq-manager-solution", CPU: 100, RAM: 128, Images: []string{"rabbitmq"}, URL: "https://github.com/containerum/rabbitmq-manager-solution"},
+	{Name: "webpack-3.8-ssh-solution", CPU: 100, RAM: 128, Images: []string{"webpack-3.8-ssh-solution"}, URL: "https://github.com/containerum/webpack-3.8-ssh-solution"},
+	{Name: "redmine-mariadb-solution", CPU: 200, RAM: 256, Images: []string{"redmine", "mariadb"}, URL: "https://github.com/containerum/redmine-mariadb-solution"},
+	{Name: "redmine-postgresql-solution", CPU: 200, RAM: 256, Images: []string{"redmine", "postgres"}, URL: "https://github.com/containerum/redmine-postgresql-solution"},
+	{Name: "magento-solution", CPU: 200, RAM: 256, Images: []string{"mysql", "magento2"}, URL: "https://github.com/containerum/magento-solution"},
+}
+
+// read calls f with data locked for reading
+func (mdb *memDB) read(f func(t *tables) error) error {
+	mdb.mu.RLock()
+	defer mdb.mu.RUnlock()
+	return f(mdb.data)
+}
+
+// write calls f with data locked for writing.
+// Like a single SQL statement f must check all constraints before modifying data.
+func (mdb *memDB) write(f func(t *tables) error) error {
+	if mdb.writeMu != nil {
+		mdb.writeMu.Lock()
+		defer mdb.writeMu.Unlock()
+	}
+	mdb.mu.Lock()
+	defer mdb.mu.Unlock()
+	return f(mdb.data)
+}
+
+// Transactional runs f on a copy of data, which replaces data if f succeeds (copy-on-write).
+// Transactions and modifications outside of them are serialized, while reads are not blocked.
+func (mdb *memDB) Transactional(ctx context.Context, f func(ctx context.Context, tx db.DB) error) (err error) {
+	start := time.Now().Format(time.ANSIC)
+	e := mdb.logger(ctx).WithField("transaction_at", start)
+	ctx, span := tracing.StartSpan(ctx, "db transaction", tracing.SpanKindInternal)
+	defer func() { span.Finish(err) }()
+	e.Debugln("Begin transaction")
+
+	if mdb.writeMu != nil {
+		mdb.writeMu.Lock()
+		defer mdb.writeMu.Unlock()
+	}
+	mdb.mu.RLock()
+	tx := &memDB{
+		log:  e,
+		mu:   &sync.RWMutex{},
+		data: mdb.data.clone(),
+	}
+	mdb.mu.RUnlock()
+
+	if err = f(ctx, tx); err != nil {
+		e.WithError(err).Debugln("Rollback transaction")
+		metrics.DBTransactionRollbacks.Inc()
+		span.SetAttribute("db.rollback", "true")
+		return err
+	}
+
+	e.Debugln("Commit transaction")
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	mdb.mu.Lock()
+	defer mdb.mu.Unlock()
+	mdb.data = tx.data
+	return nil
+}
+
+// logger returns db log entry with request-scoped fields
+func (mdb *memDB) logger(ctx context.Context) *logrus.Entry {
+	return utils.LogEntry(ctx, mdb.log)
+}
+
+func (mdb *memDB) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (mdb *memDB) Close() error {
+	return nil
+}
+
+type templateRow struct {
+	ID      string
+	Name    string
+	CPU     uint
+	RAM     uint
+	Images  []string
+	URL     string
+	Active  bool
+	Version int
+}
+
+// solutionRow contains solution with its parameters, recorded replicas and resources
+type solutionRow struct {
+	ID            string
+	TemplateID    string
+	Name          string
+	Namespace     string
+	UserID        string
+	CreatedAt     time.Time
+	IsDeleted     bool
+	DeletedAt     *time.Time
+	Status        string
+	StartSchedule string
+	StopSchedule  string
+	ExpiresAt     *time.Time
+	ExpiryWarned  bool
+	TrackBranch   bool
+	Version       int
+
+	Branch    string
+	Env       string
+	Replicas  map[string]int
+	Resources []resourceRow
+}
+
+type resourceRow struct {
+	Kind         string
+	Name         string
+	Status       string
+	ManifestHash string
+	CreatedAt    time.Time
+}
+
+type eventRow struct {
+	model.Event
+	CreatedAt time.Time
+}
+
+type webhookRow struct {
+	model.Webhook
+	CreatedAt time.Time
+}
+
+type deliveryRow struct {
+	model.WebhookDelivery
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+}
+
+// tables keep rows in insertion order
+type tables struct {
+	templates  []*templateRow
+	solutions  []*solutionRow
+	events     []*eventRow
+	webhooks   []*webhookRow
+	deliveries []*deliveryRow
+	leases     []*leaseRow
+}
+
+func (t *tables) clone() *tables {
+	ret := &tables{
+		templates:  make([]*templateRow, 0, len(t.templates)),
+		solutions:  make([]*solutionRow, 0, len(t.solutions)),
+		events:     make([]*eventRow, 0, len(t.events)),
+		webhooks:   make([]*webhookRow, 0, len(t.webhooks)),
+		deliveries: make([]*deliveryRow, 0, len(t.deliveries)),
+		leases:     make([]*leaseRow, 0, len(t.leases)),
+	}
+	for _, row := range t.templates {
+		c := *row
+		c.Images = copyStrings(row.Images)
+		ret.templates = append(ret.templates, &c)
+	}
+	for _, row := range t.solutions {
+		c := *row
+		c.DeletedAt = copyTime(row.DeletedAt)
+		c.ExpiresAt = copyTime(row.ExpiresAt)
+		c.Replicas = make(map[string]int, len(row.Replicas))
+		for k, v := range row.Replicas {
+			c.Replicas[k] = v
+		}
+		c.Resources = append([]resourceRow(nil), row.Resources...)
+		ret.solutions = append(ret.solutions, &c)
+	}
+	for _, row := range t.events {
+		c := *row
+		ret.events = append(ret.events, &c)
+	}
+	for _, row := range t.webhooks {
+		c := *row
+		c.Events = copyStrings(row.Events)
+		ret.webhooks = append(ret.webhooks, &c)
+	}
+	for _, row := range t.deliveries {
+		c := *row
+		c.Payload = append([]byte(nil), row.Payload...)
+		c.DeliveredAt = copyTime(row.DeliveredAt)
+		ret.deliveries = append(ret.deliveries, &c)
+	}
+	for _, row := range t.leases {
+		c := *row
+		ret.leases = append(ret.leases, &c)
+	}
+	return ret
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// dbTime converts time to value stored in timestamp column
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Round(time.Microsecond)
+}
+
+// parseUUID converts value to UUID column value, like postgres does
+func parseUUID(value string) (string, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid input syntax for type uuid: %q", value)
+	}
+	return id.String(), nil
+}
+
+var errInvalidJSON = errors.New("invalid input syntax for type json")
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+)
+
+func (mdb *memDB) AddEvent(ctx context.Context, event model.Event) error {
+	mdb.logger(ctx).Debugln("Saving event")
+
+	id, err := parseUUID(event.ID)
+	if err != nil {
+		return err
+	}
+	event.ID = id
+	event.Time = ""
+
+	return mdb.write(func(t *tables) error {
+		for _, row := range t.events {
+			if row.ID == id {
+				return db.ErrUniqueViolation
+			}
+		}
+		t.events = append(t.events, &eventRow{Event: event, CreatedAt: dbTime(time.Now())})
+		return nil
+	})
+}
+
+func matchEvent(filter model.EventsFilter, row *eventRow) bool {
+	for _, field := range []struct{ value, filter string }{
+		{row.UserID, filter.UserID},
+		{row.Namespace, filter.Namespace},
+		{row.Solution, filter.Solution},
+		{row.Template, filter.Template},
+		{row.Action, filter.Action},
+		{row.Outcome, filter.Outcome},
+	} {
+		if field.filter != "" && field.value != field.filter {
+			return false
+		}
+	}
+	if !filter.Since.IsZero() && row.CreatedAt.Before(dbTime(filter.Since)) {
+		return false
+	}
+	if !filter.Until.IsZero() && !row.CreatedAt.Before(dbTime(filter.Until)) {
+		return false
+	}
+	return true
+}
+
+func (mdb *memDB) GetEvents(ctx context.Context, filter model.EventsFilter) (*model.EventsList, error) {
+	mdb.logger(ctx).Infoln("Get events")
+
+	var rows []*eventRow
+	err := mdb.read(func(t *tables) error {
+		// newer rows first if creation times are equal
+		for i := len(t.events) - 1; i >= 0; i-- {
+			if matchEvent(filter, t.events[i]) {
+				rows = append(rows, t.events[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].CreatedAt.After(rows[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+	}
+
+	ret := model.EventsList{Events: make([]model.Event, 0, len(rows))}
+	for _, row := range rows {
+		event := row.Event
+		event.Time = row.CreatedAt.Format(time.RFC3339)
+		ret.Events = append(ret.Events, event)
+	}
+	return &ret, nil
+}
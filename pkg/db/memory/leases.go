@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+)
+
+type leaseRow struct {
+	Namespace string
+	Name      string
+	Holder    string
+	ExpiresAt time.Time
+}
+
+func (t *tables) solutionLease(namespace, solutionName string) *leaseRow {
+	for _, row := range t.leases {
+		if row.Namespace == namespace && row.Name == solutionName {
+			return row
+		}
+	}
+	return nil
+}
+
+func (mdb *memDB) AcquireSolutionLease(ctx context.Context, namespace, solutionName, holder string, now, expiresAt time.Time) error {
+	mdb.logger(ctx).Debugln("Acquiring solution lease")
+
+	return mdb.write(func(t *tables) error {
+		row := t.solutionLease(namespace, solutionName)
+		if row == nil {
+			t.leases = append(t.leases, &leaseRow{Namespace: namespace, Name: solutionName, Holder: holder, ExpiresAt: dbTime(expiresAt)})
+			return nil
+		}
+		if row.Holder != holder && row.ExpiresAt.After(dbTime(now)) {
+			return db.ErrLeaseConflict
+		}
+		row.Holder = holder
+		row.ExpiresAt = dbTime(expiresAt)
+		return nil
+	})
+}
+
+func (mdb *memDB) ReleaseSolutionLease(ctx context.Context, namespace, solutionName, holder string) error {
+	mdb.logger(ctx).Debugln("Releasing solution lease")
+
+	return mdb.write(func(t *tables) error {
+		leases := t.leases[:0]
+		for _, row := range t.leases {
+			if row.Namespace != namespace || row.Name != solutionName || row.Holder != holder {
+				leases = append(leases, row)
+			}
+		}
+		t.leases = leases
+		return nil
+	})
+}
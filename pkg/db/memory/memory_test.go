@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/dbtest"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.RunConformance(t, func(t *testing.T) db.DB {
+		return NewDB()
+	})
+}
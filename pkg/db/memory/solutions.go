@@ -0,0 +1,451 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// templateByID returns template referenced by solution or nil
+func (t *tables) templateByID(id string) *templateRow {
+	for _, row := range t.templates {
+		if row.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+// activeSolution returns not deleted solution or nil
+func (t *tables) activeSolution(namespace, solutionName string) *solutionRow {
+	for _, row := range t.solutions {
+		if row.Name == solutionName && row.Namespace == namespace && !row.IsDeleted {
+			return row
+		}
+	}
+	return nil
+}
+
+// deleteSolutions completely deletes solutions matching filter and returns count of deleted solutions
+func (t *tables) deleteSolutions(match func(row *solutionRow) bool) int {
+	var kept []*solutionRow
+	for _, row := range t.solutions {
+		if !match(row) {
+			kept = append(kept, row)
+		}
+	}
+	deleted := len(t.solutions) - len(kept)
+	t.solutions = kept
+	return deleted
+}
+
+// selectSolutions returns not deleted solutions matching filter joined with their templates
+func (mdb *memDB) selectSolutions(match func(row *solutionRow) bool) (*model.SolutionsList, error) {
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0)}
+	err := mdb.read(func(t *tables) error {
+		for _, row := range t.solutions {
+			if row.IsDeleted || !match(row) {
+				continue
+			}
+			solution, err := row.solution(t.templateByID(row.TemplateID))
+			if err != nil {
+				return err
+			}
+			ret.Solutions = append(ret.Solutions, solution)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (row *solutionRow) solution(template *templateRow) (model.Solution, error) {
+	solution := model.Solution{
+		Solution: kube_types.Solution{
+			ID:        row.ID,
+			Branch:    row.Branch,
+			Template:  template.Name,
+			Name:      row.Name,
+			Namespace: row.Namespace,
+			URL:       template.URL + "/tree/" + row.Branch,
+		},
+		Status:        row.Status,
+		StartSchedule: row.StartSchedule,
+		StopSchedule:  row.StopSchedule,
+		TrackBranch:   row.TrackBranch,
+		ETag:          model.ETag(row.ID, row.Version),
+	}
+	if err := json.Unmarshal([]byte(row.Env), &solution.Env); err != nil {
+		return model.Solution{}, err
+	}
+	if row.ExpiresAt != nil {
+		solution.ExpiresAt = row.ExpiresAt.Format(time.RFC3339)
+	}
+	return solution, nil
+}
+
+func (mdb *memDB) AddSolution(ctx context.Context, solution kube_types.Solution, userID, templateID, uuid, env string, expiresAt *time.Time) error {
+	mdb.logger(ctx).Infoln("Saving solution")
+
+	id, err := parseUUID(uuid)
+	if err != nil {
+		return err
+	}
+	if userID, err = parseUUID(userID); err != nil {
+		return err
+	}
+	if templateID, err = parseUUID(templateID); err != nil {
+		return err
+	}
+	if !json.Valid([]byte(env)) {
+		return errInvalidJSON
+	}
+
+	return mdb.write(func(t *tables) error {
+		for _, row := range t.solutions {
+			if row.ID == id {
+				return db.ErrUniqueViolation
+			}
+		}
+		if t.activeSolution(solution.Namespace, solution.Name) != nil {
+			return db.ErrUniqueViolation
+		}
+		if t.templateByID(templateID) == nil {
+			return db.ErrForeignKeyViolation
+		}
+
+		row := &solutionRow{
+			ID:         id,
+			TemplateID: templateID,
+			Name:       solution.Name,
+			Namespace:  solution.Namespace,
+			UserID:     userID,
+			CreatedAt:  dbTime(time.Now()),
+			Status:     model.SolutionRunning,
+			Branch:     solution.Branch,
+			Env:        env,
+			Replicas:   make(map[string]int),
+			Version:    1,
+		}
+		if expiresAt != nil {
+			exp := dbTime(*expiresAt)
+			row.ExpiresAt = &exp
+		}
+		t.solutions = append(t.solutions, row)
+		return nil
+	})
+}
+
+func (mdb *memDB) GetAllSolutionsList(ctx context.Context) (*model.SolutionsList, error) {
+	mdb.logger(ctx).Infoln("Get all solutions list")
+
+	return mdb.selectSolutions(func(row *solutionRow) bool {
+		return true
+	})
+}
+
+func (mdb *memDB) GetSolutionsList(ctx context.Context, userID string) (*model.SolutionsList, error) {
+	mdb.logger(ctx).Infoln("Get solutions list")
+
+	userID, err := parseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return mdb.selectSolutions(func(row *solutionRow) bool {
+		return row.UserID == userID
+	})
+}
+
+func (mdb *memDB) GetNamespaceSolutionsList(ctx context.Context, namespace string) (*model.SolutionsList, error) {
+	mdb.logger(ctx).Infoln("Get solutions list")
+
+	return mdb.selectSolutions(func(row *solutionRow) bool {
+		return row.Namespace == namespace
+	})
+}
+
+func (mdb *memDB) GetSolution(ctx context.Context, namespace, solutionName string) (*model.Solution, error) {
+	mdb.logger(ctx).Infoln("Get solution")
+
+	solutions, err := mdb.selectSolutions(func(row *solutionRow) bool {
+		return row.Name == solutionName && row.Namespace == namespace
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(solutions.Solutions) == 0 {
+		return nil, solerrors.ErrSolutionNotExist()
+	}
+	return &solutions.Solutions[0], nil
+}
+
+func (mdb *memDB) DeleteSolution(ctx context.Context, namespace, solutionName string) error {
+	mdb.logger(ctx).Infoln("Deleting solution")
+
+	return mdb.write(func(t *tables) error {
+		row := t.activeSolution(namespace, solutionName)
+		if row == nil {
+			return solerrors.ErrSolutionNotExist()
+		}
+		now := dbTime(time.Now())
+		row.IsDeleted = true
+		row.DeletedAt = &now
+		return nil
+	})
+}
+
+func (mdb *memDB) CompletelyDeleteSolution(ctx context.Context, namespace, solutionName string) error {
+	mdb.logger(ctx).Infoln("Deleting solution")
+
+	return mdb.write(func(t *tables) error {
+		deleted := t.deleteSolutions(func(row *solutionRow) bool {
+			return row.Name == solutionName && row.Namespace == namespace
+		})
+		if deleted == 0 {
+			return solerrors.ErrSolutionNotExist()
+		}
+		return nil
+	})
+}
+
+func (mdb *memDB) StopSolution(ctx context.Context, namespace, solutionName string, replicas map[string]int) error {
+	mdb.logger(ctx).Infoln("Stopping solution")
+
+	return mdb.write(func(t *tables) error {
+		row := t.activeSolution(namespace, solutionName)
+		if row == nil || row.Status != model.SolutionRunning {
+			return solerrors.ErrInvalidSolutionState()
+		}
+		// status is updated by separate statement in postgres backend, so it's kept if replicas insertion fails
+		row.Status = model.SolutionStopped
+		row.Version++
+		for deploy := range replicas {
+			if _, exists := row.Replicas[deploy]; exists {
+				return db.ErrUniqueViolation
+			}
+		}
+		for deploy, count := range replicas {
+			row.Replicas[deploy] = count
+		}
+		return nil
+	})
+}
+
+func (mdb *memDB) StartSolution(ctx context.Context, namespace, solutionName string) (map[string]int, error) {
+	mdb.logger(ctx).Infoln("Starting solution")
+
+	ret := make(map[string]int)
+	err := mdb.write(func(t *tables) error {
+		row := t.activeSolution(namespace, solutionName)
+		if row == nil || row.Status != model.SolutionStopped {
+			return solerrors.ErrInvalidSolutionState()
+		}
+		row.Status = model.SolutionRunning
+		row.Version++
+		for deploy, count := range row.Replicas {
+			ret[deploy] = count
+		}
+		row.Replicas = make(map[string]int)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// updateSolution modifies not deleted solution and increments its version
+func (mdb *memDB) updateSolution(namespace, solutionName string, update func(row *solutionRow)) error {
+	return mdb.write(func(t *tables) error {
+		row := t.activeSolution(namespace, solutionName)
+		if row == nil {
+			return solerrors.ErrSolutionNotExist()
+		}
+		update(row)
+		row.Version++
+		return nil
+	})
+}
+
+func (mdb *memDB) SetSolutionSchedule(ctx context.Context, namespace, solutionName string, schedule model.SolutionSchedule) error {
+	mdb.logger(ctx).Infoln("Setting solution schedule")
+
+	return mdb.updateSolution(namespace, solutionName, func(row *solutionRow) {
+		row.StartSchedule = schedule.Start
+		row.StopSchedule = schedule.Stop
+	})
+}
+
+func (mdb *memDB) SetSolutionExpiry(ctx context.Context, namespace, solutionName string, expiresAt time.Time) error {
+	mdb.logger(ctx).Infoln("Setting solution expiry")
+
+	exp := dbTime(expiresAt)
+	return mdb.updateSolution(namespace, solutionName, func(row *solutionRow) {
+		row.ExpiresAt = &exp
+		row.ExpiryWarned = false
+	})
+}
+
+func solutionExpiry(row *solutionRow) model.Solution {
+	var solution model.Solution
+	solution.Name = row.Name
+	solution.Namespace = row.Namespace
+	solution.ExpiresAt = row.ExpiresAt.Format(time.RFC3339)
+	return solution
+}
+
+func (mdb *memDB) GetExpiredSolutions(ctx context.Context, now time.Time) (*model.SolutionsList, error) {
+	mdb.logger(ctx).Infoln("Get expired solutions")
+
+	now = dbTime(now)
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0)}
+	err := mdb.read(func(t *tables) error {
+		for _, row := range t.solutions {
+			if !row.IsDeleted && row.ExpiresAt != nil && !row.ExpiresAt.After(now) {
+				ret.Solutions = append(ret.Solutions, solutionExpiry(row))
+			}
+		}
+		return nil
+	})
+	return &ret, err
+}
+
+func (mdb *memDB) MarkSolutionsExpiryWarned(ctx context.Context, now, before time.Time) (*model.SolutionsList, error) {
+	mdb.logger(ctx).Infoln("Marking expiring solutions warned")
+
+	now, before = dbTime(now), dbTime(before)
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0)}
+	err := mdb.write(func(t *tables) error {
+		for _, row := range t.solutions {
+			if !row.IsDeleted && !row.ExpiryWarned && row.ExpiresAt != nil && row.ExpiresAt.After(now) && !row.ExpiresAt.After(before) {
+				row.ExpiryWarned = true
+				ret.Solutions = append(ret.Solutions, solutionExpiry(row))
+			}
+		}
+		return nil
+	})
+	return &ret, err
+}
+
+func (mdb *memDB) SetSolutionTrackBranch(ctx context.Context, namespace, solutionName string, track bool) error {
+	mdb.logger(ctx).Infoln("Setting solution branch tracking")
+
+	return mdb.updateSolution(namespace, solutionName, func(row *solutionRow) {
+		row.TrackBranch = track
+	})
+}
+
+func (mdb *memDB) GetTrackingSolutions(ctx context.Context, templateName, branch string) (*model.SolutionsList, error) {
+	mdb.logger(ctx).Infoln("Get solutions tracking template branch")
+
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0)}
+	err := mdb.read(func(t *tables) error {
+		for _, row := range t.solutions {
+			if row.IsDeleted || !row.TrackBranch || row.Branch != branch || t.templateByID(row.TemplateID).Name != templateName {
+				continue
+			}
+			var solution model.Solution
+			solution.Name = row.Name
+			solution.Namespace = row.Namespace
+			ret.Solutions = append(ret.Solutions, solution)
+		}
+		return nil
+	})
+	return &ret, err
+}
+
+func (mdb *memDB) CountActiveSolutions(ctx context.Context) (map[string]int, error) {
+	mdb.logger(ctx).Debugln("Count active solutions")
+
+	ret := make(map[string]int)
+	err := mdb.read(func(t *tables) error {
+		for _, row := range t.solutions {
+			if !row.IsDeleted {
+				ret[t.templateByID(row.TemplateID).Name]++
+			}
+		}
+		return nil
+	})
+	return ret, err
+}
+
+func (mdb *memDB) SaveSolutionResource(ctx context.Context, namespace, solutionName string, resource model.SolutionResource) error {
+	mdb.logger(ctx).Debugln("Saving solution resource")
+
+	return mdb.write(func(t *tables) error {
+		row := t.activeSolution(namespace, solutionName)
+		if row == nil {
+			return solerrors.ErrSolutionNotExist()
+		}
+		for i := range row.Resources {
+			if row.Resources[i].Kind == resource.Kind && row.Resources[i].Name == resource.Name {
+				row.Resources[i].Status = resource.Status
+				row.Resources[i].ManifestHash = resource.ManifestHash
+				return nil
+			}
+		}
+		row.Resources = append(row.Resources, resourceRow{
+			Kind:         resource.Kind,
+			Name:         resource.Name,
+			Status:       resource.Status,
+			ManifestHash: resource.ManifestHash,
+			CreatedAt:    dbTime(time.Now()),
+		})
+		return nil
+	})
+}
+
+func (mdb *memDB) GetSolutionResources(ctx context.Context, namespace, solutionName string) (*model.SolutionResourcesList, error) {
+	mdb.logger(ctx).Infoln("Get solution resources")
+
+	var rows []resourceRow
+	err := mdb.read(func(t *tables) error {
+		row := t.activeSolution(namespace, solutionName)
+		if row == nil {
+			return solerrors.ErrSolutionNotExist()
+		}
+		rows = append(rows, row.Resources...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].CreatedAt.Equal(rows[j].CreatedAt) {
+			return rows[i].CreatedAt.Before(rows[j].CreatedAt)
+		}
+		if rows[i].Kind != rows[j].Kind {
+			return rows[i].Kind < rows[j].Kind
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	ret := model.SolutionResourcesList{Resources: make([]model.SolutionResource, 0, len(rows))}
+	for _, row := range rows {
+		ret.Resources = append(ret.Resources, model.SolutionResource{
+			Kind:         row.Kind,
+			Name:         row.Name,
+			Status:       row.Status,
+			ManifestHash: row.ManifestHash,
+			CreatedAt:    row.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return &ret, nil
+}
+
+// LockSolution does nothing except transaction check: transactions hold writer lock of database
+func (mdb *memDB) LockSolution(ctx context.Context, namespace, solutionName string) error {
+	mdb.logger(ctx).Debugln("Lock solution")
+	if mdb.writeMu != nil {
+		return db.ErrNotInTransaction
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/google/uuid"
+)
+
+func (row *templateRow) template() kube_types.SolutionTemplate {
+	return kube_types.SolutionTemplate{
+		ID:     row.ID,
+		Name:   row.Name,
+		Limits: &kube_types.SolutionLimits{CPU: row.CPU, RAM: row.RAM},
+		Images: copyStrings(row.Images),
+		URL:    row.URL,
+		Active: row.Active,
+	}
+}
+
+// activeTemplate returns active template with name or nil
+func (t *tables) activeTemplate(name string) *templateRow {
+	for _, row := range t.templates {
+		if row.Name == name && row.Active {
+			return row
+		}
+	}
+	return nil
+}
+
+func (mdb *memDB) CreateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
+	mdb.logger(ctx).Infoln("Saving solution template")
+
+	return mdb.write(func(t *tables) error {
+		if t.activeTemplate(solution.Name) != nil {
+			return db.ErrUniqueViolation
+		}
+		t.templates = append(t.templates, &templateRow{
+			ID:      uuid.New().String(),
+			Name:    solution.Name,
+			CPU:     solution.Limits.CPU,
+			RAM:     solution.Limits.RAM,
+			Images:  copyStrings(solution.Images),
+			URL:     solution.URL,
+			Active:  true,
+			Version: 1,
+		})
+		return nil
+	})
+}
+
+func (mdb *memDB) UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
+	mdb.logger(ctx).Infoln("Updating solution template")
+
+	return mdb.write(func(t *tables) error {
+		var rows int
+		for _, row := range t.templates {
+			if row.Name == solution.Name {
+				row.CPU = solution.Limits.CPU
+				row.RAM = solution.Limits.RAM
+				row.Images = copyStrings(solution.Images)
+				row.URL = solution.URL
+				row.Version++
+				rows++
+			}
+		}
+		if rows == 0 {
+			return solerrors.ErrTemplateNotExist()
+		}
+		return nil
+	})
+}
+
+func (mdb *memDB) ActivateTemplate(ctx context.Context, solution string) error {
+	mdb.logger(ctx).Infoln("Activating solution template")
+
+	return mdb.write(func(t *tables) error {
+		var inactive []*templateRow
+		for _, row := range t.templates {
+			if row.Name == solution && !row.Active {
+				inactive = append(inactive, row)
+			}
+		}
+		if len(inactive) == 0 {
+			return solerrors.ErrTemplateNotExist()
+		}
+		if len(inactive) > 1 || t.activeTemplate(solution) != nil {
+			return db.ErrUniqueViolation
+		}
+		inactive[0].Active = true
+		inactive[0].Version++
+		return nil
+	})
+}
+
+func (mdb *memDB) DeactivateTemplate(ctx context.Context, solution string) error {
+	mdb.logger(ctx).Infoln("Deactivating solution template")
+
+	return mdb.write(func(t *tables) error {
+		row := t.activeTemplate(solution)
+		if row == nil {
+			return solerrors.ErrSolutionNotExist()
+		}
+		row.Active = false
+		row.Version++
+		return nil
+	})
+}
+
+func (mdb *memDB) DeleteTemplate(ctx context.Context, solution string) error {
+	mdb.logger(ctx).Infoln("deleting solution template")
+
+	return mdb.write(func(t *tables) error {
+		var kept []*templateRow
+		deleted := make(map[string]bool)
+		for _, row := range t.templates {
+			if row.Name == solution {
+				deleted[row.ID] = true
+			} else {
+				kept = append(kept, row)
+			}
+		}
+		if len(deleted) == 0 {
+			return solerrors.ErrSolutionNotExist()
+		}
+		for _, row := range t.solutions {
+			if deleted[row.TemplateID] {
+				return db.ErrForeignKeyViolation
+			}
+		}
+		t.templates = kept
+		return nil
+	})
+}
+
+func (mdb *memDB) GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsTemplatesList, error) {
+	mdb.logger(ctx).Infoln("Get solutions templates list")
+	var ret kube_types.SolutionsTemplatesList
+
+	err := mdb.read(func(t *tables) error {
+		for _, row := range t.templates {
+			if isAdmin || row.Active {
+				ret.Solutions = append(ret.Solutions, row.template())
+			}
+		}
+		return nil
+	})
+	return &ret, err
+}
+
+func (mdb *memDB) GetTemplate(ctx context.Context, name string) (*model.Template, error) {
+	mdb.logger(ctx).Infoln("Get solution template ", name)
+
+	var ret model.Template
+	err := mdb.read(func(t *tables) error {
+		row := t.activeTemplate(name)
+		if row == nil {
+			return solerrors.ErrTemplateNotExist()
+		}
+		ret = model.Template{SolutionTemplate: row.template(), ETag: model.ETag(row.ID, row.Version)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// LockTemplate does nothing except transaction check: transactions hold writer lock of database
+func (mdb *memDB) LockTemplate(ctx context.Context, name string) error {
+	mdb.logger(ctx).Debugln("Lock template")
+	if mdb.writeMu != nil {
+		return db.ErrNotInTransaction
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+)
+
+func (row *webhookRow) webhook() model.Webhook {
+	webhook := row.Webhook
+	webhook.Events = copyStrings(row.Events)
+	webhook.CreatedAt = row.CreatedAt.Format(time.RFC3339)
+	return webhook
+}
+
+func (t *tables) webhook(webhookID string) *webhookRow {
+	for _, row := range t.webhooks {
+		if row.ID == webhookID {
+			return row
+		}
+	}
+	return nil
+}
+
+func (mdb *memDB) CreateWebhook(ctx context.Context, webhook model.Webhook) error {
+	mdb.logger(ctx).Infoln("Saving webhook")
+
+	id, err := parseUUID(webhook.ID)
+	if err != nil {
+		return err
+	}
+	webhook.ID = id
+	webhook.Events = copyStrings(webhook.Events)
+	webhook.CreatedAt = ""
+
+	return mdb.write(func(t *tables) error {
+		if t.webhook(id) != nil {
+			return db.ErrUniqueViolation
+		}
+		t.webhooks = append(t.webhooks, &webhookRow{Webhook: webhook, CreatedAt: dbTime(time.Now())})
+		return nil
+	})
+}
+
+// selectWebhooks returns webhooks matching filter
+func (mdb *memDB) selectWebhooks(match func(row *webhookRow) bool) (*model.WebhooksList, error) {
+	ret := model.WebhooksList{Webhooks: make([]model.Webhook, 0)}
+	err := mdb.read(func(t *tables) error {
+		for _, row := range t.webhooks {
+			if match(row) {
+				ret.Webhooks = append(ret.Webhooks, row.webhook())
+			}
+		}
+		return nil
+	})
+	return &ret, err
+}
+
+func (mdb *memDB) GetWebhooksList(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	mdb.logger(ctx).Infoln("Get webhooks list")
+
+	// rows are already ordered by creation time
+	return mdb.selectWebhooks(func(row *webhookRow) bool {
+		return row.Namespace == namespace
+	})
+}
+
+func (mdb *memDB) GetEventWebhooks(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	mdb.logger(ctx).Debugln("Get event webhooks")
+
+	return mdb.selectWebhooks(func(row *webhookRow) bool {
+		return row.Namespace == namespace || row.Namespace == ""
+	})
+}
+
+func (mdb *memDB) GetWebhook(ctx context.Context, namespace, webhookID string) (*model.Webhook, error) {
+	mdb.logger(ctx).Infoln("Get webhook")
+
+	webhookID, err := parseUUID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := mdb.selectWebhooks(func(row *webhookRow) bool {
+		return row.ID == webhookID && row.Namespace == namespace
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks.Webhooks) == 0 {
+		return nil, solerrors.ErrWebhookNotExist()
+	}
+	return &webhooks.Webhooks[0], nil
+}
+
+func (mdb *memDB) DeleteWebhook(ctx context.Context, namespace, webhookID string) error {
+	mdb.logger(ctx).Infoln("Deleting webhook")
+
+	webhookID, err := parseUUID(webhookID)
+	if err != nil {
+		return err
+	}
+	return mdb.write(func(t *tables) error {
+		row := t.webhook(webhookID)
+		if row == nil || row.Namespace != namespace {
+			return solerrors.ErrWebhookNotExist()
+		}
+
+		var webhooks []*webhookRow
+		for _, row := range t.webhooks {
+			if row.ID != webhookID {
+				webhooks = append(webhooks, row)
+			}
+		}
+		t.webhooks = webhooks
+
+		var deliveries []*deliveryRow
+		for _, row := range t.deliveries {
+			if row.WebhookID != webhookID {
+				deliveries = append(deliveries, row)
+			}
+		}
+		t.deliveries = deliveries
+		return nil
+	})
+}
+
+func (mdb *memDB) AddWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	mdb.logger(ctx).Debugln("Saving webhook delivery")
+
+	var err error
+	for _, id := range []*string{&delivery.ID, &delivery.WebhookID, &delivery.EventID} {
+		if *id, err = parseUUID(*id); err != nil {
+			return err
+		}
+	}
+
+	return mdb.write(func(t *tables) error {
+		if t.delivery(delivery.ID) != nil {
+			return db.ErrUniqueViolation
+		}
+		if t.webhook(delivery.WebhookID) == nil {
+			return db.ErrForeignKeyViolation
+		}
+		now := dbTime(time.Now())
+		row := &deliveryRow{
+			WebhookDelivery: model.WebhookDelivery{
+				ID:        delivery.ID,
+				WebhookID: delivery.WebhookID,
+				EventID:   delivery.EventID,
+				Action:    delivery.Action,
+				URL:       delivery.URL,
+				Payload:   append([]byte{}, delivery.Payload...),
+				Signature: delivery.Signature,
+				Status:    model.DeliveryPending,
+			},
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		}
+		t.deliveries = append(t.deliveries, row)
+		return nil
+	})
+}
+
+func (t *tables) delivery(deliveryID string) *deliveryRow {
+	for _, row := range t.deliveries {
+		if row.ID == deliveryID {
+			return row
+		}
+	}
+	return nil
+}
+
+func (row *deliveryRow) delivery() model.WebhookDelivery {
+	delivery := row.WebhookDelivery
+	delivery.Payload = append([]byte{}, row.Payload...)
+	delivery.CreatedAt = row.CreatedAt.Format(time.RFC3339)
+	if delivery.Status == model.DeliveryPending {
+		delivery.NextAttemptAt = row.NextAttemptAt.Format(time.RFC3339)
+	}
+	if row.DeliveredAt != nil {
+		delivery.DeliveredAt = row.DeliveredAt.Format(time.RFC3339)
+	}
+	return delivery
+}
+
+func (mdb *memDB) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) (*model.WebhookDeliveriesList, error) {
+	mdb.logger(ctx).Infoln("Get webhook deliveries")
+
+	webhookID, err := parseUUID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*deliveryRow
+	err = mdb.read(func(t *tables) error {
+		for i := len(t.deliveries) - 1; i >= 0; i-- {
+			if t.deliveries[i].WebhookID == webhookID {
+				rows = append(rows, t.deliveries[i])
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].CreatedAt.After(rows[j].CreatedAt)
+		})
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := model.WebhookDeliveriesList{Deliveries: make([]model.WebhookDelivery, 0, len(rows))}
+	for _, row := range rows {
+		ret.Deliveries = append(ret.Deliveries, row.delivery())
+	}
+	return &ret, nil
+}
+
+func (mdb *memDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (*model.WebhookDeliveriesList, error) {
+	mdb.logger(ctx).Debugln("Claiming webhook deliveries")
+
+	ret := model.WebhookDeliveriesList{Deliveries: make([]model.WebhookDelivery, 0)}
+	err := mdb.write(func(t *tables) error {
+		var rows []*deliveryRow
+		for _, row := range t.deliveries {
+			if row.Status == model.DeliveryPending && !row.NextAttemptAt.After(dbTime(now)) {
+				rows = append(rows, row)
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].NextAttemptAt.Before(rows[j].NextAttemptAt)
+		})
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		for _, row := range rows {
+			row.NextAttemptAt = dbTime(now.Add(lease))
+			ret.Deliveries = append(ret.Deliveries, row.delivery())
+		}
+		return nil
+	})
+	return &ret, err
+}
+
+func (mdb *memDB) SetWebhookDeliveryResult(ctx context.Context, deliveryID, status string, attempts int, lastError string, nextAttemptAt time.Time) error {
+	mdb.logger(ctx).Debugln("Saving webhook delivery result")
+
+	deliveryID, err := parseUUID(deliveryID)
+	if err != nil {
+		return err
+	}
+	return mdb.write(func(t *tables) error {
+		row := t.delivery(deliveryID)
+		if row == nil {
+			return nil
+		}
+		row.Status = status
+		row.Attempts = attempts
+		row.LastError = lastError
+		row.NextAttemptAt = dbTime(nextAttemptAt)
+		row.DeliveredAt = nil
+		if status == model.DeliveryDelivered {
+			now := dbTime(time.Now())
+			row.DeliveredAt = &now
+		}
+		return nil
+	})
+}
+
+func (mdb *memDB) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) error {
+	mdb.logger(ctx).Infoln("Scheduling webhook redelivery")
+
+	var err error
+	if webhookID, err = parseUUID(webhookID); err != nil {
+		return err
+	}
+	if deliveryID, err = parseUUID(deliveryID); err != nil {
+		return err
+	}
+	return mdb.write(func(t *tables) error {
+		row := t.delivery(deliveryID)
+		if row == nil || row.WebhookID != webhookID {
+			return solerrors.ErrWebhookDeliveryNotExist()
+		}
+		row.Status = model.DeliveryPending
+		row.Attempts = 0
+		row.LastError = ""
+		row.NextAttemptAt = dbTime(time.Now())
+		row.DeliveredAt = nil
+		return nil
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"context"
 
 	"git.containerum.net/ch/solutions/pkg/db"
-	chutils "github.com/containerum/utils/sqlxutil"
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"git.containerum.net/ch/solutions/pkg/utils"
 	"github.com/golang-migrate/migrate"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // postgresql database driver
@@ -24,6 +26,7 @@ type pgDB struct {
 	log  *logrus.Entry
 	qLog sqlx.QueryerContext
 	eLog sqlx.ExecerContext
+	inTx bool
 }
 
 // DBConnect initializes connection to postgresql database.
@@ -45,16 +48,21 @@ func DBConnect(pgConnStr string, migrationsPath string) (db.DB, error) {
 	ret := &pgDB{
 		conn: conn,
 		log:  log,
-		qLog: chutils.NewSQLXContextQueryLogger(conn, log),
-		eLog: chutils.NewSQLXContextExecLogger(conn, log),
+		qLog: instrumentedQueryer{q: conn, log: log},
+		eLog: instrumentedExecer{e: conn, log: log},
 	}
 
 	m, err := ret.migrateUp(migrationsPath)
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 	version, _, _ := m.Version()
 	log.WithField("version", version).Infoln("Migrate up")
+	// releases connection of migrations driver, database is not closed by driver
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		log.Warnf("Unable to close migrations: source: %v, database: %v", srcErr, dbErr)
+	}
 
 	return ret, nil
 }
@@ -91,7 +99,9 @@ func (pgdb *pgDB) migrateUp(path string) (*migrate.Migrate, error) {
 
 func (pgdb *pgDB) Transactional(ctx context.Context, f func(ctx context.Context, tx db.DB) error) (err error) {
 	start := time.Now().Format(time.ANSIC)
-	e := pgdb.log.WithField("transaction_at", start)
+	e := pgdb.logger(ctx).WithField("transaction_at", start)
+	ctx, span := tracing.StartSpan(ctx, "db transaction", tracing.SpanKindInternal)
+	defer func() { span.Finish(err) }()
 	e.Debugln("Begin transaction")
 	tx, txErr := pgdb.conn.Beginx()
 	if txErr != nil {
@@ -102,8 +112,9 @@ func (pgdb *pgDB) Transactional(ctx context.Context, f func(ctx context.Context,
 	arg := &pgDB{
 		conn: pgdb.conn,
 		log:  e,
-		eLog: chutils.NewSQLXContextExecLogger(tx, e),
-		qLog: chutils.NewSQLXContextQueryLogger(tx, e),
+		eLog: instrumentedExecer{e: tx, log: e},
+		qLog: instrumentedQueryer{q: tx, log: e},
+		inTx: true,
 	}
 
 	// needed for recovering panics in transactions.
@@ -115,6 +126,8 @@ func (pgdb *pgDB) Transactional(ctx context.Context, f func(ctx context.Context,
 
 		if dberr != nil {
 			e.WithError(dberr).Debugln("Rollback transaction")
+			metrics.DBTransactionRollbacks.Inc()
+			span.SetAttribute("db.rollback", "true")
 			if rerr := tx.Rollback(); rerr != nil {
 				e.WithError(rerr).Errorln("Rollback error")
 				err = db.ErrTransactionRollback
@@ -132,6 +145,15 @@ func (pgdb *pgDB) Transactional(ctx context.Context, f func(ctx context.Context,
 	return err
 }
 
+// logger returns db log entry with request-scoped fields
+func (pgdb *pgDB) logger(ctx context.Context) *logrus.Entry {
+	return utils.LogEntry(ctx, pgdb.log)
+}
+
+func (pgdb *pgDB) Ping(ctx context.Context) error {
+	return pgdb.conn.PingContext(ctx)
+}
+
 func (pgdb *pgDB) Close() error {
 	return pgdb.conn.Close()
 }
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"git.containerum.net/ch/solutions/pkg/db"
+	"github.com/lib/pq"
+)
+
+// postgresql error codes, see https://www.postgresql.org/docs/current/static/errcodes-appendix.html
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// mapError replaces constraint violation errors of postgresql driver with errors of db package
+func mapError(err error) error {
+	if pqerr, ok := err.(*pq.Error); ok {
+		switch pqerr.Code {
+		case uniqueViolation:
+			return db.ErrUniqueViolation
+		case foreignKeyViolation:
+			return db.ErrForeignKeyViolation
+		}
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"github.com/jmoiron/sqlx"
+)
+
+func (pgdb *pgDB) AddEvent(ctx context.Context, event model.Event) error {
+	pgdb.logger(ctx).Debugln("Saving event")
+
+	_, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO events (id, created_at, action, outcome, error, user_id, user_role, namespace, solution, template, request_id) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		event.ID, time.Now().UTC(), event.Action, event.Outcome, event.Error, event.UserID, event.UserRole, event.Namespace, event.Solution, event.Template, event.RequestID)
+	return err
+}
+
+func (pgdb *pgDB) GetEvents(ctx context.Context, filter model.EventsFilter) (*model.EventsList, error) {
+	pgdb.logger(ctx).Infoln("Get events")
+
+	var conds []string
+	var args []interface{}
+	addCond := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	for _, field := range []struct{ column, value string }{
+		{"user_id", filter.UserID},
+		{"namespace", filter.Namespace},
+		{"solution", filter.Solution},
+		{"template", filter.Template},
+		{"action", filter.Action},
+		{"outcome", filter.Outcome},
+	} {
+		if field.value != "" {
+			addCond(field.column+" = $%d", field.value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		addCond("created_at >= $%d", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		addCond("created_at < $%d", filter.Until.UTC())
+	}
+
+	query := "SELECT " + rowmap.EventColumns + " FROM events"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	var rows []rowmap.Event
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.Events(rows), nil
+}
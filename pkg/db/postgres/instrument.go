@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	chutils "github.com/containerum/utils/sqlxutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// instrument starts span of database operation and returns function which ends it and records latency
+func instrument(ctx context.Context, operation, query string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.StartSpan(ctx, "db "+operation, tracing.SpanKindClient)
+	span.SetAttribute("db.system", "postgresql")
+	span.SetAttribute("db.statement", query)
+	return ctx, func(err error) {
+		metrics.DBQueryDuration.ObserveSince(start, operation)
+		span.Finish(err)
+	}
+}
+
+// instrumentedQueryer logs, measures latency and traces queries made with wrapped queryer.
+// Queries are logged with request-scoped log entry fields. Constraint violation errors are replaced with db errors.
+type instrumentedQueryer struct {
+	q   sqlx.QueryerContext
+	log *logrus.Entry
+}
+
+func (iq instrumentedQueryer) queryer(ctx context.Context) sqlx.QueryerContext {
+	return chutils.NewSQLXContextQueryLogger(iq.q, utils.LogEntry(ctx, iq.log))
+}
+
+func (iq instrumentedQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	ctx, done := instrument(ctx, "query", query)
+	defer func() { done(err) }()
+	rows, err = iq.queryer(ctx).QueryContext(ctx, query, args...)
+	return rows, mapError(err)
+}
+
+func (iq instrumentedQueryer) QueryxContext(ctx context.Context, query string, args ...interface{}) (rows *sqlx.Rows, err error) {
+	ctx, done := instrument(ctx, "query", query)
+	defer func() { done(err) }()
+	rows, err = iq.queryer(ctx).QueryxContext(ctx, query, args...)
+	return rows, mapError(err)
+}
+
+func (iq instrumentedQueryer) QueryRowxContext(ctx context.Context, query string, args ...interface{}) (row *sqlx.Row) {
+	ctx, done := instrument(ctx, "query", query)
+	defer func() { done(row.Err()) }()
+	return iq.queryer(ctx).QueryRowxContext(ctx, query, args...)
+}
+
+// instrumentedExecer logs, measures latency and traces statements executed with wrapped execer.
+// Constraint violation errors are replaced with db errors.
+type instrumentedExecer struct {
+	e   sqlx.ExecerContext
+	log *logrus.Entry
+}
+
+func (ie instrumentedExecer) execer(ctx context.Context) sqlx.ExecerContext {
+	return chutils.NewSQLXContextExecLogger(ie.e, utils.LogEntry(ctx, ie.log))
+}
+
+func (ie instrumentedExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	ctx, done := instrument(ctx, "exec", query)
+	defer func() { done(err) }()
+	res, err = ie.execer(ctx).ExecContext(ctx, query, args...)
+	return res, mapError(err)
+}
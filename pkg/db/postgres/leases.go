@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+)
+
+func (pgdb *pgDB) AcquireSolutionLease(ctx context.Context, namespace, solutionName, holder string, now, expiresAt time.Time) error {
+	pgdb.logger(ctx).Debugln("Acquiring solution lease")
+
+	res, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO solution_leases (namespace, name, holder, expires_at) VALUES ($1, $2, $3, $4) "+
+		"ON CONFLICT (namespace, name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at "+
+		"WHERE solution_leases.holder = excluded.holder OR solution_leases.expires_at <= $5",
+		namespace, solutionName, holder, expiresAt.UTC(), now.UTC())
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return db.ErrLeaseConflict
+	}
+	return nil
+}
+
+func (pgdb *pgDB) ReleaseSolutionLease(ctx context.Context, namespace, solutionName, holder string) error {
+	pgdb.logger(ctx).Debugln("Releasing solution lease")
+
+	_, err := pgdb.eLog.ExecContext(ctx, "DELETE FROM solution_leases WHERE namespace = $1 AND name = $2 AND holder = $3", namespace, solutionName, holder)
+	return err
+}
@@ -2,19 +2,27 @@ package postgres
 
 import (
 	"context"
-
+	"database/sql"
 	"time"
 
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
 	"git.containerum.net/ch/solutions/pkg/solerrors"
 	kube_types "github.com/containerum/kube-client/pkg/model"
-	"github.com/json-iterator/go"
+	"github.com/jmoiron/sqlx"
 )
 
-func (pgdb *pgDB) AddSolution(ctx context.Context, solution kube_types.Solution, userID, templateID, uuid, env string) error {
-	pgdb.log.Infoln("Saving solution")
+func (pgdb *pgDB) AddSolution(ctx context.Context, solution kube_types.Solution, userID, templateID, uuid, env string, expiresAt *time.Time) error {
+	pgdb.logger(ctx).Infoln("Saving solution")
+
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
 
-	if _, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO solutions (id, template_id, name, namespace, user_id) "+
-		"VALUES ($1, $2, $3, $4, $5)", uuid, templateID, solution.Name, solution.Namespace, userID); err != nil {
+	if _, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO solutions (id, template_id, name, namespace, user_id, expires_at) "+
+		"VALUES ($1, $2, $3, $4, $5, $6)", uuid, templateID, solution.Name, solution.Namespace, userID, expiresAt); err != nil {
 		return err
 	}
 
@@ -25,103 +33,190 @@ func (pgdb *pgDB) AddSolution(ctx context.Context, solution kube_types.Solution,
 	return nil
 }
 
-func (pgdb *pgDB) GetSolutionsList(ctx context.Context, userID string) (*kube_types.SolutionsList, error) {
-	pgdb.log.Infoln("Get solutions list")
-	var ret kube_types.SolutionsList
+// solutionsQuery selects not deleted solutions, conditions may be added with "AND"
+const solutionsQuery = "SELECT " + rowmap.SolutionColumns + " FROM " + rowmap.SolutionTables + " WHERE NOT solutions.is_deleted"
+
+func (pgdb *pgDB) selectSolutions(ctx context.Context, cond string, args ...interface{}) (*model.SolutionsList, error) {
+	var rows []rowmap.Solution
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, solutionsQuery+cond, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.Solutions(rows)
+}
+
+func (pgdb *pgDB) GetAllSolutionsList(ctx context.Context) (*model.SolutionsList, error) {
+	pgdb.logger(ctx).Infoln("Get all solutions list")
+
+	return pgdb.selectSolutions(ctx, "")
+}
+
+func (pgdb *pgDB) GetSolutionsList(ctx context.Context, userID string) (*model.SolutionsList, error) {
+	pgdb.logger(ctx).Infoln("Get solutions list")
+
+	return pgdb.selectSolutions(ctx, " AND solutions.user_id = $1", userID)
+}
+
+func (pgdb *pgDB) GetNamespaceSolutionsList(ctx context.Context, namespace string) (*model.SolutionsList, error) {
+	pgdb.logger(ctx).Infoln("Get solutions list")
+
+	return pgdb.selectSolutions(ctx, " AND solutions.namespace = $1", namespace)
+}
 
-	ret.Solutions = make([]kube_types.Solution, 0)
+func (pgdb *pgDB) GetSolution(ctx context.Context, namespace, solutionName string) (*model.Solution, error) {
+	pgdb.logger(ctx).Infoln("Get solution")
 
-	rows, err := pgdb.qLog.QueryxContext(ctx, "SELECT templates.name, templates.url, solutions.id, solutions.name, solutions.namespace, parameters.env, parameters.branch "+
-		"FROM solutions JOIN parameters ON solutions.id = parameters.solution_id JOIN templates ON solutions.template_id = templates.ID WHERE solutions.user_id=$1 AND solutions.is_deleted !='true'", userID)
+	var row rowmap.Solution
+	if err := sqlx.GetContext(ctx, pgdb.qLog, &row, solutionsQuery+" AND solutions.name = $1 AND solutions.namespace = $2", solutionName, namespace); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrSolutionNotExist()
+		}
+		return nil, err
+	}
+
+	solution, err := row.Model()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		solution := kube_types.Solution{}
-		var env string
-		err := rows.Scan(&solution.Template, &solution.URL, &solution.ID, &solution.Name, &solution.Namespace, &env, &solution.Branch)
-		if err != nil {
-			return nil, err
-		}
-		if err := jsoniter.UnmarshalFromString(env, &solution.Env); err != nil {
-			return nil, err
-		}
+	return &solution, nil
+}
+
+func (pgdb *pgDB) DeleteSolution(ctx context.Context, namespace, solutionName string) error {
+	pgdb.logger(ctx).Infoln("Deleting solution")
+
+	res, err := pgdb.eLog.ExecContext(ctx, "UPDATE solutions SET is_deleted = TRUE, deleted_at = $1 WHERE name = $2 AND namespace = $3 AND NOT is_deleted", time.Now().UTC(), solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (pgdb *pgDB) CompletelyDeleteSolution(ctx context.Context, namespace, solutionName string) error {
+	pgdb.logger(ctx).Infoln("Deleting solution")
 
-		solution.URL = solution.URL + "/tree/" + solution.Branch
-		ret.Solutions = append(ret.Solutions, solution)
+	res, err := pgdb.eLog.ExecContext(ctx, "DELETE FROM solutions WHERE name=$1 AND namespace=$2", solutionName, namespace)
+	if err != nil {
+		return err
 	}
 
-	return &ret, rows.Err()
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
 }
 
-func (pgdb *pgDB) GetNamespaceSolutionsList(ctx context.Context, namespace string) (*kube_types.SolutionsList, error) {
-	pgdb.log.Infoln("Get solutions list")
-	var ret kube_types.SolutionsList
+func (pgdb *pgDB) StopSolution(ctx context.Context, namespace, solutionName string, replicas map[string]int) error {
+	pgdb.logger(ctx).Infoln("Stopping solution")
+
+	var solutionID string
+	if err := sqlx.GetContext(ctx, pgdb.qLog, &solutionID, "UPDATE solutions SET status = $1, version = version + 1 WHERE name = $2 AND namespace = $3 AND status = $4 AND NOT is_deleted RETURNING id",
+		model.SolutionStopped, solutionName, namespace, model.SolutionRunning); err != nil {
+		if err == sql.ErrNoRows {
+			return solerrors.ErrInvalidSolutionState()
+		}
+		return err
+	}
 
-	ret.Solutions = make([]kube_types.Solution, 0)
+	for deploy, count := range replicas {
+		if _, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO replicas (solution_id, deployment, replicas) VALUES ($1, $2, $3)", solutionID, deploy, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pgdb *pgDB) StartSolution(ctx context.Context, namespace, solutionName string) (map[string]int, error) {
+	pgdb.logger(ctx).Infoln("Starting solution")
+
+	var solutionID string
+	if err := sqlx.GetContext(ctx, pgdb.qLog, &solutionID, "UPDATE solutions SET status = $1, version = version + 1 WHERE name = $2 AND namespace = $3 AND status = $4 AND NOT is_deleted RETURNING id",
+		model.SolutionRunning, solutionName, namespace, model.SolutionStopped); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrInvalidSolutionState()
+		}
+		return nil, err
+	}
 
-	rows, err := pgdb.qLog.QueryxContext(ctx, "SELECT templates.name, templates.url, solutions.id, solutions.name, solutions.namespace, parameters.env, parameters.branch "+
-		"FROM solutions JOIN parameters ON solutions.id = parameters.solution_id JOIN templates ON solutions.template_id = templates.ID WHERE solutions.namespace=$1 AND solutions.is_deleted !='true'", namespace)
+	rows, err := pgdb.qLog.QueryxContext(ctx, "DELETE FROM replicas WHERE solution_id = $1 RETURNING deployment, replicas", solutionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	ret := make(map[string]int)
 	for rows.Next() {
-		solution := kube_types.Solution{}
-		var env string
-		err := rows.Scan(&solution.Template, &solution.URL, &solution.ID, &solution.Name, &solution.Namespace, &env, &solution.Branch)
-		if err != nil {
-			return nil, err
-		}
-		if err := jsoniter.UnmarshalFromString(env, &solution.Env); err != nil {
+		var deploy string
+		var count int
+		if err := rows.Scan(&deploy, &count); err != nil {
 			return nil, err
 		}
-
-		solution.URL = solution.URL + "/tree/" + solution.Branch
-		ret.Solutions = append(ret.Solutions, solution)
+		ret[deploy] = count
 	}
-
-	return &ret, rows.Err()
+	return ret, rows.Err()
 }
 
-func (pgdb *pgDB) GetSolution(ctx context.Context, namespace, solutionName string) (*kube_types.Solution, error) {
-	pgdb.log.Infoln("Get solution")
-
-	var solution kube_types.Solution
+func (pgdb *pgDB) SetSolutionSchedule(ctx context.Context, namespace, solutionName string, schedule model.SolutionSchedule) error {
+	pgdb.logger(ctx).Infoln("Setting solution schedule")
 
-	rows, err := pgdb.qLog.QueryxContext(ctx, "SELECT templates.name, templates.url, solutions.id, solutions.name, solutions.namespace, parameters.env, parameters.branch "+
-		"FROM solutions JOIN parameters ON solutions.id = parameters.solution_id JOIN templates ON solutions.template_id = templates.ID WHERE solutions.name=$1 AND solutions.namespace=$2 AND solutions.is_deleted !='true'", solutionName, namespace)
+	res, err := pgdb.eLog.ExecContext(ctx, "UPDATE solutions SET start_schedule = $1, stop_schedule = $2, version = version + 1 WHERE name = $3 AND namespace = $4 AND NOT is_deleted",
+		schedule.Start, schedule.Stop, solutionName, namespace)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer rows.Close()
-	if !rows.Next() {
-		if rows.Err() != nil {
-			return nil, rows.Err()
-		}
-		return nil, solerrors.ErrSolutionNotExist()
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
 	}
+	return err
+}
+
+func (pgdb *pgDB) SetSolutionExpiry(ctx context.Context, namespace, solutionName string, expiresAt time.Time) error {
+	pgdb.logger(ctx).Infoln("Setting solution expiry")
 
-	var env string
-	err = rows.Scan(&solution.Template, &solution.URL, &solution.ID, &solution.Name, &solution.Namespace, &env, &solution.Branch)
+	res, err := pgdb.eLog.ExecContext(ctx, "UPDATE solutions SET expires_at = $1, expiry_warned = FALSE, version = version + 1 WHERE name = $2 AND namespace = $3 AND NOT is_deleted",
+		expiresAt.UTC(), solutionName, namespace)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
 	}
-	if err := jsoniter.UnmarshalFromString(env, &solution.Env); err != nil {
+	return err
+}
+
+func (pgdb *pgDB) GetExpiredSolutions(ctx context.Context, now time.Time) (*model.SolutionsList, error) {
+	pgdb.logger(ctx).Infoln("Get expired solutions")
+
+	var rows []rowmap.SolutionExpiry
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, "SELECT "+rowmap.SolutionExpiryColumns+" FROM solutions WHERE expires_at <= $1 AND NOT is_deleted", now.UTC()); err != nil {
 		return nil, err
 	}
+	return rowmap.SolutionsExpiry(rows), nil
+}
 
-	solution.URL = solution.URL + "/tree/" + solution.Branch
+func (pgdb *pgDB) MarkSolutionsExpiryWarned(ctx context.Context, now, before time.Time) (*model.SolutionsList, error) {
+	pgdb.logger(ctx).Infoln("Marking expiring solutions warned")
 
-	return &solution, rows.Err()
+	var rows []rowmap.SolutionExpiry
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, "UPDATE solutions SET expiry_warned = TRUE WHERE expires_at > $1 AND expires_at <= $2 AND NOT expiry_warned AND NOT is_deleted "+
+		"RETURNING "+rowmap.SolutionExpiryColumns, now.UTC(), before.UTC()); err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionsExpiry(rows), nil
 }
 
-func (pgdb *pgDB) DeleteSolution(ctx context.Context, namespace, solutionName string) error {
-	pgdb.log.Infoln("Deleting solution")
+func (pgdb *pgDB) SetSolutionTrackBranch(ctx context.Context, namespace, solutionName string, track bool) error {
+	pgdb.logger(ctx).Infoln("Setting solution branch tracking")
 
-	res, err := pgdb.eLog.ExecContext(ctx, `UPDATE solutions SET is_deleted = 'true', deleted_at=$1 WHERE name=$2 AND namespace=$3 AND is_deleted != 'true'`, time.Now(), solutionName, namespace)
+	res, err := pgdb.eLog.ExecContext(ctx, "UPDATE solutions SET track_branch = $1, version = version + 1 WHERE name = $2 AND namespace = $3 AND NOT is_deleted",
+		track, solutionName, namespace)
 	if err != nil {
 		return err
 	}
@@ -133,10 +228,47 @@ func (pgdb *pgDB) DeleteSolution(ctx context.Context, namespace, solutionName st
 	return err
 }
 
-func (pgdb *pgDB) CompletelyDeleteSolution(ctx context.Context, namespace, solutionName string) error {
-	pgdb.log.Infoln("Deleting solution")
+func (pgdb *pgDB) GetTrackingSolutions(ctx context.Context, templateName, branch string) (*model.SolutionsList, error) {
+	pgdb.logger(ctx).Infoln("Get solutions tracking template branch")
 
-	res, err := pgdb.eLog.ExecContext(ctx, "DELETE FROM solutions WHERE name=$1 AND namespace=$2", solutionName, namespace)
+	var rows []rowmap.SolutionName
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, "SELECT "+rowmap.SolutionNameColumns+" FROM "+rowmap.SolutionTables+
+		" WHERE templates.name = $1 AND parameters.branch = $2 AND solutions.track_branch AND NOT solutions.is_deleted", templateName, branch); err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionNames(rows), nil
+}
+
+func (pgdb *pgDB) CountActiveSolutions(ctx context.Context) (map[string]int, error) {
+	pgdb.logger(ctx).Debugln("Count active solutions")
+
+	rows, err := pgdb.qLog.QueryxContext(ctx, "SELECT templates.name, count(*) "+
+		"FROM solutions JOIN templates ON solutions.template_id = templates.id "+
+		"WHERE NOT solutions.is_deleted GROUP BY templates.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make(map[string]int)
+	for rows.Next() {
+		var template string
+		var count int
+		if err := rows.Scan(&template, &count); err != nil {
+			return nil, err
+		}
+		ret[template] = count
+	}
+	return ret, rows.Err()
+}
+
+func (pgdb *pgDB) SaveSolutionResource(ctx context.Context, namespace, solutionName string, resource model.SolutionResource) error {
+	pgdb.logger(ctx).Debugln("Saving solution resource")
+
+	res, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO solution_resources (solution_id, kind, name, status, manifest_hash, created_at) "+
+		"SELECT id, $3, $4, $5, $6, now() FROM solutions WHERE name = $1 AND namespace = $2 AND NOT is_deleted "+
+		"ON CONFLICT (solution_id, kind, name) DO UPDATE SET status = excluded.status, manifest_hash = excluded.manifest_hash",
+		solutionName, namespace, resource.Kind, resource.Name, resource.Status, resource.ManifestHash)
 	if err != nil {
 		return err
 	}
@@ -148,20 +280,31 @@ func (pgdb *pgDB) CompletelyDeleteSolution(ctx context.Context, namespace, solut
 	return err
 }
 
-func (pgdb *pgDB) CompletelyDeleteSolutions(ctx context.Context, userID string) error {
-	pgdb.log.Infoln("Deleting user solutions")
+func (pgdb *pgDB) GetSolutionResources(ctx context.Context, namespace, solutionName string) (*model.SolutionResourcesList, error) {
+	pgdb.logger(ctx).Infoln("Get solution resources")
 
-	if _, err := pgdb.eLog.ExecContext(ctx, "DELETE FROM solutions WHERE user_id=$1", userID); err != nil {
-		return err
+	var solutionID string
+	if err := sqlx.GetContext(ctx, pgdb.qLog, &solutionID, "SELECT id FROM solutions WHERE name = $1 AND namespace = $2 AND NOT is_deleted", solutionName, namespace); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrSolutionNotExist()
+		}
+		return nil, err
 	}
-	return nil
-}
 
-func (pgdb *pgDB) CompletelyDeleteNamespaceSolutions(ctx context.Context, namespace string) error {
-	pgdb.log.Infoln("Deleting namespace solution")
+	var rows []rowmap.SolutionResource
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, "SELECT "+rowmap.SolutionResourceColumns+" FROM solution_resources WHERE solution_id = $1 ORDER BY created_at, kind, name", solutionID); err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionResources(rows), nil
+}
 
-	if _, err := pgdb.eLog.ExecContext(ctx, "DELETE FROM solutions WHERE namespace=$1", namespace); err != nil {
-		return err
+// LockSolution takes transaction-level advisory lock keyed by solution namespace and name
+func (pgdb *pgDB) LockSolution(ctx context.Context, namespace, solutionName string) error {
+	pgdb.logger(ctx).Debugln("Lock solution")
+	if !pgdb.inTx {
+		return db.ErrNotInTransaction
 	}
-	return nil
+
+	_, err := pgdb.eLog.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('solution'), hashtext($1 || '/' || $2))", namespace, solutionName)
+	return err
 }
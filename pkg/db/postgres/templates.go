@@ -2,19 +2,24 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
 	"git.containerum.net/ch/solutions/pkg/solerrors"
 	kube_types "github.com/containerum/kube-client/pkg/model"
-	"github.com/json-iterator/go"
+	"github.com/jmoiron/sqlx"
 )
 
 func (pgdb *pgDB) CreateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
-	pgdb.log.Infoln("Saving solution template")
+	pgdb.logger(ctx).Infoln("Saving solution template")
 
-	images, _ := jsoniter.Marshal(solution.Images)
+	images, _ := json.Marshal(solution.Images)
 
 	if _, err := pgdb.eLog.ExecContext(ctx,
-		`INSERT INTO templates (name, cpu, ram, images, url, active) VALUES ($1, $2, $3, $4, $5, $6);`, solution.Name, solution.Limits.CPU, solution.Limits.RAM, string(images), solution.URL, "true"); err != nil {
+		`INSERT INTO templates (name, cpu, ram, images, url, active) VALUES ($1, $2, $3, $4, $5, TRUE);`, solution.Name, solution.Limits.CPU, solution.Limits.RAM, string(images), solution.URL); err != nil {
 		return err
 	}
 
@@ -22,12 +27,12 @@ func (pgdb *pgDB) CreateTemplate(ctx context.Context, solution kube_types.Soluti
 }
 
 func (pgdb *pgDB) UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
-	pgdb.log.Infoln("Updating solution template")
+	pgdb.logger(ctx).Infoln("Updating solution template")
 
-	images, _ := jsoniter.Marshal(solution.Images)
+	images, _ := json.Marshal(solution.Images)
 
 	res, err := pgdb.eLog.ExecContext(ctx,
-		`UPDATE templates SET (cpu, ram, images, url) = ($2, $3, $4, $5) 
+		`UPDATE templates SET (cpu, ram, images, url, version) = ($2, $3, $4, $5, version + 1) 
 				WHERE name = $1`, solution.Name, solution.Limits.CPU, solution.Limits.RAM, string(images), solution.URL)
 	if err != nil {
 		return err
@@ -40,11 +45,11 @@ func (pgdb *pgDB) UpdateTemplate(ctx context.Context, solution kube_types.Soluti
 }
 
 func (pgdb *pgDB) ActivateTemplate(ctx context.Context, solution string) error {
-	pgdb.log.Infoln("Activating solution template")
+	pgdb.logger(ctx).Infoln("Activating solution template")
 
 	res, err := pgdb.eLog.ExecContext(ctx,
-		`UPDATE templates SET active = 'true' 
-				WHERE name = $1 AND active = 'false'`, solution)
+		`UPDATE templates SET active = TRUE, version = version + 1 
+				WHERE name = $1 AND NOT active`, solution)
 	if err != nil {
 		return err
 	}
@@ -56,11 +61,11 @@ func (pgdb *pgDB) ActivateTemplate(ctx context.Context, solution string) error {
 }
 
 func (pgdb *pgDB) DeactivateTemplate(ctx context.Context, solution string) error {
-	pgdb.log.Infoln("Deactivating solution template")
+	pgdb.logger(ctx).Infoln("Deactivating solution template")
 
 	res, err := pgdb.eLog.ExecContext(ctx,
-		`UPDATE templates SET active = 'false' 
-				WHERE name = $1 AND active = 'true'`, solution)
+		`UPDATE templates SET active = FALSE, version = version + 1 
+				WHERE name = $1 AND active`, solution)
 	if err != nil {
 		return err
 	}
@@ -72,7 +77,7 @@ func (pgdb *pgDB) DeactivateTemplate(ctx context.Context, solution string) error
 }
 
 func (pgdb *pgDB) DeleteTemplate(ctx context.Context, solution string) error {
-	pgdb.log.Infoln("deleting solution template")
+	pgdb.logger(ctx).Infoln("deleting solution template")
 
 	res, err := pgdb.eLog.ExecContext(ctx,
 		`DELETE FROM templates WHERE name = $1`, solution)
@@ -87,57 +92,46 @@ func (pgdb *pgDB) DeleteTemplate(ctx context.Context, solution string) error {
 }
 
 func (pgdb *pgDB) GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsTemplatesList, error) {
-	pgdb.log.Infoln("Get solutions templates list")
-	var ret kube_types.SolutionsTemplatesList
+	pgdb.logger(ctx).Infoln("Get solutions templates list")
 
-	query := "SELECT name, id, cpu, ram, images, url, active FROM templates"
+	query := "SELECT " + rowmap.TemplateColumns + " FROM templates"
 
 	if !isAdmin {
-		query = query + " WHERE active = 'true'"
+		query = query + " WHERE active"
 	}
 
-	rows, err := pgdb.qLog.QueryxContext(ctx, query)
-	if err != nil {
+	var rows []rowmap.Template
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, query); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		solution := kube_types.SolutionTemplate{Limits: &kube_types.SolutionLimits{}}
-		var images string
-		err := rows.Scan(&solution.Name, &solution.ID, &solution.Limits.CPU, &solution.Limits.RAM, &images, &solution.URL, &solution.Active)
-		if err != nil {
-			return nil, err
-		}
-		if err := jsoniter.UnmarshalFromString(images, &solution.Images); err != nil {
-			return nil, err
-		}
-
-		ret.Solutions = append(ret.Solutions, solution)
-	}
-
-	return &ret, rows.Err()
+	return rowmap.Templates(rows)
 }
 
-func (pgdb *pgDB) GetTemplate(ctx context.Context, name string) (*kube_types.SolutionTemplate, error) {
-	pgdb.log.Infoln("Get solution template ", name)
-	rows, err := pgdb.qLog.QueryxContext(ctx, "SELECT id, name, cpu, ram, images, url FROM templates WHERE name = $1 AND active = 'true'", name)
-	if err != nil {
+func (pgdb *pgDB) GetTemplate(ctx context.Context, name string) (*model.Template, error) {
+	pgdb.logger(ctx).Infoln("Get solution template ", name)
+
+	var row rowmap.Template
+	if err := sqlx.GetContext(ctx, pgdb.qLog, &row, "SELECT "+rowmap.TemplateColumns+" FROM templates WHERE name = $1 AND active", name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrTemplateNotExist()
+		}
 		return nil, err
 	}
-	defer rows.Close()
-	if !rows.Next() {
-		return nil, solerrors.ErrTemplateNotExist()
-	}
 
-	solution := kube_types.SolutionTemplate{Limits: &kube_types.SolutionLimits{}}
-	var images string
-	err = rows.Scan(&solution.ID, &solution.Name, &solution.Limits.CPU, &solution.Limits.RAM, &images, &solution.URL)
+	template, err := row.Model()
 	if err != nil {
 		return nil, err
 	}
-	if err = jsoniter.UnmarshalFromString(images, &solution.Images); err != nil {
-		return nil, err
+	return &template, nil
+}
+
+// LockTemplate takes transaction-level advisory lock keyed by template name
+func (pgdb *pgDB) LockTemplate(ctx context.Context, name string) error {
+	pgdb.logger(ctx).Debugln("Lock template")
+	if !pgdb.inTx {
+		return db.ErrNotInTransaction
 	}
 
-	return &solution, err
+	_, err := pgdb.eLog.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('template'), hashtext($1))", name)
+	return err
 }
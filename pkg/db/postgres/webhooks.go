@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/jmoiron/sqlx"
+)
+
+func (pgdb *pgDB) CreateWebhook(ctx context.Context, webhook model.Webhook) error {
+	pgdb.logger(ctx).Infoln("Saving webhook")
+
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = pgdb.eLog.ExecContext(ctx, "INSERT INTO webhooks (id, namespace, url, secret, events, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		webhook.ID, webhook.Namespace, webhook.URL, webhook.Secret, string(events), time.Now().UTC())
+	return err
+}
+
+func (pgdb *pgDB) selectWebhooks(ctx context.Context, query string, args ...interface{}) (*model.WebhooksList, error) {
+	var rows []rowmap.Webhook
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.Webhooks(rows)
+}
+
+func (pgdb *pgDB) GetWebhooksList(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	pgdb.logger(ctx).Infoln("Get webhooks list")
+
+	return pgdb.selectWebhooks(ctx, "SELECT "+rowmap.WebhookColumns+" FROM webhooks WHERE namespace = $1 ORDER BY created_at", namespace)
+}
+
+func (pgdb *pgDB) GetEventWebhooks(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	pgdb.logger(ctx).Debugln("Get event webhooks")
+
+	return pgdb.selectWebhooks(ctx, "SELECT "+rowmap.WebhookColumns+" FROM webhooks WHERE namespace = $1 OR namespace = ''", namespace)
+}
+
+func (pgdb *pgDB) GetWebhook(ctx context.Context, namespace, webhookID string) (*model.Webhook, error) {
+	pgdb.logger(ctx).Infoln("Get webhook")
+
+	webhooks, err := pgdb.selectWebhooks(ctx, "SELECT "+rowmap.WebhookColumns+" FROM webhooks WHERE id = $1 AND namespace = $2", webhookID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks.Webhooks) == 0 {
+		return nil, solerrors.ErrWebhookNotExist()
+	}
+	return &webhooks.Webhooks[0], nil
+}
+
+func (pgdb *pgDB) DeleteWebhook(ctx context.Context, namespace, webhookID string) error {
+	pgdb.logger(ctx).Infoln("Deleting webhook")
+
+	res, err := pgdb.eLog.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND namespace = $2", webhookID, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrWebhookNotExist()
+	}
+	return err
+}
+
+func (pgdb *pgDB) AddWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	pgdb.logger(ctx).Debugln("Saving webhook delivery")
+
+	now := time.Now().UTC()
+	_, err := pgdb.eLog.ExecContext(ctx, "INSERT INTO webhook_deliveries (id, webhook_id, event_id, action, url, payload, signature, status, created_at, next_attempt_at) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		delivery.ID, delivery.WebhookID, delivery.EventID, delivery.Action, delivery.URL, string(delivery.Payload), delivery.Signature, model.DeliveryPending, now, now)
+	return err
+}
+
+func (pgdb *pgDB) selectWebhookDeliveries(ctx context.Context, query string, args ...interface{}) (*model.WebhookDeliveriesList, error) {
+	var rows []rowmap.WebhookDelivery
+	if err := sqlx.SelectContext(ctx, pgdb.qLog, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.WebhookDeliveries(rows), nil
+}
+
+func (pgdb *pgDB) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) (*model.WebhookDeliveriesList, error) {
+	pgdb.logger(ctx).Infoln("Get webhook deliveries")
+
+	return pgdb.selectWebhookDeliveries(ctx, "SELECT "+rowmap.WebhookDeliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2", webhookID, limit)
+}
+
+func (pgdb *pgDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (*model.WebhookDeliveriesList, error) {
+	pgdb.logger(ctx).Debugln("Claiming webhook deliveries")
+
+	return pgdb.selectWebhookDeliveries(ctx, "UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id IN "+
+		"(SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= $3 ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED) "+
+		"RETURNING "+rowmap.WebhookDeliveryColumns,
+		now.Add(lease).UTC(), model.DeliveryPending, now.UTC(), limit)
+}
+
+func (pgdb *pgDB) SetWebhookDeliveryResult(ctx context.Context, deliveryID, status string, attempts int, lastError string, nextAttemptAt time.Time) error {
+	pgdb.logger(ctx).Debugln("Saving webhook delivery result")
+
+	var deliveredAt *time.Time
+	if status == model.DeliveryDelivered {
+		now := time.Now().UTC()
+		deliveredAt = &now
+	}
+	_, err := pgdb.eLog.ExecContext(ctx, "UPDATE webhook_deliveries SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, delivered_at = $5 WHERE id = $6",
+		status, attempts, lastError, nextAttemptAt.UTC(), deliveredAt, deliveryID)
+	return err
+}
+
+func (pgdb *pgDB) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) error {
+	pgdb.logger(ctx).Infoln("Scheduling webhook redelivery")
+
+	res, err := pgdb.eLog.ExecContext(ctx, "UPDATE webhook_deliveries SET status = $1, attempts = 0, last_error = '', next_attempt_at = $2, delivered_at = NULL WHERE id = $3 AND webhook_id = $4",
+		model.DeliveryPending, time.Now().UTC(), deliveryID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrWebhookDeliveryNotExist()
+	}
+	return err
+}
@@ -0,0 +1,302 @@
+// Package rowmap contains rows of SQL databases tables and their mapping to models.
+// It's shared by postgres and sqlite databases, which have same schema.
+// Rows are scanned by sqlx using "db" tags, so queries must select columns listed in *Columns constants.
+package rowmap
+
+import (
+	"encoding/json"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// SolutionColumns are columns selected from SolutionTables
+const SolutionColumns = "solutions.id, solutions.name, solutions.namespace, solutions.status, solutions.start_schedule, solutions.stop_schedule, " +
+	"solutions.expires_at, solutions.track_branch, solutions.version, parameters.branch, parameters.env, templates.name AS template, templates.url AS template_url"
+
+// SolutionTables joins solutions with their parameters and templates
+const SolutionTables = "solutions JOIN parameters ON solutions.id = parameters.solution_id JOIN templates ON solutions.template_id = templates.id"
+
+// Solution is a solution joined with its parameters and template
+type Solution struct {
+	ID            string     `db:"id"`
+	Name          string     `db:"name"`
+	Namespace     string     `db:"namespace"`
+	Status        string     `db:"status"`
+	StartSchedule string     `db:"start_schedule"`
+	StopSchedule  string     `db:"stop_schedule"`
+	ExpiresAt     *time.Time `db:"expires_at"`
+	TrackBranch   bool       `db:"track_branch"`
+	Version       int        `db:"version"`
+	Branch        string     `db:"branch"`
+	Env           string     `db:"env"`
+	Template      string     `db:"template"`
+	TemplateURL   string     `db:"template_url"`
+}
+
+func (row Solution) Model() (model.Solution, error) {
+	solution := model.Solution{
+		Solution: kube_types.Solution{
+			ID:        row.ID,
+			Branch:    row.Branch,
+			Template:  row.Template,
+			Name:      row.Name,
+			Namespace: row.Namespace,
+			URL:       row.TemplateURL + "/tree/" + row.Branch,
+		},
+		Status:        row.Status,
+		StartSchedule: row.StartSchedule,
+		StopSchedule:  row.StopSchedule,
+		TrackBranch:   row.TrackBranch,
+		ETag:          model.ETag(row.ID, row.Version),
+	}
+	if err := json.Unmarshal([]byte(row.Env), &solution.Env); err != nil {
+		return model.Solution{}, err
+	}
+	if row.ExpiresAt != nil {
+		solution.ExpiresAt = formatTime(*row.ExpiresAt)
+	}
+	return solution, nil
+}
+
+func Solutions(rows []Solution) (*model.SolutionsList, error) {
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0, len(rows))}
+	for _, row := range rows {
+		solution, err := row.Model()
+		if err != nil {
+			return nil, err
+		}
+		ret.Solutions = append(ret.Solutions, solution)
+	}
+	return &ret, nil
+}
+
+// SolutionNameColumns are columns selected from solutions table
+const SolutionNameColumns = "solutions.name, solutions.namespace"
+
+// SolutionName is a name and namespace of solution
+type SolutionName struct {
+	Name      string `db:"name"`
+	Namespace string `db:"namespace"`
+}
+
+func SolutionNames(rows []SolutionName) *model.SolutionsList {
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0, len(rows))}
+	for _, row := range rows {
+		var solution model.Solution
+		solution.Name, solution.Namespace = row.Name, row.Namespace
+		ret.Solutions = append(ret.Solutions, solution)
+	}
+	return &ret
+}
+
+// SolutionExpiryColumns are columns selected from solutions table.
+// Columns are not qualified with table name, so they may be used in "RETURNING" clause.
+const SolutionExpiryColumns = "name, namespace, expires_at"
+
+// SolutionExpiry is a name, namespace and expiration time of solution
+type SolutionExpiry struct {
+	Name      string    `db:"name"`
+	Namespace string    `db:"namespace"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func SolutionsExpiry(rows []SolutionExpiry) *model.SolutionsList {
+	ret := model.SolutionsList{Solutions: make([]model.Solution, 0, len(rows))}
+	for _, row := range rows {
+		var solution model.Solution
+		solution.Name, solution.Namespace = row.Name, row.Namespace
+		solution.ExpiresAt = formatTime(row.ExpiresAt)
+		ret.Solutions = append(ret.Solutions, solution)
+	}
+	return &ret
+}
+
+// SolutionResourceColumns are columns selected from solution_resources table
+const SolutionResourceColumns = "kind, name, status, manifest_hash, created_at"
+
+type SolutionResource struct {
+	Kind         string    `db:"kind"`
+	Name         string    `db:"name"`
+	Status       string    `db:"status"`
+	ManifestHash string    `db:"manifest_hash"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func SolutionResources(rows []SolutionResource) *model.SolutionResourcesList {
+	ret := model.SolutionResourcesList{Resources: make([]model.SolutionResource, 0, len(rows))}
+	for _, row := range rows {
+		ret.Resources = append(ret.Resources, model.SolutionResource{
+			Kind:         row.Kind,
+			Name:         row.Name,
+			Status:       row.Status,
+			ManifestHash: row.ManifestHash,
+			CreatedAt:    formatTime(row.CreatedAt),
+		})
+	}
+	return &ret
+}
+
+// TemplateColumns are columns selected from templates table
+const TemplateColumns = "id, name, cpu, ram, images, url, active, version"
+
+type Template struct {
+	ID      string `db:"id"`
+	Name    string `db:"name"`
+	CPU     uint   `db:"cpu"`
+	RAM     uint   `db:"ram"`
+	Images  string `db:"images"`
+	URL     string `db:"url"`
+	Active  bool   `db:"active"`
+	Version int    `db:"version"`
+}
+
+func (row Template) Model() (model.Template, error) {
+	template := model.Template{
+		SolutionTemplate: kube_types.SolutionTemplate{
+			ID:     row.ID,
+			Name:   row.Name,
+			Limits: &kube_types.SolutionLimits{CPU: row.CPU, RAM: row.RAM},
+			URL:    row.URL,
+			Active: row.Active,
+		},
+		ETag: model.ETag(row.ID, row.Version),
+	}
+	if err := json.Unmarshal([]byte(row.Images), &template.Images); err != nil {
+		return model.Template{}, err
+	}
+	return template, nil
+}
+
+// Templates returns templates list. Like before, list is nil if there are no templates.
+func Templates(rows []Template) (*kube_types.SolutionsTemplatesList, error) {
+	var ret kube_types.SolutionsTemplatesList
+	for _, row := range rows {
+		template, err := row.Model()
+		if err != nil {
+			return nil, err
+		}
+		ret.Solutions = append(ret.Solutions, template.SolutionTemplate)
+	}
+	return &ret, nil
+}
+
+// EventColumns are columns selected from events table
+const EventColumns = "id, created_at, action, outcome, error, user_id, user_role, namespace, solution, template, request_id"
+
+type Event struct {
+	ID        string    `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Action    string    `db:"action"`
+	Outcome   string    `db:"outcome"`
+	Error     string    `db:"error"`
+	UserID    string    `db:"user_id"`
+	UserRole  string    `db:"user_role"`
+	Namespace string    `db:"namespace"`
+	Solution  string    `db:"solution"`
+	Template  string    `db:"template"`
+	RequestID string    `db:"request_id"`
+}
+
+func Events(rows []Event) *model.EventsList {
+	ret := model.EventsList{Events: make([]model.Event, 0, len(rows))}
+	for _, row := range rows {
+		ret.Events = append(ret.Events, model.Event{
+			ID:        row.ID,
+			Time:      formatTime(row.CreatedAt),
+			Action:    row.Action,
+			Outcome:   row.Outcome,
+			Error:     row.Error,
+			UserID:    row.UserID,
+			UserRole:  row.UserRole,
+			Namespace: row.Namespace,
+			Solution:  row.Solution,
+			Template:  row.Template,
+			RequestID: row.RequestID,
+		})
+	}
+	return &ret
+}
+
+// WebhookColumns are columns selected from webhooks table
+const WebhookColumns = "id, namespace, url, secret, events, created_at"
+
+type Webhook struct {
+	ID        string    `db:"id"`
+	Namespace string    `db:"namespace"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Events    string    `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func Webhooks(rows []Webhook) (*model.WebhooksList, error) {
+	ret := model.WebhooksList{Webhooks: make([]model.Webhook, 0, len(rows))}
+	for _, row := range rows {
+		webhook := model.Webhook{
+			ID:        row.ID,
+			Namespace: row.Namespace,
+			URL:       row.URL,
+			Secret:    row.Secret,
+			CreatedAt: formatTime(row.CreatedAt),
+		}
+		if err := json.Unmarshal([]byte(row.Events), &webhook.Events); err != nil {
+			return nil, err
+		}
+		ret.Webhooks = append(ret.Webhooks, webhook)
+	}
+	return &ret, nil
+}
+
+// WebhookDeliveryColumns are columns selected from webhook_deliveries table.
+// Columns are not qualified with table name, so they may be used in "RETURNING" clause.
+const WebhookDeliveryColumns = "id, webhook_id, event_id, action, url, payload, signature, status, attempts, last_error, created_at, next_attempt_at, delivered_at"
+
+type WebhookDelivery struct {
+	ID            string     `db:"id"`
+	WebhookID     string     `db:"webhook_id"`
+	EventID       string     `db:"event_id"`
+	Action        string     `db:"action"`
+	URL           string     `db:"url"`
+	Payload       string     `db:"payload"`
+	Signature     string     `db:"signature"`
+	Status        string     `db:"status"`
+	Attempts      int        `db:"attempts"`
+	LastError     string     `db:"last_error"`
+	CreatedAt     time.Time  `db:"created_at"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+}
+
+// WebhookDeliveries returns deliveries list. Next attempt time is returned for pending deliveries only.
+func WebhookDeliveries(rows []WebhookDelivery) *model.WebhookDeliveriesList {
+	ret := model.WebhookDeliveriesList{Deliveries: make([]model.WebhookDelivery, 0, len(rows))}
+	for _, row := range rows {
+		delivery := model.WebhookDelivery{
+			ID:        row.ID,
+			WebhookID: row.WebhookID,
+			EventID:   row.EventID,
+			Action:    row.Action,
+			URL:       row.URL,
+			Payload:   []byte(row.Payload),
+			Signature: row.Signature,
+			Status:    row.Status,
+			Attempts:  row.Attempts,
+			LastError: row.LastError,
+			CreatedAt: formatTime(row.CreatedAt),
+		}
+		if row.Status == model.DeliveryPending {
+			delivery.NextAttemptAt = formatTime(row.NextAttemptAt)
+		}
+		if row.DeliveredAt != nil {
+			delivery.DeliveredAt = formatTime(*row.DeliveredAt)
+		}
+		ret.Deliveries = append(ret.Deliveries, delivery)
+	}
+	return &ret
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/golang-migrate/migrate"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // sqlite database driver
+
+	"github.com/golang-migrate/migrate/database"
+	migdrv "github.com/golang-migrate/migrate/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/source/file" // needed to load migrations scripts from files
+	"github.com/sirupsen/logrus"
+)
+
+type sqliteDB struct {
+	conn *sqlx.DB // do not use it in select/exec operations
+	log  *logrus.Entry
+	qLog sqlx.QueryerContext
+	eLog sqlx.ExecerContext
+
+	inTx bool
+}
+
+// DBConnect opens sqlite database file (created if not exists) and applies migrations using github.com/golang-migrate/migrate.
+// Migrations are specific to sqlite and stored separately from postgresql ones.
+// Database is used through single connection: sqlite allows only one writer at time,
+// so statements and transactions of concurrent requests are serialized instead of failing with "database is locked".
+// Driver requires cgo, DBConnect returns error if binary was built with CGO_ENABLED=0.
+func DBConnect(file string, migrationsPath string) (db.DB, error) {
+	log := logrus.WithField("component", "db")
+	log.Infoln("Opening sqlite database ", file)
+	conn, err := sqlx.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1", file))
+	if err != nil {
+		log.WithError(err).Errorln("Sqlite database open failed")
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	if pingErr := conn.Ping(); pingErr != nil {
+		conn.Close()
+		return nil, pingErr
+	}
+
+	ret := &sqliteDB{
+		conn: conn,
+		log:  log,
+		qLog: instrumentedQueryer{q: conn, log: log},
+		eLog: instrumentedExecer{e: conn, log: log},
+	}
+
+	m, err := ret.migrateUp(migrationsPath)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	version, _, _ := m.Version()
+	log.WithField("version", version).Infoln("Migrate up")
+	if srcErr, dbErr := m.Close(); srcErr != nil || dbErr != nil {
+		log.Warnf("Unable to close migrations: source: %v, database: %v", srcErr, dbErr)
+	}
+
+	return ret, nil
+}
+
+func (sdb *sqliteDB) migrateUp(path string) (*migrate.Migrate, error) {
+	sdb.log.Infof("Running migrations")
+	// driver creates only default migrations table regardless of config
+	instance, err := migdrv.WithInstance(sdb.conn.DB, &migdrv.Config{})
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+path, "solutions", sharedDriver{instance})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return nil, err
+	}
+	return m, nil
+}
+
+// sharedDriver is a migrations driver using connection of sqliteDB.
+// Driver closes database on Close, so Close is no-op and connection is closed by sqliteDB only.
+type sharedDriver struct {
+	database.Driver
+}
+
+func (sharedDriver) Close() error {
+	return nil
+}
+
+func (sdb *sqliteDB) Transactional(ctx context.Context, f func(ctx context.Context, tx db.DB) error) (err error) {
+	start := time.Now().Format(time.ANSIC)
+	e := sdb.logger(ctx).WithField("transaction_at", start)
+	ctx, span := tracing.StartSpan(ctx, "db transaction", tracing.SpanKindInternal)
+	defer func() { span.Finish(err) }()
+	e.Debugln("Begin transaction")
+	tx, txErr := sdb.conn.BeginTxx(ctx, nil)
+	if txErr != nil {
+		e.WithError(txErr).Errorln("Begin transaction error")
+		return db.ErrTransactionBegin
+	}
+
+	arg := &sqliteDB{
+		conn: sdb.conn,
+		log:  e,
+		eLog: instrumentedExecer{e: tx, log: e},
+		qLog: instrumentedQueryer{q: tx, log: e},
+		inTx: true,
+	}
+
+	// needed for recovering panics in transactions.
+	defer func(dberr error) {
+		// if panic recovered, try to rollback transaction
+		if panicErr := recover(); panicErr != nil {
+			dberr = fmt.Errorf("panic in transaction: %v", panicErr)
+		}
+
+		if dberr != nil {
+			e.WithError(dberr).Debugln("Rollback transaction")
+			metrics.DBTransactionRollbacks.Inc()
+			span.SetAttribute("db.rollback", "true")
+			if rerr := tx.Rollback(); rerr != nil {
+				e.WithError(rerr).Errorln("Rollback error")
+				err = db.ErrTransactionRollback
+			}
+			err = dberr // forward error with panic description
+			return
+		}
+
+		e.Debugln("Commit transaction")
+		if cerr := tx.Commit(); cerr != nil {
+			e.WithError(cerr).Errorln("Commit error")
+			err = db.ErrTransactionCommit
+		}
+	}(f(ctx, arg))
+	return err
+}
+
+// atomic runs f in transaction unless database is already used in transaction.
+// It replaces statements which are atomic in postgresql ("UPDATE ... RETURNING") but not supported by sqlite.
+func (sdb *sqliteDB) atomic(ctx context.Context, f func(tx *sqliteDB) error) error {
+	if sdb.inTx {
+		return f(sdb)
+	}
+	return sdb.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return f(tx.(*sqliteDB))
+	})
+}
+
+// logger returns db log entry with request-scoped fields
+func (sdb *sqliteDB) logger(ctx context.Context) *logrus.Entry {
+	return utils.LogEntry(ctx, sdb.log)
+}
+
+func (sdb *sqliteDB) Ping(ctx context.Context) error {
+	return sdb.conn.PingContext(ctx)
+}
+
+func (sdb *sqliteDB) Close() error {
+	return sdb.conn.Close()
+}
+
+// timestampFormat is a format of stored times. Times are stored in UTC as text
+// with fixed-width fraction, so they may be compared and sorted as strings.
+const timestampFormat = "2006-01-02 15:04:05.000000000"
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+// nullTimestamp returns timestamp or nil (NULL) if t is nil
+func nullTimestamp(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
@@ -0,0 +1,22 @@
+//go:build cgo
+// +build cgo
+
+package sqlite
+
+import (
+	"git.containerum.net/ch/solutions/pkg/db"
+	"github.com/mattn/go-sqlite3"
+)
+
+// mapError replaces constraint violation errors of sqlite driver with errors of db package
+func mapError(err error) error {
+	if sqlerr, ok := err.(sqlite3.Error); ok {
+		switch sqlerr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return db.ErrUniqueViolation
+		case sqlite3.ErrConstraintForeignKey:
+			return db.ErrForeignKeyViolation
+		}
+	}
+	return err
+}
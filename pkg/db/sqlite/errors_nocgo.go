@@ -0,0 +1,9 @@
+//go:build !cgo
+// +build !cgo
+
+package sqlite
+
+// mapError returns err as is: sqlite driver is a stub without cgo and database can't be opened
+func mapError(err error) error {
+	return err
+}
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"github.com/jmoiron/sqlx"
+)
+
+func (sdb *sqliteDB) AddEvent(ctx context.Context, event model.Event) error {
+	sdb.logger(ctx).Debugln("Saving event")
+
+	_, err := sdb.eLog.ExecContext(ctx, "INSERT INTO events (id, created_at, action, outcome, error, user_id, user_role, namespace, solution, template, request_id) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		event.ID, timestamp(time.Now()), event.Action, event.Outcome, event.Error, event.UserID, event.UserRole, event.Namespace, event.Solution, event.Template, event.RequestID)
+	return err
+}
+
+func (sdb *sqliteDB) GetEvents(ctx context.Context, filter model.EventsFilter) (*model.EventsList, error) {
+	sdb.logger(ctx).Infoln("Get events")
+
+	var conds []string
+	var args []interface{}
+	addCond := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, cond)
+	}
+	for _, field := range []struct{ column, value string }{
+		{"user_id", filter.UserID},
+		{"namespace", filter.Namespace},
+		{"solution", filter.Solution},
+		{"template", filter.Template},
+		{"action", filter.Action},
+		{"outcome", filter.Outcome},
+	} {
+		if field.value != "" {
+			addCond(field.column+" = ?", field.value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		addCond("created_at >= ?", timestamp(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		addCond("created_at < ?", timestamp(filter.Until))
+	}
+
+	query := "SELECT " + rowmap.EventColumns + " FROM events"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += " LIMIT ?"
+	}
+
+	var rows []rowmap.Event
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.Events(rows), nil
+}
@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+)
+
+func (sdb *sqliteDB) AcquireSolutionLease(ctx context.Context, namespace, solutionName, holder string, now, expiresAt time.Time) error {
+	sdb.logger(ctx).Debugln("Acquiring solution lease")
+
+	res, err := sdb.eLog.ExecContext(ctx, "INSERT INTO solution_leases (namespace, name, holder, expires_at) VALUES (?, ?, ?, ?) "+
+		"ON CONFLICT (namespace, name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at "+
+		"WHERE solution_leases.holder = excluded.holder OR solution_leases.expires_at <= ?",
+		namespace, solutionName, holder, timestamp(expiresAt), timestamp(now))
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return db.ErrLeaseConflict
+	}
+	return nil
+}
+
+func (sdb *sqliteDB) ReleaseSolutionLease(ctx context.Context, namespace, solutionName, holder string) error {
+	sdb.logger(ctx).Debugln("Releasing solution lease")
+
+	_, err := sdb.eLog.ExecContext(ctx, "DELETE FROM solution_leases WHERE namespace = ? AND name = ? AND holder = ?", namespace, solutionName, holder)
+	return err
+}
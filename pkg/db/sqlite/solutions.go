@@ -0,0 +1,343 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/jmoiron/sqlx"
+)
+
+func (sdb *sqliteDB) AddSolution(ctx context.Context, solution kube_types.Solution, userID, templateID, uuid, env string, expiresAt *time.Time) error {
+	sdb.logger(ctx).Infoln("Saving solution")
+
+	if _, err := sdb.eLog.ExecContext(ctx, "INSERT INTO solutions (id, template_id, name, namespace, user_id, created_at, expires_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?)", uuid, templateID, solution.Name, solution.Namespace, userID, timestamp(time.Now()), nullTimestamp(expiresAt)); err != nil {
+		return err
+	}
+
+	if _, err := sdb.eLog.ExecContext(ctx, "INSERT INTO parameters (solution_id, branch, env) "+
+		"VALUES (?, ?, ?)", uuid, solution.Branch, env); err != nil {
+		return err
+	}
+	return nil
+}
+
+// solutionsQuery selects not deleted solutions, conditions may be added with "AND"
+const solutionsQuery = "SELECT " + rowmap.SolutionColumns + " FROM " + rowmap.SolutionTables + " WHERE NOT solutions.is_deleted"
+
+func (sdb *sqliteDB) selectSolutions(ctx context.Context, cond string, args ...interface{}) (*model.SolutionsList, error) {
+	var rows []rowmap.Solution
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, solutionsQuery+cond, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.Solutions(rows)
+}
+
+func (sdb *sqliteDB) GetAllSolutionsList(ctx context.Context) (*model.SolutionsList, error) {
+	sdb.logger(ctx).Infoln("Get all solutions list")
+
+	return sdb.selectSolutions(ctx, "")
+}
+
+func (sdb *sqliteDB) GetSolutionsList(ctx context.Context, userID string) (*model.SolutionsList, error) {
+	sdb.logger(ctx).Infoln("Get solutions list")
+
+	return sdb.selectSolutions(ctx, " AND solutions.user_id = ?", userID)
+}
+
+func (sdb *sqliteDB) GetNamespaceSolutionsList(ctx context.Context, namespace string) (*model.SolutionsList, error) {
+	sdb.logger(ctx).Infoln("Get solutions list")
+
+	return sdb.selectSolutions(ctx, " AND solutions.namespace = ?", namespace)
+}
+
+func (sdb *sqliteDB) GetSolution(ctx context.Context, namespace, solutionName string) (*model.Solution, error) {
+	sdb.logger(ctx).Infoln("Get solution")
+
+	var row rowmap.Solution
+	if err := sqlx.GetContext(ctx, sdb.qLog, &row, solutionsQuery+" AND solutions.name = ? AND solutions.namespace = ?", solutionName, namespace); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrSolutionNotExist()
+		}
+		return nil, err
+	}
+
+	solution, err := row.Model()
+	if err != nil {
+		return nil, err
+	}
+	return &solution, nil
+}
+
+func (sdb *sqliteDB) DeleteSolution(ctx context.Context, namespace, solutionName string) error {
+	sdb.logger(ctx).Infoln("Deleting solution")
+
+	res, err := sdb.eLog.ExecContext(ctx, "UPDATE solutions SET is_deleted = 1, deleted_at = ? WHERE name = ? AND namespace = ? AND NOT is_deleted", timestamp(time.Now()), solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) CompletelyDeleteSolution(ctx context.Context, namespace, solutionName string) error {
+	sdb.logger(ctx).Infoln("Deleting solution")
+
+	res, err := sdb.eLog.ExecContext(ctx, "DELETE FROM solutions WHERE name = ? AND namespace = ?", solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+// setSolutionStatus changes status of solution from "from" to "to" and returns solution ID
+func (sdb *sqliteDB) setSolutionStatus(ctx context.Context, namespace, solutionName, from, to string) (string, error) {
+	var solutionID string
+	if err := sqlx.GetContext(ctx, sdb.qLog, &solutionID, "SELECT id FROM solutions WHERE name = ? AND namespace = ? AND status = ? AND NOT is_deleted",
+		solutionName, namespace, from); err != nil {
+		if err == sql.ErrNoRows {
+			return "", solerrors.ErrInvalidSolutionState()
+		}
+		return "", err
+	}
+
+	if _, err := sdb.eLog.ExecContext(ctx, "UPDATE solutions SET status = ?, version = version + 1 WHERE id = ?", to, solutionID); err != nil {
+		return "", err
+	}
+	return solutionID, nil
+}
+
+func (sdb *sqliteDB) StopSolution(ctx context.Context, namespace, solutionName string, replicas map[string]int) error {
+	sdb.logger(ctx).Infoln("Stopping solution")
+
+	return sdb.atomic(ctx, func(tx *sqliteDB) error {
+		solutionID, err := tx.setSolutionStatus(ctx, namespace, solutionName, model.SolutionRunning, model.SolutionStopped)
+		if err != nil {
+			return err
+		}
+
+		for deploy, count := range replicas {
+			if _, err := tx.eLog.ExecContext(ctx, "INSERT INTO replicas (solution_id, deployment, replicas) VALUES (?, ?, ?)", solutionID, deploy, count); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (sdb *sqliteDB) StartSolution(ctx context.Context, namespace, solutionName string) (ret map[string]int, err error) {
+	sdb.logger(ctx).Infoln("Starting solution")
+
+	err = sdb.atomic(ctx, func(tx *sqliteDB) error {
+		solutionID, err := tx.setSolutionStatus(ctx, namespace, solutionName, model.SolutionStopped, model.SolutionRunning)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.qLog.QueryxContext(ctx, "SELECT deployment, replicas FROM replicas WHERE solution_id = ?", solutionID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		ret = make(map[string]int)
+		for rows.Next() {
+			var deploy string
+			var count int
+			if err := rows.Scan(&deploy, &count); err != nil {
+				return err
+			}
+			ret[deploy] = count
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = tx.eLog.ExecContext(ctx, "DELETE FROM replicas WHERE solution_id = ?", solutionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (sdb *sqliteDB) SetSolutionSchedule(ctx context.Context, namespace, solutionName string, schedule model.SolutionSchedule) error {
+	sdb.logger(ctx).Infoln("Setting solution schedule")
+
+	res, err := sdb.eLog.ExecContext(ctx, "UPDATE solutions SET start_schedule = ?, stop_schedule = ?, version = version + 1 WHERE name = ? AND namespace = ? AND NOT is_deleted",
+		schedule.Start, schedule.Stop, solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) SetSolutionExpiry(ctx context.Context, namespace, solutionName string, expiresAt time.Time) error {
+	sdb.logger(ctx).Infoln("Setting solution expiry")
+
+	res, err := sdb.eLog.ExecContext(ctx, "UPDATE solutions SET expires_at = ?, expiry_warned = 0, version = version + 1 WHERE name = ? AND namespace = ? AND NOT is_deleted",
+		timestamp(expiresAt), solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) selectSolutionsExpiry(ctx context.Context, cond string, args ...interface{}) ([]rowmap.SolutionExpiry, error) {
+	var rows []rowmap.SolutionExpiry
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, "SELECT "+rowmap.SolutionExpiryColumns+" FROM solutions WHERE "+cond, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (sdb *sqliteDB) GetExpiredSolutions(ctx context.Context, now time.Time) (*model.SolutionsList, error) {
+	sdb.logger(ctx).Infoln("Get expired solutions")
+
+	rows, err := sdb.selectSolutionsExpiry(ctx, "expires_at <= ? AND NOT is_deleted", timestamp(now))
+	if err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionsExpiry(rows), nil
+}
+
+func (sdb *sqliteDB) MarkSolutionsExpiryWarned(ctx context.Context, now, before time.Time) (*model.SolutionsList, error) {
+	sdb.logger(ctx).Infoln("Marking expiring solutions warned")
+
+	const cond = "expires_at > ? AND expires_at <= ? AND NOT expiry_warned AND NOT is_deleted"
+	var rows []rowmap.SolutionExpiry
+	err := sdb.atomic(ctx, func(tx *sqliteDB) error {
+		var err error
+		if rows, err = tx.selectSolutionsExpiry(ctx, cond, timestamp(now), timestamp(before)); err != nil || len(rows) == 0 {
+			return err
+		}
+		// transaction is the only writer, so same solutions are updated
+		_, err = tx.eLog.ExecContext(ctx, "UPDATE solutions SET expiry_warned = 1 WHERE "+cond, timestamp(now), timestamp(before))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionsExpiry(rows), nil
+}
+
+func (sdb *sqliteDB) SetSolutionTrackBranch(ctx context.Context, namespace, solutionName string, track bool) error {
+	sdb.logger(ctx).Infoln("Setting solution branch tracking")
+
+	res, err := sdb.eLog.ExecContext(ctx, "UPDATE solutions SET track_branch = ?, version = version + 1 WHERE name = ? AND namespace = ? AND NOT is_deleted",
+		track, solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) GetTrackingSolutions(ctx context.Context, templateName, branch string) (*model.SolutionsList, error) {
+	sdb.logger(ctx).Infoln("Get solutions tracking template branch")
+
+	var rows []rowmap.SolutionName
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, "SELECT "+rowmap.SolutionNameColumns+" FROM "+rowmap.SolutionTables+
+		" WHERE templates.name = ? AND parameters.branch = ? AND solutions.track_branch AND NOT solutions.is_deleted", templateName, branch); err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionNames(rows), nil
+}
+
+func (sdb *sqliteDB) CountActiveSolutions(ctx context.Context) (map[string]int, error) {
+	sdb.logger(ctx).Debugln("Count active solutions")
+
+	rows, err := sdb.qLog.QueryxContext(ctx, "SELECT templates.name, count(*) "+
+		"FROM solutions JOIN templates ON solutions.template_id = templates.id "+
+		"WHERE NOT solutions.is_deleted GROUP BY templates.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make(map[string]int)
+	for rows.Next() {
+		var template string
+		var count int
+		if err := rows.Scan(&template, &count); err != nil {
+			return nil, err
+		}
+		ret[template] = count
+	}
+	return ret, rows.Err()
+}
+
+func (sdb *sqliteDB) SaveSolutionResource(ctx context.Context, namespace, solutionName string, resource model.SolutionResource) error {
+	sdb.logger(ctx).Debugln("Saving solution resource")
+
+	// "WHERE" clause is required to parse "ON CONFLICT" after "SELECT"
+	res, err := sdb.eLog.ExecContext(ctx, "INSERT INTO solution_resources (solution_id, kind, name, status, manifest_hash, created_at) "+
+		"SELECT id, ?, ?, ?, ?, ? FROM solutions WHERE name = ? AND namespace = ? AND NOT is_deleted "+
+		"ON CONFLICT (solution_id, kind, name) DO UPDATE SET status = excluded.status, manifest_hash = excluded.manifest_hash",
+		resource.Kind, resource.Name, resource.Status, resource.ManifestHash, timestamp(time.Now()), solutionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) GetSolutionResources(ctx context.Context, namespace, solutionName string) (*model.SolutionResourcesList, error) {
+	sdb.logger(ctx).Infoln("Get solution resources")
+
+	var solutionID string
+	if err := sqlx.GetContext(ctx, sdb.qLog, &solutionID, "SELECT id FROM solutions WHERE name = ? AND namespace = ? AND NOT is_deleted", solutionName, namespace); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrSolutionNotExist()
+		}
+		return nil, err
+	}
+
+	var rows []rowmap.SolutionResource
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, "SELECT "+rowmap.SolutionResourceColumns+" FROM solution_resources WHERE solution_id = ? ORDER BY created_at, kind, name", solutionID); err != nil {
+		return nil, err
+	}
+	return rowmap.SolutionResources(rows), nil
+}
+
+// LockSolution does nothing except transaction check: transactions use single connection and do not run concurrently
+func (sdb *sqliteDB) LockSolution(ctx context.Context, namespace, solutionName string) error {
+	sdb.logger(ctx).Debugln("Lock solution")
+	if !sdb.inTx {
+		return db.ErrNotInTransaction
+	}
+	return nil
+}
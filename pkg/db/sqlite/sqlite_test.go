@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/dbtest"
+)
+
+const testMigrations = "../../migrations/sqlite"
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solutions-sqlite")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var count int
+	var opened []db.DB
+	defer func() {
+		for _, database := range opened {
+			database.Close()
+		}
+	}()
+	dbtest.RunConformance(t, func(t *testing.T) db.DB {
+		count++
+		database, err := DBConnect(filepath.Join(dir, fmt.Sprintf("test%d.db", count)), testMigrations)
+		if err != nil {
+			t.Fatalf("Unable to open database: %v", err)
+		}
+		opened = append(opened, database)
+		return database
+	})
+}
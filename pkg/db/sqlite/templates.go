@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/jmoiron/sqlx"
+)
+
+func (sdb *sqliteDB) CreateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
+	sdb.logger(ctx).Infoln("Saving solution template")
+
+	images, _ := json.Marshal(solution.Images)
+
+	if _, err := sdb.eLog.ExecContext(ctx,
+		`INSERT INTO templates (name, cpu, ram, images, url, active) VALUES (?, ?, ?, ?, ?, 1)`, solution.Name, solution.Limits.CPU, solution.Limits.RAM, string(images), solution.URL); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sdb *sqliteDB) UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
+	sdb.logger(ctx).Infoln("Updating solution template")
+
+	images, _ := json.Marshal(solution.Images)
+
+	res, err := sdb.eLog.ExecContext(ctx,
+		`UPDATE templates SET cpu = ?, ram = ?, images = ?, url = ?, version = version + 1 WHERE name = ?`, solution.Limits.CPU, solution.Limits.RAM, string(images), solution.URL, solution.Name)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrTemplateNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) ActivateTemplate(ctx context.Context, solution string) error {
+	sdb.logger(ctx).Infoln("Activating solution template")
+
+	res, err := sdb.eLog.ExecContext(ctx,
+		`UPDATE templates SET active = 1, version = version + 1 WHERE name = ? AND NOT active`, solution)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrTemplateNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) DeactivateTemplate(ctx context.Context, solution string) error {
+	sdb.logger(ctx).Infoln("Deactivating solution template")
+
+	res, err := sdb.eLog.ExecContext(ctx,
+		`UPDATE templates SET active = 0, version = version + 1 WHERE name = ? AND active`, solution)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) DeleteTemplate(ctx context.Context, solution string) error {
+	sdb.logger(ctx).Infoln("deleting solution template")
+
+	res, err := sdb.eLog.ExecContext(ctx,
+		`DELETE FROM templates WHERE name = ?`, solution)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrSolutionNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsTemplatesList, error) {
+	sdb.logger(ctx).Infoln("Get solutions templates list")
+
+	query := "SELECT " + rowmap.TemplateColumns + " FROM templates"
+
+	if !isAdmin {
+		query = query + " WHERE active"
+	}
+
+	var rows []rowmap.Template
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, query); err != nil {
+		return nil, err
+	}
+	return rowmap.Templates(rows)
+}
+
+func (sdb *sqliteDB) GetTemplate(ctx context.Context, name string) (*model.Template, error) {
+	sdb.logger(ctx).Infoln("Get solution template ", name)
+
+	var row rowmap.Template
+	if err := sqlx.GetContext(ctx, sdb.qLog, &row, "SELECT "+rowmap.TemplateColumns+" FROM templates WHERE name = ? AND active", name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, solerrors.ErrTemplateNotExist()
+		}
+		return nil, err
+	}
+
+	template, err := row.Model()
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// LockTemplate does nothing except transaction check: transactions use single connection and do not run concurrently
+func (sdb *sqliteDB) LockTemplate(ctx context.Context, name string) error {
+	sdb.logger(ctx).Debugln("Lock template")
+	if !sdb.inTx {
+		return db.ErrNotInTransaction
+	}
+	return nil
+}
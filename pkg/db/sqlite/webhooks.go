@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db/rowmap"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/jmoiron/sqlx"
+)
+
+func (sdb *sqliteDB) CreateWebhook(ctx context.Context, webhook model.Webhook) error {
+	sdb.logger(ctx).Infoln("Saving webhook")
+
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = sdb.eLog.ExecContext(ctx, "INSERT INTO webhooks (id, namespace, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		webhook.ID, webhook.Namespace, webhook.URL, webhook.Secret, string(events), timestamp(time.Now()))
+	return err
+}
+
+func (sdb *sqliteDB) selectWebhooks(ctx context.Context, query string, args ...interface{}) (*model.WebhooksList, error) {
+	var rows []rowmap.Webhook
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.Webhooks(rows)
+}
+
+func (sdb *sqliteDB) GetWebhooksList(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	sdb.logger(ctx).Infoln("Get webhooks list")
+
+	return sdb.selectWebhooks(ctx, "SELECT "+rowmap.WebhookColumns+" FROM webhooks WHERE namespace = ? ORDER BY created_at", namespace)
+}
+
+func (sdb *sqliteDB) GetEventWebhooks(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	sdb.logger(ctx).Debugln("Get event webhooks")
+
+	return sdb.selectWebhooks(ctx, "SELECT "+rowmap.WebhookColumns+" FROM webhooks WHERE namespace = ? OR namespace = ''", namespace)
+}
+
+func (sdb *sqliteDB) GetWebhook(ctx context.Context, namespace, webhookID string) (*model.Webhook, error) {
+	sdb.logger(ctx).Infoln("Get webhook")
+
+	webhooks, err := sdb.selectWebhooks(ctx, "SELECT "+rowmap.WebhookColumns+" FROM webhooks WHERE id = ? AND namespace = ?", webhookID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks.Webhooks) == 0 {
+		return nil, solerrors.ErrWebhookNotExist()
+	}
+	return &webhooks.Webhooks[0], nil
+}
+
+func (sdb *sqliteDB) DeleteWebhook(ctx context.Context, namespace, webhookID string) error {
+	sdb.logger(ctx).Infoln("Deleting webhook")
+
+	res, err := sdb.eLog.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ? AND namespace = ?", webhookID, namespace)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrWebhookNotExist()
+	}
+	return err
+}
+
+func (sdb *sqliteDB) AddWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	sdb.logger(ctx).Debugln("Saving webhook delivery")
+
+	now := timestamp(time.Now())
+	_, err := sdb.eLog.ExecContext(ctx, "INSERT INTO webhook_deliveries (id, webhook_id, event_id, action, url, payload, signature, status, created_at, next_attempt_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		delivery.ID, delivery.WebhookID, delivery.EventID, delivery.Action, delivery.URL, string(delivery.Payload), delivery.Signature, model.DeliveryPending, now, now)
+	return err
+}
+
+func (sdb *sqliteDB) selectWebhookDeliveries(ctx context.Context, query string, args ...interface{}) (*model.WebhookDeliveriesList, error) {
+	var rows []rowmap.WebhookDelivery
+	if err := sqlx.SelectContext(ctx, sdb.qLog, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rowmap.WebhookDeliveries(rows), nil
+}
+
+func (sdb *sqliteDB) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) (*model.WebhookDeliveriesList, error) {
+	sdb.logger(ctx).Infoln("Get webhook deliveries")
+
+	return sdb.selectWebhookDeliveries(ctx, "SELECT "+rowmap.WebhookDeliveryColumns+" FROM webhook_deliveries WHERE webhook_id = ? ORDER BY created_at DESC LIMIT ?", webhookID, limit)
+}
+
+func (sdb *sqliteDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (ret *model.WebhookDeliveriesList, err error) {
+	sdb.logger(ctx).Debugln("Claiming webhook deliveries")
+
+	err = sdb.atomic(ctx, func(tx *sqliteDB) error {
+		var err error
+		ret, err = tx.selectWebhookDeliveries(ctx, "SELECT "+rowmap.WebhookDeliveryColumns+" FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?",
+			model.DeliveryPending, timestamp(now), limit)
+		if err != nil || len(ret.Deliveries) == 0 {
+			return err
+		}
+
+		nextAttemptAt := now.Add(lease)
+		ids := make([]string, 0, len(ret.Deliveries))
+		for i := range ret.Deliveries {
+			ret.Deliveries[i].NextAttemptAt = nextAttemptAt.UTC().Format(time.RFC3339)
+			ids = append(ids, ret.Deliveries[i].ID)
+		}
+		query, args, err := sqlx.In("UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id IN (?)", timestamp(nextAttemptAt), ids)
+		if err != nil {
+			return err
+		}
+		_, err = tx.eLog.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (sdb *sqliteDB) SetWebhookDeliveryResult(ctx context.Context, deliveryID, status string, attempts int, lastError string, nextAttemptAt time.Time) error {
+	sdb.logger(ctx).Debugln("Saving webhook delivery result")
+
+	var deliveredAt *time.Time
+	if status == model.DeliveryDelivered {
+		now := time.Now()
+		deliveredAt = &now
+	}
+	_, err := sdb.eLog.ExecContext(ctx, "UPDATE webhook_deliveries SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, delivered_at = ? WHERE id = ?",
+		status, attempts, lastError, timestamp(nextAttemptAt), nullTimestamp(deliveredAt), deliveryID)
+	return err
+}
+
+func (sdb *sqliteDB) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) error {
+	sdb.logger(ctx).Infoln("Scheduling webhook redelivery")
+
+	res, err := sdb.eLog.ExecContext(ctx, "UPDATE webhook_deliveries SET status = ?, attempts = 0, last_error = '', next_attempt_at = ?, delivered_at = NULL WHERE id = ? AND webhook_id = ?",
+		model.DeliveryPending, timestamp(time.Now()), deliveryID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return solerrors.ErrWebhookDeliveryNotExist()
+	}
+	return err
+}
@@ -2,11 +2,13 @@ package db
 
 import (
 	"io"
+	"time"
 
 	"context"
 
 	"errors"
 
+	"git.containerum.net/ch/solutions/pkg/model"
 	kube_types "github.com/containerum/kube-client/pkg/model"
 )
 
@@ -15,26 +17,93 @@ var (
 	ErrTransactionBegin    = errors.New("transaction begin error")
 	ErrTransactionRollback = errors.New("transaction rollback error")
 	ErrTransactionCommit   = errors.New("transaction commit error")
+	ErrNotInTransaction    = errors.New("operation requires transaction")
 )
 
+// Errors returned by all implementations instead of driver-specific constraint violation errors
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violation")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+)
+
+// ErrLeaseConflict is returned if lease is held by other holder and is not expired yet
+var ErrLeaseConflict = errors.New("lease is held by other holder")
+
 // DB is an interface for persistent data storage (also sometimes called DAO).
 type DB interface {
 	CreateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error
 	UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error
 	DeleteTemplate(ctx context.Context, solution string) error
 	GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsTemplatesList, error)
-	GetTemplate(ctx context.Context, name string) (*kube_types.SolutionTemplate, error)
+	GetTemplate(ctx context.Context, name string) (*model.Template, error)
 	ActivateTemplate(ctx context.Context, solution string) error
 	DeactivateTemplate(ctx context.Context, solution string) error
+	// LockTemplate takes lock of template held until the end of transaction.
+	// Returns ErrNotInTransaction if called outside of transaction.
+	LockTemplate(ctx context.Context, name string) error
 
-	GetSolutionsList(ctx context.Context, userID string) (*kube_types.SolutionsList, error)
-	GetNamespaceSolutionsList(ctx context.Context, namespace string) (*kube_types.SolutionsList, error)
-	GetSolution(ctx context.Context, namespace, solutionName string) (*kube_types.Solution, error)
-	AddSolution(ctx context.Context, solution kube_types.Solution, userID, templateID, uuid, env string) error
+	GetAllSolutionsList(ctx context.Context) (*model.SolutionsList, error)
+	GetSolutionsList(ctx context.Context, userID string) (*model.SolutionsList, error)
+	GetNamespaceSolutionsList(ctx context.Context, namespace string) (*model.SolutionsList, error)
+	GetSolution(ctx context.Context, namespace, solutionName string) (*model.Solution, error)
+	AddSolution(ctx context.Context, solution kube_types.Solution, userID, templateID, uuid, env string, expiresAt *time.Time) error
 	DeleteSolution(ctx context.Context, namespace, solutionName string) error
 	CompletelyDeleteSolution(ctx context.Context, namespace, solutionName string) error
-	CompletelyDeleteSolutions(ctx context.Context, userID string) error
-	CompletelyDeleteNamespaceSolutions(ctx context.Context, namespace string) error
+	// StopSolution marks solution stopped and records its deployments replicas
+	StopSolution(ctx context.Context, namespace, solutionName string, replicas map[string]int) error
+	// StartSolution marks solution running and returns recorded deployments replicas
+	StartSolution(ctx context.Context, namespace, solutionName string) (map[string]int, error)
+	SetSolutionSchedule(ctx context.Context, namespace, solutionName string, schedule model.SolutionSchedule) error
+	// SetSolutionExpiry sets solution expiration time and resets expiry warning
+	SetSolutionExpiry(ctx context.Context, namespace, solutionName string, expiresAt time.Time) error
+	// GetExpiredSolutions returns names and namespaces of solutions expired at "now"
+	GetExpiredSolutions(ctx context.Context, now time.Time) (*model.SolutionsList, error)
+	// MarkSolutionsExpiryWarned marks solutions expiring after "now" and before "before" as warned.
+	// Already expired solutions are not warned, they are deleted instead.
+	// Returns names and namespaces of solutions which were not warned yet.
+	MarkSolutionsExpiryWarned(ctx context.Context, now, before time.Time) (*model.SolutionsList, error)
+	SetSolutionTrackBranch(ctx context.Context, namespace, solutionName string, track bool) error
+	// GetTrackingSolutions returns names and namespaces of solutions tracking template branch
+	GetTrackingSolutions(ctx context.Context, templateName, branch string) (*model.SolutionsList, error)
+	// CountActiveSolutions returns count of not deleted solutions by template name
+	CountActiveSolutions(ctx context.Context) (map[string]int, error)
+	// SaveSolutionResource records resource created for solution or updates status and manifest hash of recorded resource
+	SaveSolutionResource(ctx context.Context, namespace, solutionName string, resource model.SolutionResource) error
+	// GetSolutionResources returns resources recorded for solution in creation order
+	GetSolutionResources(ctx context.Context, namespace, solutionName string) (*model.SolutionResourcesList, error)
+	// LockSolution takes lock of solution (existing or not) held until the end of transaction.
+	// Returns ErrNotInTransaction if called outside of transaction.
+	LockSolution(ctx context.Context, namespace, solutionName string) error
+	// AcquireSolutionLease records solution lease of holder until expiresAt. It also prolongs lease already held by holder.
+	// Lease of other holder is taken over only if it expired at "now", ErrLeaseConflict is returned otherwise.
+	// Solution may not exist, so leases may be taken before solution creation.
+	AcquireSolutionLease(ctx context.Context, namespace, solutionName, holder string, now, expiresAt time.Time) error
+	// ReleaseSolutionLease deletes solution lease if it's held by holder
+	ReleaseSolutionLease(ctx context.Context, namespace, solutionName, holder string) error
+
+	AddEvent(ctx context.Context, event model.Event) error
+	// GetEvents returns events matching filter, newest first
+	GetEvents(ctx context.Context, filter model.EventsFilter) (*model.EventsList, error)
+
+	CreateWebhook(ctx context.Context, webhook model.Webhook) error
+	// GetWebhooksList returns webhooks of namespace, global webhooks if namespace is empty
+	GetWebhooksList(ctx context.Context, namespace string) (*model.WebhooksList, error)
+	// GetEventWebhooks returns webhooks of namespace and global webhooks
+	GetEventWebhooks(ctx context.Context, namespace string) (*model.WebhooksList, error)
+	GetWebhook(ctx context.Context, namespace, webhookID string) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, namespace, webhookID string) error
+	AddWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+	// GetWebhookDeliveries returns last webhook deliveries, newest first
+	GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) (*model.WebhookDeliveriesList, error)
+	// ClaimWebhookDeliveries returns pending deliveries which should be attempted at "now"
+	// and postpones their next attempt by lease, so concurrent workers don't send them twice
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (*model.WebhookDeliveriesList, error)
+	SetWebhookDeliveryResult(ctx context.Context, deliveryID, status string, attempts int, lastError string, nextAttemptAt time.Time) error
+	// RedeliverWebhook schedules delivery to be sent again
+	RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) error
+
+	// Ping checks database connection
+	Ping(ctx context.Context) error
 
 	// Perform operations inside transaction
 	// Transaction commits if `f` returns nil error, rollbacks and forwards error otherwise
@@ -0,0 +1,165 @@
+// Package lock implements per-solution locks shared by all service replicas.
+//
+// Lock is a lease recorded in database. Lease is renewed while operation is running
+// and expires if holder crashed, so other replicas may take it over after TTL.
+// If lease is lost (taken over or not renewed in time) context of operation is cancelled.
+// Lease times are set by holder clocks, so TTL should be much larger than clocks skew of replicas.
+//
+// Leader lease is recorded the same way, so periodic jobs run by one replica at a time.
+package lock
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTTL is a default time while lease of crashed holder blocks solution
+const DefaultTTL = 30 * time.Second
+
+// Locker takes solution locks
+type Locker struct {
+	db  db.DB
+	ttl time.Duration
+	log *logrus.Entry
+}
+
+// NewLocker returns locker keeping leases in database. Leases are renewed every ttl/3 while lock is held.
+func NewLocker(database db.DB, ttl time.Duration) *Locker {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Locker{
+		db:  database,
+		ttl: ttl,
+		log: logrus.WithField("component", "lock"),
+	}
+}
+
+// Leader lease key. Solutions always have namespace, so it doesn't conflict with solution lock.
+const (
+	leaderNamespace = ""
+	leaderName      = "leader"
+)
+
+type heldKey struct {
+	namespace, name string
+}
+
+// LockSolution takes lock of solution (existing or not) until release is called.
+// Returns retryable solerrors.ErrSolutionLocked if solution is locked by other operation.
+// Context is returned unchanged on error.
+//
+// Returned context is cancelled if lease renewal fails with conflict or doesn't succeed before lease expires,
+// because other replica may take lock over, so operation must be done with returned context.
+//
+// Lock is reentrant through returned context: functions called with it may lock the same solution again,
+// i.e. background job may call other service methods locking solution.
+func (l *Locker) LockSolution(ctx context.Context, namespace, solutionName string) (_ context.Context, release func(), err error) {
+	key := heldKey{namespace: namespace, name: solutionName}
+	if ctx.Value(key) != nil {
+		return ctx, func() {}, nil
+	}
+
+	holder := uuid.New().String()
+	expiresAt, err := l.acquire(ctx, namespace, solutionName, holder)
+	if err != nil {
+		if err == db.ErrLeaseConflict {
+			return ctx, nil, solerrors.ErrSolutionLocked().AddDetailF("solution %s is locked by other operation", solutionName)
+		}
+		l.logger(ctx).WithError(err).Errorf("Unable to lock solution %s", solutionName)
+		return ctx, nil, err
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		l.renew(ctx, cancel, namespace, solutionName, holder, expiresAt, done)
+	}()
+
+	release = func() {
+		// renewal in progress would record lease again after release
+		close(done)
+		<-stopped
+		cancel()
+		// lease is released even if request is cancelled
+		if err := l.db.Transactional(context.Background(), func(ctx context.Context, tx db.DB) error {
+			return tx.ReleaseSolutionLease(ctx, namespace, solutionName, holder)
+		}); err != nil {
+			l.logger(ctx).WithError(err).Errorf("Unable to unlock solution %s, lock expires after %v", solutionName, l.ttl)
+		}
+	}
+	return context.WithValue(lockCtx, key, holder), release, nil
+}
+
+// RunAsLeader calls run when replica takes leader lease and retries to take it every ttl/3 until ctx is done,
+// so leadership is taken over by other replica within TTL after leader crash.
+// Context of run is cancelled when leadership is lost, run must return after it.
+func (l *Locker) RunAsLeader(ctx context.Context, run func(ctx context.Context)) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		leaderCtx, release, err := l.LockSolution(ctx, leaderNamespace, leaderName)
+		if err == nil {
+			l.logger(ctx).Infoln("Replica is elected as leader")
+			run(leaderCtx)
+			release()
+			if ctx.Err() == nil {
+				l.logger(ctx).Warnln("Replica lost leadership")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquire records lease and returns its expiration time
+func (l *Locker) acquire(ctx context.Context, namespace, solutionName, holder string) (time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(l.ttl)
+	return expiresAt, l.db.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.AcquireSolutionLease(ctx, namespace, solutionName, holder, now, expiresAt)
+	})
+}
+
+// renew prolongs lease expiring at expiresAt until done is closed.
+// Failed renewals are retried while lease is valid, cancel is called if lease is lost.
+func (l *Locker) renew(ctx context.Context, cancel context.CancelFunc, namespace, solutionName, holder string, expiresAt time.Time, done <-chan struct{}) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// renewal is limited by lease expiration, lease may be taken over after it
+			renewCtx, cancelRenew := context.WithDeadline(context.Background(), expiresAt)
+			renewedUntil, err := l.acquire(renewCtx, namespace, solutionName, holder)
+			cancelRenew()
+			switch {
+			case err == nil:
+				expiresAt = renewedUntil
+			case err == db.ErrLeaseConflict || !time.Now().Before(expiresAt):
+				l.logger(ctx).WithError(err).Errorf("Solution %s lock is lost, cancelling operation", solutionName)
+				cancel()
+				return
+			default:
+				l.logger(ctx).WithError(err).Warnf("Unable to renew solution %s lock, retrying", solutionName)
+			}
+		}
+	}
+}
+
+// logger returns lock log entry with request-scoped fields
+func (l *Locker) logger(ctx context.Context) *logrus.Entry {
+	return utils.LogEntry(ctx, l.log)
+}
@@ -0,0 +1,92 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/memory"
+)
+
+func TestLockRenewal(t *testing.T) {
+	database := memory.NewDB()
+	locker := NewLocker(database, 30*time.Millisecond)
+
+	ctx, release, err := locker.LockSolution(context.Background(), "ns", "solution")
+	if err != nil {
+		t.Fatalf("Unable to lock solution: %v", err)
+	}
+	defer release()
+
+	// lease is renewed, so it's not expired after ttl
+	time.Sleep(100 * time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("Lock context is cancelled while lease is renewed: %v", err)
+	}
+	if _, _, err := locker.LockSolution(context.Background(), "ns", "solution"); err == nil {
+		t.Fatalf("Solution is locked twice")
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	database := memory.NewDB()
+	locker := NewLocker(database, 30*time.Millisecond)
+
+	ctx, release, err := locker.LockSolution(context.Background(), "ns", "solution")
+	if err != nil {
+		t.Fatalf("Unable to lock solution: %v", err)
+	}
+	defer release()
+
+	// other holder takes lease over as if it expired
+	if err := database.Transactional(context.Background(), func(ctx context.Context, tx db.DB) error {
+		later := time.Now().Add(time.Hour)
+		return tx.AcquireSolutionLease(ctx, "ns", "solution", "other", later, later.Add(time.Hour))
+	}); err != nil {
+		t.Fatalf("Unable to take lease over: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Lock context is not cancelled after lease is lost")
+	}
+}
+
+func TestRunAsLeader(t *testing.T) {
+	database := memory.NewDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaders := make(chan string, 2)
+	run := func(name string) {
+		NewLocker(database, 30*time.Millisecond).RunAsLeader(ctx, func(ctx context.Context) {
+			leaders <- name
+			<-ctx.Done()
+		})
+	}
+	go run("first")
+	if leader := <-leaders; leader != "first" {
+		t.Fatalf("Unexpected leader %s", leader)
+	}
+	go run("second")
+	select {
+	case leader := <-leaders:
+		t.Fatalf("Replica %s is elected while leader is alive", leader)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// other holder takes leader lease over, so both replicas are followers until it expires
+	if err := database.Transactional(context.Background(), func(ctx context.Context, tx db.DB) error {
+		now := time.Now()
+		return tx.AcquireSolutionLease(ctx, leaderNamespace, leaderName, "other", now.Add(time.Hour), now.Add(50*time.Millisecond))
+	}); err != nil {
+		t.Fatalf("Unable to take leader lease over: %v", err)
+	}
+	select {
+	case <-leaders:
+	case <-time.After(time.Second):
+		t.Fatalf("Leader is not elected after lease expiration")
+	}
+}
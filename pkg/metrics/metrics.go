@@ -0,0 +1,57 @@
+// Package metrics contains service metrics exposed in prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ResultSuccess is a "result" label value for successful operations
+	ResultSuccess = "success"
+	// ResultFailure is a "result" label value for failed operations
+	ResultFailure = "failure"
+
+	namespace = "solutions_"
+)
+
+// Service metrics
+var (
+	HTTPRequests = NewCounterVec(namespace+"http_requests_total",
+		"Count of HTTP requests by route and response status.", "method", "route", "status")
+	HTTPRequestDuration = NewHistogramVec(namespace+"http_request_duration_seconds",
+		"HTTP requests latency by route.", nil, "method", "route")
+
+	SolutionRuns = NewCounterVec(namespace+"runs_total",
+		"Count of solution runs by template and result.", "template", "result")
+	ResourceCreateFailures = NewCounterVec(namespace+"resource_create_failures_total",
+		"Count of failed solution resources creations by resource type.", "type")
+	ActiveSolutions = NewGaugeVec(namespace+"active_solutions",
+		"Count of active (not deleted) solutions by template.", "template")
+
+	DownloadDuration = NewHistogramVec(namespace+"download_duration_seconds",
+		"Template files download latency by host.", nil, "host")
+	DownloadErrors = NewCounterVec(namespace+"download_errors_total",
+		"Count of failed template files downloads by host.", "host")
+
+	ClientRetries = NewCounterVec(namespace+"client_retries_total",
+		"Count of retried outbound requests by client.", "client")
+	ClientCircuitOpens = NewCounterVec(namespace+"client_circuit_opens_total",
+		"Count of circuit breaker openings by client and upstream host.", "client", "upstream")
+
+	DBQueryDuration = NewHistogramVec(namespace+"db_query_duration_seconds",
+		"Database queries latency by operation (query or exec).", nil, "operation")
+	DBTransactionRollbacks = NewCounterVec(namespace+"db_transaction_rollbacks_total",
+		"Count of rolled back database transactions.")
+)
+
+// Handler returns handler writing metrics of DefaultRegistry
+func Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var buf bytes.Buffer
+		DefaultRegistry.Write(ctx.Request.Context(), &buf)
+		ctx.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+	}
+}
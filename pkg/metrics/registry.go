@@ -0,0 +1,318 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are default histogram buckets in seconds (same as prometheus client uses)
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+const labelsSeparator = "\xff"
+
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry holds metrics and writes them in prometheus text exposition format
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	scrapeHook []func(ctx context.Context)
+}
+
+// DefaultRegistry is a registry used by package level metrics
+var DefaultRegistry = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// OnScrape adds function called before metrics are written.
+// It's used for metrics which are cheaper to compute on demand (i.e. counts of DB records).
+func (r *Registry) OnScrape(f func(ctx context.Context)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scrapeHook = append(r.scrapeHook, f)
+}
+
+// OnScrapeCached adds scrape hook called at most once per ttl, metrics set by previous call are written meanwhile.
+// It's used for metrics computed by expensive queries, so frequent or concurrent scrapes don't load database.
+func (r *Registry) OnScrapeCached(ttl time.Duration, f func(ctx context.Context)) {
+	var mu sync.Mutex
+	var calledAt time.Time
+	r.OnScrape(func(ctx context.Context) {
+		// concurrent scrapes wait for running call, so they don't write partially updated metrics
+		mu.Lock()
+		defer mu.Unlock()
+		if !calledAt.IsZero() && time.Since(calledAt) < ttl {
+			return
+		}
+		calledAt = time.Now()
+		f(ctx)
+	})
+}
+
+// Write calls scrape hooks and writes all metrics to w
+func (r *Registry) Write(ctx context.Context, w io.Writer) {
+	r.mu.Lock()
+	hooks := append([]func(ctx context.Context){}, r.scrapeHook...)
+	collectors := append([]collector{}, r.collectors...)
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// OnScrape adds scrape hook to DefaultRegistry
+func OnScrape(f func(ctx context.Context)) {
+	DefaultRegistry.OnScrape(f)
+}
+
+// OnScrapeCached adds cached scrape hook to DefaultRegistry
+func OnScrapeCached(ttl time.Duration, f func(ctx context.Context)) {
+	DefaultRegistry.OnScrapeCached(ttl, f)
+}
+
+type vec struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string][]string
+}
+
+func newVec(name, help, typ string, labels []string) vec {
+	return vec{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		values: make(map[string][]string),
+	}
+}
+
+// key returns key of series with labels values. Must be called with mu locked.
+func (v *vec) key(labelValues []string) string {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, labelsSeparator)
+	if _, ok := v.values[key]; !ok {
+		v.values[key] = append([]string{}, labelValues...)
+	}
+	return key
+}
+
+// sortedKeys returns series keys in stable order. Must be called with mu locked.
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.typ)
+}
+
+func (v *vec) labelsString(key string, extra ...string) string {
+	pairs := make([]string, 0, len(v.labels)+1)
+	for i, value := range v.values[key] {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, v.labels[i], escapeLabel(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec is a set of counters partitioned by labels
+type CounterVec struct {
+	vec
+	counters map[string]float64
+}
+
+// NewCounterVec creates counter and registers it in DefaultRegistry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		vec:      newVec(name, help, "counter", labels),
+		counters: make(map[string]float64),
+	}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc increments counter with labels values by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta to counter with labels values
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[c.key(labelValues)] += delta
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelsString(k), formatFloat(c.counters[k]))
+	}
+}
+
+// GaugeVec is a set of gauges partitioned by labels
+type GaugeVec struct {
+	vec
+	gauges map[string]float64
+}
+
+// NewGaugeVec creates gauge and registers it in DefaultRegistry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		vec:    newVec(name, help, "gauge", labels),
+		gauges: make(map[string]float64),
+	}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// Set sets gauge with labels values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.gauges[g.key(labelValues)] = value
+}
+
+// Reset removes all gauge series, so series which are not set anymore are not exposed
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values = make(map[string][]string)
+	g.gauges = make(map[string]float64)
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w)
+	for _, k := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelsString(k), formatFloat(g.gauges[k]))
+	}
+}
+
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// HistogramVec is a set of histograms partitioned by labels
+type HistogramVec struct {
+	vec
+	bounds     []float64
+	histograms map[string]*histogram
+}
+
+// NewHistogramVec creates histogram and registers it in DefaultRegistry.
+// DefaultBuckets are used if buckets is empty.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{
+		vec:        newVec(name, help, "histogram", labels),
+		bounds:     buckets,
+		histograms: make(map[string]*histogram),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// Observe adds value to histogram with labels values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := h.key(labelValues)
+	hist, ok := h.histograms[k]
+	if !ok {
+		hist = &histogram{buckets: make([]uint64, len(h.bounds))}
+		h.histograms[k] = hist
+	}
+	for i, bound := range h.bounds {
+		if value <= bound {
+			hist.buckets[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+// ObserveSince adds time elapsed since start in seconds to histogram with labels values
+func (h *HistogramVec) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, k := range h.sortedKeys() {
+		hist := h.histograms[k]
+		for i, bound := range h.bounds {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelsString(k, "le", formatFloat(bound)), hist.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelsString(k, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelsString(k), formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelsString(k), hist.count)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
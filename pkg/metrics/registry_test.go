@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOnScrapeCached(t *testing.T) {
+	registry := &Registry{}
+	calls := 0
+	registry.OnScrapeCached(time.Hour, func(ctx context.Context) {
+		calls++
+	})
+	for i := 0; i < 3; i++ {
+		registry.Write(context.Background(), &bytes.Buffer{})
+	}
+	if calls != 1 {
+		t.Fatalf("Cached hook is called %d times", calls)
+	}
+
+	registry = &Registry{}
+	calls = 0
+	registry.OnScrapeCached(0, func(ctx context.Context) {
+		calls++
+	})
+	registry.Write(context.Background(), &bytes.Buffer{})
+	registry.Write(context.Background(), &bytes.Buffer{})
+	if calls != 2 {
+		t.Fatalf("Hook without ttl is called %d times", calls)
+	}
+}
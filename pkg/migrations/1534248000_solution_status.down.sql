@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS replicas;
+ALTER TABLE solutions
+  DROP COLUMN status,
+  DROP COLUMN start_schedule,
+  DROP COLUMN stop_schedule;
@@ -0,0 +1,12 @@
+ALTER TABLE solutions
+  ADD COLUMN status TEXT NOT NULL DEFAULT 'Running',
+  ADD COLUMN start_schedule TEXT NOT NULL DEFAULT '',
+  ADD COLUMN stop_schedule TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS replicas
+(
+  solution_id UUID NOT NULL,
+  deployment TEXT NOT NULL,
+  replicas INTEGER NOT NULL,
+  CONSTRAINT replicas_pkey PRIMARY KEY (solution_id, deployment),
+  CONSTRAINT replicas_solutions_fkey FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
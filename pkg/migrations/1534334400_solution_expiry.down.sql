@@ -0,0 +1,3 @@
+ALTER TABLE solutions
+  DROP COLUMN expires_at,
+  DROP COLUMN expiry_warned;
@@ -0,0 +1,3 @@
+ALTER TABLE solutions
+  ADD COLUMN expires_at TIMESTAMP WITHOUT TIME ZONE,
+  ADD COLUMN expiry_warned BOOLEAN NOT NULL DEFAULT 'false';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS events
+(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  action TEXT NOT NULL,
+  outcome TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  user_id TEXT NOT NULL DEFAULT '',
+  user_role TEXT NOT NULL DEFAULT '',
+  namespace TEXT NOT NULL DEFAULT '',
+  solution TEXT NOT NULL DEFAULT '',
+  template TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS events_created_at_idx ON events (created_at);
+CREATE INDEX IF NOT EXISTS events_solution_idx ON events (namespace, solution, created_at);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+  id UUID PRIMARY KEY,
+  namespace TEXT NOT NULL DEFAULT '',
+  url TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  events TEXT NOT NULL DEFAULT '[]',
+  created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_namespace_idx ON webhooks (namespace);
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+  id UUID PRIMARY KEY,
+  webhook_id UUID NOT NULL,
+  event_id UUID NOT NULL,
+  action TEXT NOT NULL,
+  url TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  signature TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  next_attempt_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  delivered_at TIMESTAMP WITHOUT TIME ZONE,
+  CONSTRAINT webhook_deliveries_webhooks_fkey FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at);
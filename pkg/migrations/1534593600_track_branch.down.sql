@@ -0,0 +1,2 @@
+ALTER TABLE solutions
+  DROP COLUMN track_branch;
@@ -0,0 +1,2 @@
+ALTER TABLE solutions
+  ADD COLUMN track_branch BOOLEAN NOT NULL DEFAULT 'false';
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS solution_resources;
+DROP INDEX IF EXISTS parameters_solution_id_idx;
+DROP INDEX IF EXISTS solutions_template_id_idx;
+DROP INDEX IF EXISTS solutions_user_id_idx;
+DROP INDEX IF EXISTS solutions_namespace_idx;
+ALTER TABLE parameters
+  ALTER COLUMN env DROP NOT NULL,
+  ALTER COLUMN env DROP DEFAULT;
+ALTER TABLE templates
+  ALTER COLUMN active DROP DEFAULT;
+ALTER TABLE webhook_deliveries
+  ALTER COLUMN created_at TYPE TIMESTAMP WITHOUT TIME ZONE USING created_at AT TIME ZONE 'UTC',
+  ALTER COLUMN next_attempt_at TYPE TIMESTAMP WITHOUT TIME ZONE USING next_attempt_at AT TIME ZONE 'UTC',
+  ALTER COLUMN delivered_at TYPE TIMESTAMP WITHOUT TIME ZONE USING delivered_at AT TIME ZONE 'UTC';
+ALTER TABLE webhooks
+  ALTER COLUMN created_at TYPE TIMESTAMP WITHOUT TIME ZONE USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE events
+  ALTER COLUMN created_at TYPE TIMESTAMP WITHOUT TIME ZONE USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE solutions
+  ALTER COLUMN created_at TYPE TIMESTAMP WITHOUT TIME ZONE USING created_at AT TIME ZONE current_setting('TimeZone'),
+  ALTER COLUMN deleted_at TYPE TIMESTAMP WITHOUT TIME ZONE USING deleted_at AT TIME ZONE current_setting('TimeZone'),
+  ALTER COLUMN expires_at TYPE TIMESTAMP WITHOUT TIME ZONE USING expires_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,36 @@
+-- solutions timestamps were written in local time: created_at by now() default in database time zone,
+-- deleted_at and expires_at by service in its time zone. They are converted as times in database time zone,
+-- so migration requires service time zone (TZ) to be the same as database TimeZone setting.
+ALTER TABLE solutions
+  ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE current_setting('TimeZone'),
+  ALTER COLUMN deleted_at TYPE TIMESTAMP WITH TIME ZONE USING deleted_at AT TIME ZONE current_setting('TimeZone'),
+  ALTER COLUMN expires_at TYPE TIMESTAMP WITH TIME ZONE USING expires_at AT TIME ZONE current_setting('TimeZone');
+-- events and webhooks timestamps were written in UTC
+ALTER TABLE events
+  ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE webhooks
+  ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE webhook_deliveries
+  ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+  ALTER COLUMN next_attempt_at TYPE TIMESTAMP WITH TIME ZONE USING next_attempt_at AT TIME ZONE 'UTC',
+  ALTER COLUMN delivered_at TYPE TIMESTAMP WITH TIME ZONE USING delivered_at AT TIME ZONE 'UTC';
+ALTER TABLE templates
+  ALTER COLUMN active SET DEFAULT TRUE;
+UPDATE parameters SET env = '{}' WHERE env IS NULL;
+ALTER TABLE parameters
+  ALTER COLUMN env SET DEFAULT '{}',
+  ALTER COLUMN env SET NOT NULL;
+CREATE INDEX IF NOT EXISTS solutions_namespace_idx ON solutions (namespace);
+CREATE INDEX IF NOT EXISTS solutions_user_id_idx ON solutions (user_id);
+CREATE INDEX IF NOT EXISTS solutions_template_id_idx ON solutions (template_id);
+CREATE INDEX IF NOT EXISTS parameters_solution_id_idx ON parameters (solution_id);
+CREATE TABLE IF NOT EXISTS solution_resources
+(
+  solution_id UUID NOT NULL,
+  kind TEXT NOT NULL,
+  name TEXT NOT NULL,
+  status TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  CONSTRAINT solution_resources_pkey PRIMARY KEY (solution_id, kind, name),
+  CONSTRAINT solution_resources_solutions_fkey FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE solution_resources
+  DROP COLUMN manifest_hash;
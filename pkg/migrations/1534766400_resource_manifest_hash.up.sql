@@ -0,0 +1,2 @@
+ALTER TABLE solution_resources
+  ADD COLUMN manifest_hash TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE solutions
+  DROP COLUMN version;
+ALTER TABLE templates
+  DROP COLUMN version;
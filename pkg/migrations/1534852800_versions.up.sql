@@ -0,0 +1,4 @@
+ALTER TABLE templates
+  ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE solutions
+  ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
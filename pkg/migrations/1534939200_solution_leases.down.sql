@@ -0,0 +1 @@
+DROP TABLE IF EXISTS solution_leases;
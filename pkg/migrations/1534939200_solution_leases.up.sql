@@ -0,0 +1,9 @@
+-- leases of solution locks shared by service replicas, solution may not exist yet
+CREATE TABLE IF NOT EXISTS solution_leases
+(
+  namespace TEXT NOT NULL,
+  name TEXT NOT NULL,
+  holder TEXT NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT solution_leases_pkey PRIMARY KEY (namespace, name)
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS replicas;
+DROP TABLE IF EXISTS parameters;
+DROP TABLE IF EXISTS solutions;
+DROP TABLE IF EXISTS templates;
@@ -0,0 +1,108 @@
+-- schema of postgresql migrations up to 1534593600_track_branch.
+-- UUIDs are stored as text and generated like uuid_generate_v4(), json as text, booleans as integers
+-- and timestamps as UTC text ("YYYY-MM-DD HH:MM:SS.NNNNNNNNN") which may be compared as strings.
+CREATE TABLE IF NOT EXISTS templates
+(
+  id TEXT PRIMARY KEY NOT NULL DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+  name TEXT NOT NULL,
+  cpu INTEGER NOT NULL,
+  ram INTEGER NOT NULL,
+  images TEXT NOT NULL,
+  url TEXT NOT NULL,
+  active BOOLEAN NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS only_active_template
+  ON templates (name) WHERE active;
+CREATE TABLE IF NOT EXISTS solutions
+(
+  id TEXT PRIMARY KEY NOT NULL,
+  template_id TEXT NOT NULL,
+  name TEXT,
+  namespace TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  is_deleted BOOLEAN NOT NULL DEFAULT 0,
+  deleted_at TIMESTAMP,
+  status TEXT NOT NULL DEFAULT 'Running',
+  start_schedule TEXT NOT NULL DEFAULT '',
+  stop_schedule TEXT NOT NULL DEFAULT '',
+  expires_at TIMESTAMP,
+  expiry_warned BOOLEAN NOT NULL DEFAULT 0,
+  track_branch BOOLEAN NOT NULL DEFAULT 0,
+  CONSTRAINT solutions_template_fkey FOREIGN KEY (template_id) REFERENCES templates (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS only_not_deleted_solution_namespace
+  ON solutions (name, namespace) WHERE NOT is_deleted;
+CREATE TABLE IF NOT EXISTS parameters
+(
+  id TEXT PRIMARY KEY NOT NULL DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+  solution_id TEXT NOT NULL,
+  branch TEXT NOT NULL,
+  env TEXT,
+  data TEXT,
+  CONSTRAINT solutions_parametes_fkey FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS replicas
+(
+  solution_id TEXT NOT NULL,
+  deployment TEXT NOT NULL,
+  replicas INTEGER NOT NULL,
+  CONSTRAINT replicas_pkey PRIMARY KEY (solution_id, deployment),
+  CONSTRAINT replicas_solutions_fkey FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS events
+(
+  id TEXT PRIMARY KEY NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  action TEXT NOT NULL,
+  outcome TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  user_id TEXT NOT NULL DEFAULT '',
+  user_role TEXT NOT NULL DEFAULT '',
+  namespace TEXT NOT NULL DEFAULT '',
+  solution TEXT NOT NULL DEFAULT '',
+  template TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS events_created_at_idx ON events (created_at);
+CREATE INDEX IF NOT EXISTS events_solution_idx ON events (namespace, solution, created_at);
+CREATE TABLE IF NOT EXISTS webhooks
+(
+  id TEXT PRIMARY KEY NOT NULL,
+  namespace TEXT NOT NULL DEFAULT '',
+  url TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  events TEXT NOT NULL DEFAULT '[]',
+  created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_namespace_idx ON webhooks (namespace);
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+  id TEXT PRIMARY KEY NOT NULL,
+  webhook_id TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  action TEXT NOT NULL,
+  url TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  signature TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  next_attempt_at TIMESTAMP NOT NULL,
+  delivered_at TIMESTAMP,
+  CONSTRAINT webhook_deliveries_webhooks_fkey FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at);
+INSERT INTO templates (name, cpu, ram, images, url, active) VALUES
+  ('mariadb-solution', 100, 128, '["mariadb"]', 'https://github.com/containerum/mariadb-solution', 1),
+  ('postgresql-solution', 100, 128, '["postgres"]', 'https://github.com/containerum/postgresql-solution', 1),
+  ('grafana-xxl-solution', 100, 128, '["grafana-xxl"]', 'https://github.com/containerum/grafana-xxl-solution', 1),
+  ('rabbitmq-manager-solution', 100, 128, '["rabbitmq"]', 'https://github.com/containerum/rabbitmq-manager-solution', 1),
+  ('webpack-3.8-ssh-solution', 100, 128, '["webpack-3.8-ssh-solution"]', 'https://github.com/containerum/webpack-3.8-ssh-solution', 1),
+  ('redmine-mariadb-solution', 200, 256, '["redmine", "mariadb"]', 'https://github.com/containerum/redmine-mariadb-solution', 1),
+  ('redmine-postgresql-solution', 200, 256, '["redmine", "postgres"]', 'https://github.com/containerum/redmine-postgresql-solution', 1),
+  ('magento-solution', 200, 256, '["mysql", "magento2"]', 'https://github.com/containerum/magento-solution', 1);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS solution_resources;
+DROP INDEX IF EXISTS parameters_solution_id_idx;
+DROP INDEX IF EXISTS solutions_template_id_idx;
+DROP INDEX IF EXISTS solutions_user_id_idx;
+DROP INDEX IF EXISTS solutions_namespace_idx;
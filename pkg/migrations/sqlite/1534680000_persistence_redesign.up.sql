@@ -0,0 +1,15 @@
+-- booleans and timestamps of sqlite schema are already stored as integers and UTC text
+CREATE INDEX IF NOT EXISTS solutions_namespace_idx ON solutions (namespace);
+CREATE INDEX IF NOT EXISTS solutions_user_id_idx ON solutions (user_id);
+CREATE INDEX IF NOT EXISTS solutions_template_id_idx ON solutions (template_id);
+CREATE INDEX IF NOT EXISTS parameters_solution_id_idx ON parameters (solution_id);
+CREATE TABLE IF NOT EXISTS solution_resources
+(
+  solution_id TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  name TEXT NOT NULL,
+  status TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  CONSTRAINT solution_resources_pkey PRIMARY KEY (solution_id, kind, name),
+  CONSTRAINT solution_resources_solutions_fkey FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+-- bundled sqlite version doesn't support "DROP COLUMN", so table is rebuilt
+CREATE TABLE solution_resources_old
+(
+  solution_id TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  name TEXT NOT NULL,
+  status TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  CONSTRAINT solution_resources_pkey PRIMARY KEY (solution_id, kind, name),
+  CONSTRAINT solution_resources_solutions_fkey FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
+INSERT INTO solution_resources_old (solution_id, kind, name, status, created_at)
+  SELECT solution_id, kind, name, status, created_at FROM solution_resources;
+DROP TABLE solution_resources;
+ALTER TABLE solution_resources_old RENAME TO solution_resources;
@@ -0,0 +1,4 @@
+-- bundled sqlite version doesn't support "DROP COLUMN" and templates and solutions tables
+-- can't be rebuilt without cascade deletion of referencing rows, so version columns are kept.
+-- They have defaults and are not used by previous versions of service.
+SELECT 1;
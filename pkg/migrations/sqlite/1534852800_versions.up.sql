@@ -0,0 +1,2 @@
+ALTER TABLE templates ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE solutions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// SolutionBundle -- portable solution configuration
+//
+// swagger:model
+type SolutionBundle struct {
+	//export date in RFC3339 format
+	ExportedAt string `json:"exported_at"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Template   string `json:"template"`
+	URL        string `json:"url"`
+	Branch     string `json:"branch"`
+	// commit which branch was resolved to during export
+	Commit string            `json:"commit,omitempty"`
+	Env    map[string]string `json:"env"`
+	// env variables with removed values, manifests contain placeholders instead of them
+	Redacted  []string                 `json:"redacted,omitempty"`
+	Resources []SolutionBundleResource `json:"resources"`
+}
+
+// SolutionBundleResource -- rendered solution resource
+//
+// swagger:model
+type SolutionBundleResource struct {
+	Type       string          `json:"type"`
+	ConfigFile string          `json:"config_file"`
+	Name       string          `json:"name"`
+	Manifest   json.RawMessage `json:"manifest"`
+}
+
+// SolutionImportRequest -- request to create solution from bundle
+//
+// swagger:model
+type SolutionImportRequest struct {
+	// required: true
+	Bundle SolutionBundle `json:"bundle"`
+	// new solution name, bundle solution name is used if empty
+	Name string `json:"name,omitempty"`
+	// env variables overriding bundle env, values of redacted variables used in manifests are required
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Solution builds solution to run in namespace from import request.
+// Redacted env variables are dropped unless they are overridden.
+func (req SolutionImportRequest) Solution(namespace string) kube_types.Solution {
+	ret := kube_types.Solution{
+		Name:      req.Bundle.Name,
+		Namespace: namespace,
+		Template:  req.Bundle.Template,
+		Branch:    req.Bundle.Branch,
+		Env:       make(map[string]string, len(req.Bundle.Env)+len(req.Env)),
+	}
+	if req.Name != "" {
+		ret.Name = req.Name
+	}
+	if req.Bundle.Commit != "" {
+		ret.Branch = req.Bundle.Commit
+	}
+
+	redacted := make(map[string]bool, len(req.Bundle.Redacted))
+	for _, k := range req.Bundle.Redacted {
+		redacted[k] = true
+	}
+	for k, v := range req.Bundle.Env {
+		if !redacted[k] {
+			ret.Env[k] = v
+		}
+	}
+	for k, v := range req.Env {
+		ret.Env[k] = v
+	}
+	return ret
+}
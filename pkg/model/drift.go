@@ -0,0 +1,55 @@
+package model
+
+// Kinds of drifted resources
+const (
+	KindDeployment = "deployment"
+	KindService    = "service"
+)
+
+// SolutionsDriftReport -- drift between solutions records and cluster resources
+//
+// swagger:model
+type SolutionsDriftReport struct {
+	//check date in RFC3339 format
+	CheckedAt string `json:"checked_at"`
+	Checked   int    `json:"checked"`
+	Drifted   int    `json:"drifted"`
+	// drifted solutions
+	Solutions []SolutionDrift `json:"solutions"`
+	// solution resources without solution record
+	Orphaned []DriftResource `json:"orphaned,omitempty"`
+	Errors   []string        `json:"errors,omitempty"`
+}
+
+// SolutionDrift -- drift of one solution
+//
+// swagger:model
+type SolutionDrift struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// resources from template which are not found in cluster
+	Missing []DriftResource `json:"missing,omitempty"`
+	// resources labelled with solution which are not in template
+	Orphaned []DriftResource `json:"orphaned,omitempty"`
+	// resources which differ from template
+	Modified []DriftResource `json:"modified,omitempty"`
+	// missing resources which were recreated
+	Healed []DriftResource `json:"healed,omitempty"`
+	Errors []string        `json:"errors,omitempty"`
+}
+
+// DriftResource -- drifted resource
+//
+// swagger:model
+type DriftResource struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	SolutionID string `json:"solution_id,omitempty"`
+	Details    string `json:"details,omitempty"`
+}
+
+// IsDrifted returns true if solution has any drift or check errors
+func (drift SolutionDrift) IsDrifted() bool {
+	return len(drift.Missing)+len(drift.Orphaned)+len(drift.Modified)+len(drift.Errors) > 0
+}
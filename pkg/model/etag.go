@@ -0,0 +1,27 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// ETag returns entity tag of database entity version.
+// Entity ID is included as hash, so entity created with the same name has new tag while ID is not disclosed.
+func ETag(id string, version int) string {
+	sum := sha256.Sum256([]byte(id))
+	return strconv.Quote(hex.EncodeToString(sum[:8]) + "-" + strconv.Itoa(version))
+}
+
+// MatchETag checks if "If-Match" header value matches entity tag.
+// Header may contain "*" or comma separated list of tags, weak tags never match.
+func MatchETag(ifMatch, etag string) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// Event actions
+const (
+	EventRunSolution        = "run_solution"
+	EventUpgradeSolution    = "upgrade_solution"
+	EventDeleteSolution     = "delete_solution"
+	EventStopSolution       = "stop_solution"
+	EventStartSolution      = "start_solution"
+	EventScheduleSolution   = "schedule_solution"
+	EventExtendSolution     = "extend_solution"
+	EventTrackSolution      = "track_solution"
+	EventSolutionExpiring   = "solution_expiring"
+	EventAddTemplate        = "add_template"
+	EventUpdateTemplate     = "update_template"
+	EventActivateTemplate   = "activate_template"
+	EventDeactivateTemplate = "deactivate_template"
+	EventRefreshTemplate    = "refresh_template"
+)
+
+// EventActions contains all event actions
+var EventActions = []string{
+	EventRunSolution,
+	EventUpgradeSolution,
+	EventDeleteSolution,
+	EventStopSolution,
+	EventStartSolution,
+	EventScheduleSolution,
+	EventExtendSolution,
+	EventTrackSolution,
+	EventSolutionExpiring,
+	EventAddTemplate,
+	EventUpdateTemplate,
+	EventActivateTemplate,
+	EventDeactivateTemplate,
+	EventRefreshTemplate,
+}
+
+// Event outcomes
+const (
+	EventSuccess = "success"
+	EventFailure = "failure"
+)
+
+// Event -- record of state-changing operation
+//
+// swagger:model
+type Event struct {
+	ID string `json:"id"`
+	// event date in RFC3339 format
+	Time   string `json:"time"`
+	Action string `json:"action"`
+	// success or failure
+	Outcome   string `json:"outcome"`
+	Error     string `json:"error,omitempty"`
+	UserID    string `json:"user_id"`
+	UserRole  string `json:"user_role"`
+	Namespace string `json:"namespace,omitempty"`
+	Solution  string `json:"solution,omitempty"`
+	Template  string `json:"template,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// EventsList -- list of events
+//
+// swagger:model
+type EventsList struct {
+	Events []Event `json:"events"`
+}
+
+// EventsFilter -- events search parameters, empty fields are ignored
+type EventsFilter struct {
+	UserID    string
+	Namespace string
+	Solution  string
+	Template  string
+	Action    string
+	Outcome   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
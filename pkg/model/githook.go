@@ -0,0 +1,29 @@
+package model
+
+// TemplatePush -- template repository push received from git hosting webhook
+//
+// swagger:model
+type TemplatePush struct {
+	// git hosting: github, gitlab or gitea
+	Provider string `json:"provider"`
+	// repository full name ("owner/repo")
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	Commit     string `json:"commit"`
+}
+
+// TemplatePushResult -- template push processing result
+//
+// swagger:model
+type TemplatePushResult struct {
+	Template string `json:"template"`
+	Branch   string `json:"branch"`
+	Commit   string `json:"commit"`
+	// true if template is valid on pushed branch
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+	// true if template images were refreshed
+	ImagesRefreshed bool `json:"images_refreshed"`
+	// solutions ("namespace/name") tracking pushed branch, upgraded in background
+	Upgrading []string `json:"upgrading"`
+}
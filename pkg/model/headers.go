@@ -2,10 +2,10 @@ package model
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 
 	"github.com/containerum/kube-client/pkg/model"
-	"github.com/json-iterator/go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,7 +24,7 @@ func ParseUserHeaderData(str string) (*UserHeaderDataMap, error) {
 		return nil, ErrUnableDecodeUserHeaderData
 	}
 	var userData []model.UserHeaderData
-	err = jsoniter.Unmarshal(data, &userData)
+	err = json.Unmarshal(data, &userData)
 	if err != nil {
 		log.WithError(err).WithField("Value", string(data)).Warn(ErrUnableUnmarshalUserHeaderData)
 		return nil, ErrUnableUnmarshalUserHeaderData
@@ -0,0 +1,21 @@
+package model
+
+// HealthCheck -- result of service dependency check
+//
+// swagger:model
+type HealthCheck struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+	// failed optional check doesn't fail report
+	Optional bool `json:"optional,omitempty"`
+}
+
+// HealthReport -- results of all service dependencies checks
+//
+// swagger:model
+type HealthReport struct {
+	OK     bool          `json:"ok"`
+	Checks []HealthCheck `json:"checks"`
+}
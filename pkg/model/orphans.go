@@ -0,0 +1,26 @@
+package model
+
+// OrphanResourcesReport -- solution resources without live solution record
+//
+// swagger:model
+type OrphanResourcesReport struct {
+	//check date in RFC3339 format
+	CheckedAt string `json:"checked_at"`
+	DryRun    bool   `json:"dry_run"`
+	Found     int    `json:"found"`
+	Deleted   int    `json:"deleted"`
+	// orphan resources grouped by solution
+	Solutions []OrphanSolution `json:"solutions"`
+	Errors    []string         `json:"errors,omitempty"`
+}
+
+// OrphanSolution -- orphan resources of one deleted solution
+//
+// swagger:model
+type OrphanSolution struct {
+	SolutionID string          `json:"solution_id"`
+	Namespace  string          `json:"namespace"`
+	Resources  []DriftResource `json:"resources"`
+	Deleted    bool            `json:"deleted"`
+	Error      string          `json:"error,omitempty"`
+}
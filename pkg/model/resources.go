@@ -0,0 +1,31 @@
+package model
+
+// Solution resource statuses
+const (
+	ResourceCreated = "created"
+	// resource creation failed, resource may not exist
+	ResourceFailed = "failed"
+	// resource was removed from solution and deleted
+	ResourceDeleted = "deleted"
+)
+
+// SolutionResource -- kubernetes resource created for solution
+//
+// swagger:model
+type SolutionResource struct {
+	// resource kind (i.e. "deployment" or "service")
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// SHA-256 hash of rendered resource manifest
+	ManifestHash string `json:"manifest_hash,omitempty"`
+	// creation date in RFC3339 format
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// SolutionResourcesList -- list of solution resources
+//
+// swagger:model
+type SolutionResourcesList struct {
+	Resources []SolutionResource `json:"resources"`
+}
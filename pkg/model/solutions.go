@@ -0,0 +1,178 @@
+package model
+
+import (
+	"time"
+
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// Solution statuses
+const (
+	SolutionRunning = "Running"
+	SolutionStopped = "Stopped"
+)
+
+// Solution -- running solution with its state
+//
+// swagger:model
+type Solution struct {
+	kube_types.Solution
+	Status string `json:"status"`
+	// cron expression to start solution
+	StartSchedule string `json:"start_schedule,omitempty"`
+	// cron expression to stop solution
+	StopSchedule string `json:"stop_schedule,omitempty"`
+	// expiration date in RFC3339 format
+	ExpiresAt string `json:"expires_at,omitempty"`
+	// upgrade solution automatically when its template branch is pushed
+	TrackBranch bool `json:"track_branch"`
+	// entity tag of solution version, returned in "ETag" header
+	ETag string `json:"-"`
+}
+
+// Template -- solution template with its version
+//
+// swagger:model
+type Template struct {
+	kube_types.SolutionTemplate
+	// entity tag of template version, returned in "ETag" header
+	ETag string `json:"-"`
+}
+
+// SolutionsList -- list of running solutions
+//
+// swagger:model
+type SolutionsList struct {
+	Solutions []Solution `json:"solutions"`
+}
+
+// SolutionSchedule -- solution start/stop schedule
+//
+// swagger:model
+type SolutionSchedule struct {
+	// cron expression to start solution, empty to disable
+	Start string `json:"start"`
+	// cron expression to stop solution, empty to disable
+	Stop string `json:"stop"`
+}
+
+// DeleteSolutionsResponse -- bulk solutions deletion report
+//
+// swagger:model
+type DeleteSolutionsResponse struct {
+	Deleted    int                    `json:"deleted"`
+	NotDeleted int                    `json:"not_deleted"`
+	Solutions  []DeleteSolutionResult `json:"solutions"`
+}
+
+// DeleteSolutionResult -- deletion result for one solution
+//
+// swagger:model
+type DeleteSolutionResult struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Deleted   bool   `json:"deleted"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DeleteSuccessful adds successfully deleted solution to report
+func (resp *DeleteSolutionsResponse) DeleteSuccessful(name, namespace string) {
+	resp.Deleted++
+	resp.Solutions = append(resp.Solutions, DeleteSolutionResult{
+		Name:      name,
+		Namespace: namespace,
+		Deleted:   true,
+	})
+}
+
+// DeleteFailed adds not deleted solution to report
+func (resp *DeleteSolutionsResponse) DeleteFailed(name, namespace string, err error) {
+	resp.NotDeleted++
+	resp.Solutions = append(resp.Solutions, DeleteSolutionResult{
+		Name:      name,
+		Namespace: namespace,
+		Error:     err.Error(),
+	})
+}
+
+// Errors returns deletion errors of all not deleted solutions
+func (resp *DeleteSolutionsResponse) Errors() []string {
+	var errs []string
+	for _, sol := range resp.Solutions {
+		if !sol.Deleted {
+			errs = append(errs, sol.Namespace+"/"+sol.Name+": "+sol.Error)
+		}
+	}
+	return errs
+}
+
+// SolutionCloneRequest -- request to create copy of running solution
+//
+// swagger:model
+type SolutionCloneRequest struct {
+	// target namespace, source solution namespace is used if empty
+	Namespace string `json:"namespace,omitempty"`
+	// required: true
+	Name string `json:"name"`
+	// generate new values for env variables which template fills with random strings
+	RegenerateRandom bool `json:"regenerate_random,omitempty"`
+}
+
+// SolutionExpiry -- solution time to live
+//
+// swagger:model
+type SolutionExpiry struct {
+	// solution time to live (e.g. "72h"), solution is deleted after it expires
+	TTL string `json:"ttl,omitempty"`
+	// solution expiration date in RFC3339 format
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// IsSet returns true if solution expiry is specified
+func (expiry SolutionExpiry) IsSet() bool {
+	return expiry.TTL != "" || expiry.ExpiresAt != ""
+}
+
+// Time returns solution expiration time in UTC. TTL is counted from "from" time.
+func (expiry SolutionExpiry) Time(from time.Time) (time.Time, error) {
+	if expiry.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, expiry.ExpiresAt)
+		return expiresAt.UTC(), err
+	}
+	ttl, err := time.ParseDuration(expiry.TTL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return from.Add(ttl), nil
+}
+
+// RunSolutionRequest -- request to run solution
+//
+// swagger:model
+type RunSolutionRequest struct {
+	kube_types.Solution
+	SolutionExpiry
+	// upgrade solution automatically when its template branch is pushed
+	TrackBranch bool `json:"track_branch,omitempty"`
+}
+
+// SolutionTracking -- solution template branch tracking settings
+//
+// swagger:model
+type SolutionTracking struct {
+	// upgrade solution automatically when its template branch is pushed
+	TrackBranch bool `json:"track_branch"`
+}
+
+// UpgradeSolutionResponse -- solution upgrade result
+//
+// swagger:model
+type UpgradeSolutionResponse struct {
+	Updated int `json:"updated"`
+	Created int `json:"created"`
+	// resources removed from template
+	Deleted int `json:"deleted"`
+	// resources which manifests are not changed since last upgrade
+	Unchanged int      `json:"unchanged"`
+	Errors    []string `json:"errors"`
+}
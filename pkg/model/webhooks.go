@@ -0,0 +1,91 @@
+package model
+
+import "encoding/json"
+
+// Headers sent with webhook payloads
+const (
+	WebhookEventHeader    = "X-Solutions-Event"
+	WebhookDeliveryHeader = "X-Solutions-Delivery"
+	// payload HMAC-SHA256 signature in "sha256=<hex>" format
+	WebhookSignatureHeader = "X-Solutions-Signature"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook -- subscription to events.
+// Solution creation and failure are delivered as run_solution events with success and failure outcomes.
+//
+// swagger:model
+type Webhook struct {
+	ID string `json:"id"`
+	// namespace, empty for global webhooks
+	Namespace string `json:"namespace,omitempty"`
+	// URL to POST events to
+	// required: true
+	URL string `json:"url"`
+	// key to sign payloads with HMAC-SHA256, never returned
+	// required: true
+	Secret string `json:"secret,omitempty"`
+	// event actions to deliver, all events are delivered if empty
+	Events []string `json:"events,omitempty"`
+	// creation date in RFC3339 format
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Matches returns true if webhook is subscribed to event
+func (webhook Webhook) Matches(event Event) bool {
+	if webhook.Namespace != "" && webhook.Namespace != event.Namespace {
+		return false
+	}
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, action := range webhook.Events {
+		if action == event.Action {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhooksList -- list of webhooks
+//
+// swagger:model
+type WebhooksList struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookDelivery -- event delivery to webhook
+//
+// swagger:model
+type WebhookDelivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	Action    string `json:"action"`
+	URL       string `json:"url"`
+	// delivered event
+	Payload json.RawMessage `json:"payload"`
+	// payload HMAC-SHA256 signature sent in X-Solutions-Signature header
+	Signature string `json:"-"`
+	// pending, delivered or failed
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	// dates in RFC3339 format
+	CreatedAt     string `json:"created_at"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	DeliveredAt   string `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveriesList -- list of webhook deliveries
+//
+// swagger:model
+type WebhookDeliveriesList struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:operation GET /admin/drift Drift GetSolutionsDrift
+// Get last solutions drift report.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+// responses:
+//  '200':
+//    description: solutions drift report
+//    schema:
+//      $ref: '#/definitions/SolutionsDriftReport'
+//  default:
+//    $ref: '#/responses/error'
+func GetSolutionsDrift(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	resp, err := ss.GetSolutionsDrift(ctx.Request.Context())
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableReconcileSolutions(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation POST /admin/drift Drift ReconcileSolutions
+// Check all solutions for drift and optionally recreate missing resources.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: heal
+//    in: query
+//    type: boolean
+//    required: false
+// responses:
+//  '200':
+//    description: solutions drift report
+//    schema:
+//      $ref: '#/definitions/SolutionsDriftReport'
+//  default:
+//    $ref: '#/responses/error'
+func ReconcileSolutions(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var heal bool
+	if ctx.Query("heal") != "" {
+		var err error
+		if heal, err = strconv.ParseBool(ctx.Query("heal")); err != nil {
+			gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+			return
+		}
+	}
+
+	resp, err := ss.ReconcileSolutions(ctx.Request.Context(), heal)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableReconcileSolutions(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation GET /namespaces/{namespace}/solutions/{solution}/drift Solutions GetSolutionDrift
+// Check solution for drift between template and actual resources.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: solution drift
+//    schema:
+//      $ref: '#/definitions/SolutionDrift'
+//  default:
+//    $ref: '#/responses/error'
+func GetSolutionDrift(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	resp, err := ss.GetSolutionDrift(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"))
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableGetSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation GET /admin/orphans Drift GetOrphanResources
+// Get solution resources without live solution record (dry run).
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+// responses:
+//  '200':
+//    description: orphan resources
+//    schema:
+//      $ref: '#/definitions/OrphanResourcesReport'
+//  default:
+//    $ref: '#/responses/error'
+func GetOrphanResources(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	resp, err := ss.CollectOrphanResources(ctx.Request.Context(), true)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableCollectOrphans(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation DELETE /admin/orphans Drift DeleteOrphanResources
+// Delete solution resources without live solution record.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+// responses:
+//  '202':
+//    description: orphan resources deleted
+//    schema:
+//      $ref: '#/definitions/OrphanResourcesReport'
+//  default:
+//    $ref: '#/responses/error'
+func DeleteOrphanResources(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	resp, err := ss.CollectOrphanResources(ctx.Request.Context(), false)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableCollectOrphans(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, resp)
+}
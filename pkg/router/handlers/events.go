@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+// parseEventsFilter parses common events query parameters
+func parseEventsFilter(ctx *gin.Context) (model.EventsFilter, *cherry.Err) {
+	filter := model.EventsFilter{
+		Action:  ctx.Query("action"),
+		Outcome: ctx.Query("outcome"),
+		Limit:   defaultEventsLimit,
+	}
+	var errs []error
+	for param, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := ctx.Query(param); v != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if v := ctx.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case limit > 0 && limit <= maxEventsLimit:
+			filter.Limit = limit
+		default:
+			errs = append(errs, strconv.ErrRange)
+		}
+	}
+	if len(errs) > 0 {
+		return filter, solerrors.ErrRequestValidationFailed().AddDetailsErr(errs...)
+	}
+	return filter, nil
+}
+
+func getEvents(ctx *gin.Context, filter model.EventsFilter) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	resp, err := ss.GetEvents(ctx.Request.Context(), filter)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableGetEvents(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation GET /namespaces/{namespace}/solutions/{solution}/events Solutions GetSolutionEvents
+// Get solution events, newest first.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: action
+//    in: query
+//    type: string
+//    required: false
+//  - name: outcome
+//    in: query
+//    type: string
+//    required: false
+//  - name: since
+//    in: query
+//    type: string
+//    required: false
+//  - name: until
+//    in: query
+//    type: string
+//    required: false
+//  - name: limit
+//    in: query
+//    type: integer
+//    required: false
+// responses:
+//  '200':
+//    description: solution events
+//    schema:
+//      $ref: '#/definitions/EventsList'
+//  default:
+//    $ref: '#/responses/error'
+func GetSolutionEvents(ctx *gin.Context) {
+	filter, err := parseEventsFilter(ctx)
+	if err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+	filter.Namespace = ctx.Param("namespace")
+	filter.Solution = ctx.Param("solution")
+
+	getEvents(ctx, filter)
+}
+
+// swagger:operation GET /admin/audit Events GetAuditEvents
+// Get audit trail of all state-changing operations, newest first.
+//
+// ---
+// x-method-visibility: private
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: user_id
+//    in: query
+//    type: string
+//    required: false
+//  - name: namespace
+//    in: query
+//    type: string
+//    required: false
+//  - name: solution
+//    in: query
+//    type: string
+//    required: false
+//  - name: template
+//    in: query
+//    type: string
+//    required: false
+//  - name: action
+//    in: query
+//    type: string
+//    required: false
+//  - name: outcome
+//    in: query
+//    type: string
+//    required: false
+//  - name: since
+//    in: query
+//    type: string
+//    required: false
+//  - name: until
+//    in: query
+//    type: string
+//    required: false
+//  - name: limit
+//    in: query
+//    type: integer
+//    required: false
+// responses:
+//  '200':
+//    description: events
+//    schema:
+//      $ref: '#/definitions/EventsList'
+//  default:
+//    $ref: '#/responses/error'
+func GetAuditEvents(ctx *gin.Context) {
+	filter, err := parseEventsFilter(ctx)
+	if err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+	filter.UserID = ctx.Query("user_id")
+	filter.Namespace = ctx.Query("namespace")
+	filter.Solution = ctx.Query("solution")
+	filter.Template = ctx.Query("template")
+
+	getEvents(ctx, filter)
+}
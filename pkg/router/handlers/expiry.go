@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/validation"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// swagger:operation POST /namespaces/{namespace}/solutions/{solution}/extend Solutions ExtendSolution
+// Extend solution expiry.
+// TTL is added to current solution expiration time, expiration date replaces it.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SolutionExpiry'
+// responses:
+//  '202':
+//    description: solution expiry extended
+//  default:
+//    $ref: '#/responses/error'
+func ExtendSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var request model.SolutionExpiry
+	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	if err := validation.ValidateSolutionExpiry(request); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+
+	if err := ss.ExtendSolution(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"), request); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableExtendSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
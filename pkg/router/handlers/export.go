@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/validation"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// swagger:operation GET /namespaces/{namespace}/solutions/{solution}/export Solutions ExportSolution
+// Export solution as portable bundle.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: redact
+//    in: query
+//    type: boolean
+//    required: false
+// responses:
+//  '200':
+//    description: solution bundle
+//    schema:
+//      $ref: '#/definitions/SolutionBundle'
+//  default:
+//    $ref: '#/responses/error'
+func ExportSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var redact bool
+	if ctx.Query("redact") != "" {
+		var err error
+		if redact, err = strconv.ParseBool(ctx.Query("redact")); err != nil {
+			gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+			return
+		}
+	}
+
+	resp, err := ss.ExportSolution(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"), redact)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableExportSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation POST /namespaces/{namespace}/solutions/import Solutions ImportSolution
+// Create solution from bundle.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SolutionImportRequest'
+// responses:
+//  '202':
+//    description: solution imported
+//    schema:
+//      $ref: '#/definitions/ImportResponse'
+//  default:
+//    $ref: '#/responses/error'
+func ImportSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var request model.SolutionImportRequest
+	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	if err := validation.ValidateSolutionImport(request); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+	// imported solution is checked like solution in run request
+	if err := validation.ValidateSolution(request.Solution(ctx.Param("namespace"))); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+
+	if request.Bundle.Branch == "" {
+		request.Bundle.Branch = branchMaster
+	}
+
+	ret, err := ss.ImportSolution(ctx.Request.Context(), ctx.Param("namespace"), request)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableImportSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, ret)
+}
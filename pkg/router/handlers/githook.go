@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+)
+
+// gitPushPayload contains push payload fields common for GitHub, GitLab and Gitea
+type gitPushPayload struct {
+	Ref   string `json:"ref"`
+	After string `json:"after"`
+	// GitHub and Gitea
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	// GitLab
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+// isPushEvent returns true if git hosting webhook is sent on push
+func isPushEvent(ctx *gin.Context, provider string) bool {
+	switch provider {
+	case m.Gitea:
+		return m.GetHeader(ctx, "X-Gitea-Event") == "push"
+	case m.GitLab:
+		return m.GetHeader(ctx, "X-Gitlab-Event") == "Push Hook"
+	default:
+		return m.GetHeader(ctx, "X-GitHub-Event") == "push"
+	}
+}
+
+// swagger:operation POST /hooks/git/{template} Templates TemplateGitHook
+// Receive template repository push from GitHub, GitLab or Gitea.
+// Template is validated on pushed branch and solutions tracking pushed branch are upgraded in background.
+// Events other than branch push are ignored.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - name: template
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: event ignored
+//  '202':
+//    description: push processed
+//    schema:
+//      $ref: '#/definitions/TemplatePushResult'
+//  default:
+//    $ref: '#/responses/error'
+func TemplateGitHook(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	provider := ctx.MustGet(m.GitHookProvider).(string)
+
+	if !isPushEvent(ctx, provider) {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	var payload gitPushPayload
+	if err := ctx.ShouldBindWith(&payload, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+	if !strings.HasPrefix(payload.Ref, branchRefPrefix) {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	push := model.TemplatePush{
+		Provider:   provider,
+		Repository: payload.Repository.FullName,
+		Branch:     strings.TrimPrefix(payload.Ref, branchRefPrefix),
+		Commit:     payload.After,
+	}
+	if provider == m.GitLab {
+		push.Repository = payload.Project.PathWithNamespace
+	}
+
+	// git hosting doesn't send identity headers, so template is refreshed on behalf of service
+	ret, err := ss.RefreshTemplate(utils.AdminContext(ctx.Request.Context()), ctx.Param("template"), push)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableRefreshTemplate(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, ret)
+}
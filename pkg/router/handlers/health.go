@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// swagger:operation GET /healthz Health Healthz
+// Check if service is alive.
+//
+// ---
+// x-method-visibility: private
+// responses:
+//  '200':
+//    description: service is alive
+func Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// swagger:operation GET /readyz Health Readyz
+// Check if service dependencies are available.
+//
+// ---
+// x-method-visibility: private
+// responses:
+//  '200':
+//    description: service is ready
+//    schema:
+//      $ref: '#/definitions/HealthReport'
+//  '503':
+//    description: some of required service dependencies are not available
+//    schema:
+//      $ref: '#/definitions/HealthReport'
+func Readyz(timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+		report := ss.CheckReadiness(ctx.Request.Context(), timeout)
+		if !report.OK {
+			ctx.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		ctx.JSON(http.StatusOK, report)
+	}
+}
+
+// swagger:operation GET /status Health ServiceStatus
+// Get service status aggregated from dependencies checks.
+//
+// ---
+// x-method-visibility: private
+// responses:
+//  '200':
+//    description: service works
+//  '500':
+//    description: some of required service dependencies are not available
+func ServiceStatus(status *kube_types.ServiceStatus, timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+		report := ss.CheckStatus(ctx.Request.Context(), timeout)
+		ret := kube_types.ServiceStatus{
+			Name:     status.Name,
+			Version:  status.Version,
+			StatusOK: report.OK,
+			Details:  make(map[string]string, len(report.Checks)),
+		}
+		for _, check := range report.Checks {
+			if check.OK {
+				ret.AddDetails(check.Name, "ok")
+			} else {
+				ret.AddDetails(check.Name, check.Error)
+			}
+		}
+		if !ret.StatusOK {
+			ctx.JSON(http.StatusInternalServerError, ret)
+			return
+		}
+		ctx.JSON(http.StatusOK, ret)
+	}
+}
@@ -3,13 +3,13 @@ package handlers
 import (
 	"net/http"
 
+	"git.containerum.net/ch/solutions/pkg/model"
 	m "git.containerum.net/ch/solutions/pkg/router/middleware"
 	"git.containerum.net/ch/solutions/pkg/server"
 	"git.containerum.net/ch/solutions/pkg/solerrors"
 	"git.containerum.net/ch/solutions/pkg/validation"
 	"github.com/containerum/cherry"
 	"github.com/containerum/cherry/adaptors/gonic"
-	kubeTypes "github.com/containerum/kube-client/pkg/model"
 	"github.com/containerum/utils/httputil"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -104,8 +104,12 @@ func GetNamespaceSolutions(ctx *gin.Context) {
 // responses:
 //  '200':
 //    description: running solution
+//    headers:
+//      ETag:
+//        type: string
+//        description: entity tag of solution version
 //    schema:
-//      $ref: '#/definitions/SolutionsList'
+//      $ref: '#/definitions/Solution'
 //  default:
 //    $ref: '#/responses/error'
 func GetSolution(ctx *gin.Context) {
@@ -121,6 +125,7 @@ func GetSolution(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header("ETag", resp.ETag)
 	ctx.JSON(http.StatusOK, resp)
 }
 
@@ -202,6 +207,45 @@ func GetSolutionsServices(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// swagger:operation GET /namespaces/{namespace}/solutions/{solution}/resources Solutions GetSolutionResources
+// Get resources created for solution.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: solution resources
+//    schema:
+//      $ref: '#/definitions/SolutionResourcesList'
+//  default:
+//    $ref: '#/responses/error'
+func GetSolutionResources(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	resp, err := ss.GetSolutionResources(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"))
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableGetSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
 // swagger:operation POST /namespaces/{namespace}/solutions Solutions RunSolution
 // Run solution.
 //
@@ -217,7 +261,7 @@ func GetSolutionsServices(ctx *gin.Context) {
 //  - name: body
 //    in: body
 //    schema:
-//      $ref: '#/definitions/Solution'
+//      $ref: '#/definitions/RunSolutionRequest'
 // responses:
 //  '202':
 //    description: solution created
@@ -228,7 +272,7 @@ func GetSolutionsServices(ctx *gin.Context) {
 func RunSolution(ctx *gin.Context) {
 	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
 
-	var request kubeTypes.Solution
+	var request model.RunSolutionRequest
 	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
 		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
 		return
@@ -236,11 +280,18 @@ func RunSolution(ctx *gin.Context) {
 
 	request.Namespace = ctx.Param("namespace")
 
-	if err := validation.ValidateSolution(request); err != nil {
+	if err := validation.ValidateSolution(request.Solution); err != nil {
 		gonic.Gonic(err, ctx)
 		return
 	}
 
+	if request.IsSet() {
+		if err := validation.ValidateSolutionExpiry(request.SolutionExpiry); err != nil {
+			gonic.Gonic(err, ctx)
+			return
+		}
+	}
+
 	if request.Branch == "" {
 		request.Branch = branchMaster
 	}
@@ -267,6 +318,7 @@ func RunSolution(ctx *gin.Context) {
 // parameters:
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
 //  - name: namespace
 //    in: path
 //    type: string
@@ -306,11 +358,14 @@ func DeleteSolution(ctx *gin.Context) {
 // responses:
 //  '202':
 //    description: user solutions deleted
+//    schema:
+//      $ref: '#/definitions/DeleteSolutionsResponse'
 //  default:
 //    $ref: '#/responses/error'
 func DeleteSolutions(ctx *gin.Context) {
 	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
-	if err := ss.DeleteSolutions(ctx.Request.Context()); err != nil {
+	ret, err := ss.DeleteSolutions(ctx.Request.Context())
+	if err != nil {
 		if cherr, ok := err.(*cherry.Err); ok {
 			gonic.Gonic(cherr, ctx)
 		} else {
@@ -320,7 +375,7 @@ func DeleteSolutions(ctx *gin.Context) {
 		return
 	}
 
-	ctx.Status(http.StatusAccepted)
+	ctx.JSON(http.StatusAccepted, ret)
 }
 
 // swagger:operation DELETE /namespaces/{namespace}/solutions Solutions DeleteNamespaceSolutions
@@ -338,11 +393,14 @@ func DeleteSolutions(ctx *gin.Context) {
 // responses:
 //  '202':
 //    description: solutions deleted
+//    schema:
+//      $ref: '#/definitions/DeleteSolutionsResponse'
 //  default:
 //    $ref: '#/responses/error'
 func DeleteNamespaceSolutions(ctx *gin.Context) {
 	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
-	if err := ss.DeleteNamespaceSolutions(ctx.Request.Context(), ctx.Param("namespace")); err != nil {
+	ret, err := ss.DeleteNamespaceSolutions(ctx.Request.Context(), ctx.Param("namespace"))
+	if err != nil {
 		if cherr, ok := err.(*cherry.Err); ok {
 			gonic.Gonic(cherr, ctx)
 		} else {
@@ -351,5 +409,68 @@ func DeleteNamespaceSolutions(ctx *gin.Context) {
 		}
 		return
 	}
-	ctx.Status(http.StatusAccepted)
+	ctx.JSON(http.StatusAccepted, ret)
+}
+
+// swagger:operation POST /namespaces/{namespace}/solutions/{solution}/clone Solutions CloneSolution
+// Create copy of running solution.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SolutionCloneRequest'
+// responses:
+//  '202':
+//    description: solution cloned
+//    schema:
+//      $ref: '#/definitions/RunSolutionResponse'
+//  default:
+//    $ref: '#/responses/error'
+func CloneSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var request model.SolutionCloneRequest
+	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	if request.Namespace == "" {
+		request.Namespace = ctx.Param("namespace")
+	}
+
+	if err := validation.ValidateSolutionClone(request); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+
+	if !m.WriteNamespaceAccess(ctx, request.Namespace) {
+		return
+	}
+
+	ret, err := ss.CloneSolution(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"), request)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableCloneSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, ret)
 }
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/validation"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// swagger:operation POST /namespaces/{namespace}/solutions/{solution}/stop Solutions StopSolution
+// Stop solution (scale all deployments to zero).
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '202':
+//    description: solution stopped
+//  default:
+//    $ref: '#/responses/error'
+func StopSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	if err := ss.StopSolution(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution")); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableChangeSolutionState(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// swagger:operation POST /namespaces/{namespace}/solutions/{solution}/start Solutions StartSolution
+// Start stopped solution (restore deployments replicas).
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '202':
+//    description: solution started
+//  default:
+//    $ref: '#/responses/error'
+func StartSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	if err := ss.StartSolution(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution")); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableChangeSolutionState(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// swagger:operation PUT /namespaces/{namespace}/solutions/{solution}/schedule Solutions SetSolutionSchedule
+// Set solution start/stop schedule.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SolutionSchedule'
+// responses:
+//  '202':
+//    description: solution schedule updated
+//  default:
+//    $ref: '#/responses/error'
+func SetSolutionSchedule(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var request model.SolutionSchedule
+	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	if err := validation.ValidateSolutionSchedule(request); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+
+	if err := ss.SetSolutionSchedule(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"), request); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableChangeSolutionState(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
@@ -46,6 +46,46 @@ func GetTemplatesList(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// swagger:operation GET /templates/{template} Templates GetTemplate
+// Get solution template.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: template
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: solution template
+//    headers:
+//      ETag:
+//        type: string
+//        description: entity tag of template version
+//    schema:
+//      $ref: '#/definitions/Template'
+//  default:
+//    $ref: '#/responses/error'
+func GetTemplate(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+	resp, err := ss.GetTemplate(ctx.Request.Context(), ctx.Param("template"), ctx.GetHeader(httputil.UserRoleXHeader) == m.RoleAdmin)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableGetTemplate(), ctx)
+		}
+		return
+	}
+
+	ctx.Header("ETag", resp.ETag)
+	ctx.JSON(http.StatusOK, resp)
+}
+
 // swagger:operation GET /templates/{template}/env Templates GetTemplatesEnv
 // Get solution templates environment variables.
 //
@@ -184,6 +224,7 @@ func AddTemplate(ctx *gin.Context) {
 // parameters:
 //  - $ref: '#/parameters/UserRoleHeader'
 //  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
 //  - name: template
 //    in: path
 //    type: string
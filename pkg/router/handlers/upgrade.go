@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// swagger:operation POST /namespaces/{namespace}/solutions/{solution}/upgrade Solutions UpgradeSolution
+// Upgrade solution resources to current version of its template branch.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '202':
+//    description: solution upgraded
+//    schema:
+//      $ref: '#/definitions/UpgradeSolutionResponse'
+//  default:
+//    $ref: '#/responses/error'
+func UpgradeSolution(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	resp, err := ss.UpgradeSolution(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"))
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableUpgradeSolution(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, resp)
+}
+
+// swagger:operation PUT /namespaces/{namespace}/solutions/{solution}/track Solutions SetSolutionTracking
+// Enable or disable automatic solution upgrade when its template branch is pushed.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - $ref: '#/parameters/IfMatchHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: solution
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/SolutionTracking'
+// responses:
+//  '202':
+//    description: solution tracking updated
+//  default:
+//    $ref: '#/responses/error'
+func SetSolutionTracking(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var request model.SolutionTracking
+	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	if err := ss.SetSolutionTracking(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("solution"), request); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableChangeSolutionState(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/validation"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Webhook handlers serve both namespace webhooks and global webhooks managed by admins under /admin/webhooks.
+// Namespace path parameter is empty for global webhooks.
+
+// swagger:operation GET /namespaces/{namespace}/webhooks Webhooks GetWebhooksList
+// Get namespace webhooks list.
+// Global webhooks list is available to admins at GET /admin/webhooks.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: webhooks list
+//    schema:
+//      $ref: '#/definitions/WebhooksList'
+//  default:
+//    $ref: '#/responses/error'
+func GetWebhooksList(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	resp, err := ss.GetWebhooksList(ctx.Request.Context(), ctx.Param("namespace"))
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableGetWebhooks(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation POST /namespaces/{namespace}/webhooks Webhooks CreateWebhook
+// Create namespace webhook.
+// Global webhooks receiving events of all namespaces are created by admins at POST /admin/webhooks.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: body
+//    in: body
+//    schema:
+//      $ref: '#/definitions/Webhook'
+// responses:
+//  '201':
+//    description: webhook created
+//    schema:
+//      $ref: '#/definitions/Webhook'
+//  default:
+//    $ref: '#/responses/error'
+func CreateWebhook(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	var request model.Webhook
+	if err := ctx.ShouldBindWith(&request, binding.JSON); err != nil {
+		gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	if err := validation.ValidateWebhook(request); err != nil {
+		gonic.Gonic(err, ctx)
+		return
+	}
+
+	resp, err := ss.CreateWebhook(ctx.Request.Context(), ctx.Param("namespace"), request)
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableCreateWebhook(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// swagger:operation DELETE /namespaces/{namespace}/webhooks/{webhook} Webhooks DeleteWebhook
+// Delete namespace webhook.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: webhook
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '202':
+//    description: webhook deleted
+//  default:
+//    $ref: '#/responses/error'
+func DeleteWebhook(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	if err := ss.DeleteWebhook(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("webhook")); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableDeleteWebhook(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// swagger:operation GET /namespaces/{namespace}/webhooks/{webhook}/deliveries Webhooks GetWebhookDeliveries
+// Get last webhook deliveries, newest first.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: webhook
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '200':
+//    description: webhook deliveries
+//    schema:
+//      $ref: '#/definitions/WebhookDeliveriesList'
+//  default:
+//    $ref: '#/responses/error'
+func GetWebhookDeliveries(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	resp, err := ss.GetWebhookDeliveries(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("webhook"))
+	if err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableGetWebhooks(), ctx)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// swagger:operation POST /namespaces/{namespace}/webhooks/{webhook}/deliveries/{delivery}/redeliver Webhooks RedeliverWebhook
+// Send webhook delivery again.
+//
+// ---
+// x-method-visibility: public
+// parameters:
+//  - $ref: '#/parameters/UserRoleHeader'
+//  - $ref: '#/parameters/UserIDHeader'
+//  - name: namespace
+//    in: path
+//    type: string
+//    required: true
+//  - name: webhook
+//    in: path
+//    type: string
+//    required: true
+//  - name: delivery
+//    in: path
+//    type: string
+//    required: true
+// responses:
+//  '202':
+//    description: redelivery scheduled
+//  default:
+//    $ref: '#/responses/error'
+func RedeliverWebhook(ctx *gin.Context) {
+	ss := ctx.MustGet(m.SolutionsServices).(server.SolutionsService)
+
+	if err := ss.RedeliverWebhook(ctx.Request.Context(), ctx.Param("namespace"), ctx.Param("webhook"), ctx.Param("delivery")); err != nil {
+		if cherr, ok := err.(*cherry.Err); ok {
+			gonic.Gonic(cherr, ctx)
+		} else {
+			ctx.Error(err)
+			gonic.Gonic(solerrors.ErrUnableRedeliverWebhook(), ctx)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
@@ -54,7 +54,17 @@ func WriteAccess(ctx *gin.Context) {
 }
 
 func CheckAccess(ctx *gin.Context, level []kubeModel.AccessLevel) {
-	ns := ctx.Param("namespace")
+	CheckNamespaceAccess(ctx, ctx.Param("namespace"), level)
+}
+
+// WriteNamespaceAccess checks write access to namespace which is not in path params (e.g. from request body)
+func WriteNamespaceAccess(ctx *gin.Context, ns string) bool {
+	return CheckNamespaceAccess(ctx, ns, writeLevels)
+}
+
+// CheckNamespaceAccess returns true if user has access to namespace.
+// Otherwise it aborts request with error and returns false.
+func CheckNamespaceAccess(ctx *gin.Context, ns string, level []kubeModel.AccessLevel) bool {
 	if GetHeader(ctx, headers.UserRoleXHeader) == RoleUser {
 		var userNsData *kubeModel.UserHeaderData
 		nsList := ctx.MustGet(UserNamespaces).(*model.UserHeaderDataMap)
@@ -66,14 +76,15 @@ func CheckAccess(ctx *gin.Context, level []kubeModel.AccessLevel) {
 		}
 		if userNsData != nil {
 			if ok := containsAccess(userNsData.Access, level...); ok {
-				return
+				return true
 			}
 			gonic.Gonic(solerrors.ErrAccessError(), ctx)
-			return
+			return false
 		}
 		gonic.Gonic(solerrors.ErrSolutionNotExist().AddDetails("project is not found"), ctx)
-		return
+		return false
 	}
+	return true
 }
 
 func containsAccess(access kubeModel.AccessLevel, in ...kubeModel.AccessLevel) bool {
@@ -12,6 +12,8 @@ const (
 
 	//SolutionsServices is key for services
 	SolutionsServices = "s-service"
+	//GitHookProvider is key for git hosting which sent webhook
+	GitHookProvider = "git-hook-provider"
 )
 
 // RegisterServices adds services to context
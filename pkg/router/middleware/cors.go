@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/gin-contrib/cors.v1"
+)
+
+// requiredHeaders are always allowed in CORS requests, because service API can't be used without them
+var requiredHeaders = []string{"Authorization", "If-Match"}
+
+// CORSConfig is a policy of cross-origin requests
+type CORSConfig struct {
+	// AllowOrigins are origins allowed to make requests, i.e. "https://web.containerum.io".
+	// Origin may have wildcard subdomain, i.e. "https://*.containerum.io". "*" allows all origins.
+	AllowOrigins []string
+	AllowMethods []string
+	// AllowHeaders are request headers allowed in addition to required Authorization and If-Match
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge is a time while browsers may cache preflight request result
+	MaxAge time.Duration
+}
+
+func (config CORSConfig) allowAll() bool {
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks origins format
+func (config CORSConfig) Validate() error {
+	if len(config.AllowOrigins) == 0 {
+		return errors.New("no CORS origins allowed, use * to allow all origins")
+	}
+	if config.allowAll() {
+		if config.AllowCredentials {
+			return errors.New("CORS credentials can't be allowed for all origins")
+		}
+		return nil
+	}
+	for _, origin := range config.AllowOrigins {
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("bad CORS origin %s: scheme is required", origin)
+		}
+		if wildcard := strings.Index(origin, "*"); wildcard >= 0 &&
+			(strings.Count(origin, "*") > 1 || !strings.HasSuffix(origin[:wildcard], "://") || !strings.HasPrefix(origin[wildcard+1:], ".")) {
+			return fmt.Errorf("bad CORS origin %s: only wildcard subdomain is allowed", origin)
+		}
+	}
+	return nil
+}
+
+// allowHeaders returns allowed headers with required ones
+func (config CORSConfig) allowHeaders() []string {
+	headers := append([]string{}, config.AllowHeaders...)
+	for _, required := range requiredHeaders {
+		found := false
+		for _, header := range config.AllowHeaders {
+			if strings.EqualFold(header, required) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			headers = append(headers, required)
+		}
+	}
+	return headers
+}
+
+// allowOrigin returns function checking origin against allowed origins and wildcard subdomains
+func (config CORSConfig) allowOrigin() func(origin string) bool {
+	exact := make(map[string]bool)
+	var wildcards [][2]string // prefix, suffix
+	for _, allowed := range config.AllowOrigins {
+		allowed = strings.ToLower(allowed)
+		if wildcard := strings.Index(allowed, "*"); wildcard >= 0 {
+			wildcards = append(wildcards, [2]string{allowed[:wildcard], allowed[wildcard+1:]})
+		} else {
+			exact[allowed] = true
+		}
+	}
+	return func(origin string) bool {
+		origin = strings.ToLower(origin)
+		if exact[origin] {
+			return true
+		}
+		for _, wildcard := range wildcards {
+			if strings.HasPrefix(origin, wildcard[0]) && strings.HasSuffix(origin, wildcard[1]) &&
+				validSubdomain(origin[len(wildcard[0]):len(origin)-len(wildcard[1])]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func validSubdomain(subdomain string) bool {
+	if subdomain == "" || strings.HasPrefix(subdomain, ".") || strings.HasSuffix(subdomain, ".") {
+		return false
+	}
+	for _, r := range subdomain {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// CORS handles cross-origin requests. Requests from not allowed origins are rejected,
+// preflight requests are answered without passing to next handlers.
+// Policy may be replaced while requests are served.
+type CORS struct {
+	mu      sync.RWMutex
+	handler gin.HandlerFunc
+}
+
+// NewCORS returns CORS middleware applying config
+func NewCORS(config CORSConfig) (*CORS, error) {
+	c := &CORS{}
+	return c, c.Set(config)
+}
+
+// Set replaces CORS policy. Previous policy is kept if config is invalid.
+func (c *CORS) Set(config CORSConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	cfg := cors.Config{
+		AllowMethods:     config.AllowMethods,
+		AllowHeaders:     config.allowHeaders(),
+		ExposeHeaders:    config.ExposeHeaders,
+		AllowCredentials: config.AllowCredentials,
+		MaxAge:           config.MaxAge,
+	}
+	if config.allowAll() {
+		cfg.AllowAllOrigins = true
+	} else {
+		cfg.AllowOriginFunc = config.allowOrigin()
+	}
+	handler := cors.New(cfg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+	return nil
+}
+
+// Handle applies current CORS policy to request
+func (c *CORS) Handle(ctx *gin.Context) {
+	c.mu.RLock()
+	handler := c.handler
+	c.mu.RUnlock()
+	handler(ctx)
+}
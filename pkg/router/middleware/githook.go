@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+)
+
+// Git hosting providers
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+	Gitea  = "gitea"
+)
+
+// MaxGitHookBody limits git webhook payload, it is read before signature is checked
+const MaxGitHookBody = 5 << 20
+
+// gitHookProvider detects git hosting by webhook headers.
+// Gitea also sends GitHub headers, so it's checked first.
+func gitHookProvider(ctx *gin.Context) string {
+	switch {
+	case GetHeader(ctx, "X-Gitea-Event") != "":
+		return Gitea
+	case GetHeader(ctx, "X-Gitlab-Event") != "":
+		return GitLab
+	case GetHeader(ctx, "X-GitHub-Event") != "":
+		return GitHub
+	}
+	return ""
+}
+
+func validHMAC(secret string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// VerifyGitHook checks git hosting webhook secret.
+// GitHub and Gitea sign payloads with HMAC-SHA256, GitLab sends secret as is.
+// All webhooks are rejected if secret is empty. Payloads larger than MaxGitHookBody are rejected.
+func VerifyGitHook(secret string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if secret == "" {
+			gonic.Gonic(solerrors.ErrInvalidGitHookSignature().AddDetails("git webhooks are disabled"), ctx)
+			return
+		}
+
+		tooLarge := solerrors.ErrRequestTooLarge().AddDetails(fmt.Sprintf("git webhook payload is limited to %d bytes", MaxGitHookBody))
+		if ctx.Request.ContentLength > MaxGitHookBody {
+			gonic.Gonic(tooLarge, ctx)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, MaxGitHookBody))
+		if len(body) >= MaxGitHookBody && err != nil {
+			gonic.Gonic(tooLarge, ctx)
+			return
+		}
+		if err != nil {
+			gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+			return
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		provider := gitHookProvider(ctx)
+		var valid bool
+		switch provider {
+		case GitHub:
+			valid = validHMAC(secret, body, strings.TrimPrefix(GetHeader(ctx, "X-Hub-Signature-256"), "sha256="))
+		case Gitea:
+			valid = validHMAC(secret, body, GetHeader(ctx, "X-Gitea-Signature"))
+		case GitLab:
+			valid = subtle.ConstantTimeCompare([]byte(GetHeader(ctx, "X-Gitlab-Token")), []byte(secret)) == 1
+		default:
+			gonic.Gonic(solerrors.ErrRequestValidationFailed().AddDetails("unknown git hosting"), ctx)
+			return
+		}
+		if !valid {
+			gonic.Gonic(solerrors.ErrInvalidGitHookSignature(), ctx)
+			return
+		}
+		ctx.Set(GitHookProvider, provider)
+	}
+}
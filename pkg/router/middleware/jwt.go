@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"git.containerum.net/ch/solutions/pkg/auth"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry/adaptors/gonic"
+	headers "github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+const authorizationHeader = "Authorization"
+
+// JWTIdentity authenticates requests by bearer token and replaces identity headers with ones derived from token claims.
+// Identity headers sent by client are never trusted, so service may be used without API gateway.
+// Identity headers are sent to other services as before.
+func JWTIdentity(verifier *auth.Verifier) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorization := GetHeader(ctx, authorizationHeader)
+		if len(authorization) < len("Bearer ") || !strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+			gonic.Gonic(solerrors.ErrInvalidToken().AddDetails("bearer token is required"), ctx)
+			return
+		}
+		identity, err := verifier.Verify(ctx.Request.Context(), strings.TrimSpace(authorization[len("Bearer "):]))
+		if err != nil {
+			gonic.Gonic(solerrors.ErrInvalidToken().AddDetailsErr(err), ctx)
+			return
+		}
+
+		header := ctx.Request.Header
+		header.Set(headers.UserIDXHeader, identity.UserID)
+		header.Set(headers.UserRoleXHeader, identity.Role)
+		header.Del(headers.UserNamespacesXHeader)
+		if identity.Role == auth.RoleUser {
+			data, err := json.Marshal(identity.Namespaces)
+			if err != nil {
+				gonic.Gonic(solerrors.ErrInvalidToken().AddDetailsErr(err), ctx)
+				return
+			}
+			header.Set(headers.UserNamespacesXHeader, base64.StdEncoding.EncodeToString(data))
+		}
+	}
+}
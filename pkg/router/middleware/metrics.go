@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+const unmatchedRoute = "unmatched"
+
+// routeMatcher finds registered route pattern of request path,
+// so path parameters don't produce separate metrics series
+type routeMatcher struct {
+	once   sync.Once
+	e      *gin.Engine
+	routes map[string][][]string // method -> split route paths
+}
+
+func (rm *routeMatcher) match(method, path string) string {
+	rm.once.Do(func() {
+		// routes are registered after middlewares, so they are collected on first request
+		rm.routes = make(map[string][][]string)
+		for _, route := range rm.e.Routes() {
+			rm.routes[route.Method] = append(rm.routes[route.Method], strings.Split(route.Path, "/"))
+		}
+	})
+
+	segments := strings.Split(path, "/")
+	for _, route := range rm.routes[method] {
+		if matchSegments(route, segments) {
+			return strings.Join(route, "/")
+		}
+	}
+	return unmatchedRoute
+}
+
+func matchSegments(route, segments []string) bool {
+	for i, seg := range route {
+		if strings.HasPrefix(seg, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if !strings.HasPrefix(seg, ":") && seg != segments[i] {
+			return false
+		}
+	}
+	return len(route) == len(segments)
+}
+
+// Metrics collects HTTP requests count and latency by route
+func Metrics(e *gin.Engine) gin.HandlerFunc {
+	rm := &routeMatcher{e: e}
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		route := rm.match(ctx.Request.Method, ctx.Request.URL.Path)
+		metrics.HTTPRequests.Inc(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status()))
+		metrics.HTTPRequestDuration.ObserveSince(start, ctx.Request.Method, route)
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const ifMatchHeader = "If-Match"
+
+// SaveIfMatch saves "If-Match" request header to request context,
+// so service checks it against entity tag of solution or template before modification
+func SaveIfMatch(ctx *gin.Context) {
+	if ifMatch := ctx.GetHeader(ifMatchHeader); ifMatch != "" {
+		ctx.Request = ctx.Request.WithContext(utils.ContextWithIfMatch(ctx.Request.Context(), ifMatch))
+	}
+}
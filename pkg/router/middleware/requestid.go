@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/containerum/cherry"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const maxRequestIDLength = 128
+
+// validRequestID checks that request ID received from client is safe to log and forward
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDWriter adds request ID to fields of cherry errors written to response
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if w.Status() >= 400 && strings.HasPrefix(w.Header().Get("Content-Type"), gin.MIMEJSON) {
+		var cherr cherry.Err
+		if err := json.Unmarshal(data, &cherr); err == nil && cherr.ID.SID != "" {
+			if cherr.Fields == nil {
+				cherr.Fields = make(cherry.Fields)
+			}
+			cherr.Fields[utils.RequestIDField] = w.requestID
+			if body, err := json.Marshal(cherr); err == nil {
+				if _, err := w.ResponseWriter.Write(body); err != nil {
+					return 0, err
+				}
+				return len(data), nil
+			}
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// RequestID takes request ID from X-Request-ID header or generates new one.
+// Request ID is forwarded to other services with request headers,
+// returned in response header and errors and added to request-scoped log entry.
+func RequestID(ctx *gin.Context) {
+	requestID := ctx.Request.Header.Get(httputil.RequestIDXHeader)
+	if !validRequestID(requestID) {
+		requestID = uuid.New().String()
+		ctx.Request.Header.Set(httputil.RequestIDXHeader, requestID)
+	}
+	ctx.Header(httputil.RequestIDXHeader, requestID)
+	ctx.Writer = &requestIDWriter{ResponseWriter: ctx.Writer, requestID: requestID}
+
+	rctx := context.WithValue(ctx.Request.Context(), httputil.RequestIDContextKey, requestID)
+	rctx = utils.ContextWithLogEntry(rctx, logrus.WithField(utils.RequestIDField, requestID))
+	ctx.Request = ctx.Request.WithContext(rctx)
+}
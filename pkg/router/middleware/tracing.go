@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"strconv"
+
+	"git.containerum.net/ch/solutions/pkg/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing starts server span for every request.
+// Trace is continued if request contains trace context of caller.
+func Tracing(e *gin.Engine) gin.HandlerFunc {
+	rm := &routeMatcher{e: e}
+	return func(ctx *gin.Context) {
+		rctx := tracing.Extract(ctx.Request.Context(), ctx.Request.Header)
+		rctx, span := tracing.StartSpan(rctx, ctx.Request.Method+" "+ctx.Request.URL.Path, tracing.SpanKindServer)
+		if span == nil {
+			ctx.Next()
+			return
+		}
+		ctx.Request = ctx.Request.WithContext(rctx)
+
+		ctx.Next()
+
+		route := rm.match(ctx.Request.Method, ctx.Request.URL.Path)
+		span.SetName(ctx.Request.Method + " " + route)
+		span.SetAttribute("http.method", ctx.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.path", ctx.Request.URL.Path)
+		span.SetAttribute("http.status_code", strconv.Itoa(ctx.Writer.Status()))
+		if len(ctx.Errors) > 0 {
+			span.SetError(ctx.Errors.Last())
+		} else if ctx.Writer.Status() >= 500 {
+			span.SetError(errorStatus(ctx.Writer.Status()))
+		}
+		span.End()
+	}
+}
+
+type errorStatus int
+
+func (s errorStatus) Error() string {
+	return "response status " + strconv.Itoa(int(s))
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"git.containerum.net/ch/auth/static"
+	"git.containerum.net/ch/solutions/pkg/auth"
+	"git.containerum.net/ch/solutions/pkg/metrics"
 	h "git.containerum.net/ch/solutions/pkg/router/handlers"
 	m "git.containerum.net/ch/solutions/pkg/router/middleware"
 	"git.containerum.net/ch/solutions/pkg/server"
@@ -17,40 +19,81 @@ import (
 	"github.com/gin-gonic/contrib/ginrus"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/gin-contrib/cors.v1"
 )
 
-//CreateRouter initialises router and middlewares
-func CreateRouter(ss *server.SolutionsService, status *model.ServiceStatus, enableCORS bool) http.Handler {
+//CreateRouter initialises router and middlewares. CORS is disabled if cors is nil.
+//Users are authenticated by bearer tokens if verifier is set, otherwise identity headers set by API gateway are trusted.
+//Metrics are served if serveMetrics is set, otherwise they are served by CreateMetricsRouter on separate port.
+func CreateRouter(ss *server.SolutionsService, status *model.ServiceStatus, cors *m.CORS, verifier *auth.Verifier, gitHookSecret string, readyTimeout time.Duration, serveMetrics bool) http.Handler {
 	e := gin.New()
-	e.GET("/status", httputil.ServiceStatus(status))
-	initMiddlewares(e, ss)
-	initRoutes(e, enableCORS)
+	if cors != nil {
+		// CORS is handled before any other middleware, so preflight requests
+		// to every route (and to unknown ones) are answered without identity headers
+		e.Use(cors.Handle)
+	}
+	e.GET("/status", m.RegisterServices(ss), h.ServiceStatus(status, readyTimeout))
+	e.GET("/healthz", h.Healthz)
+	e.GET("/readyz", m.RegisterServices(ss), h.Readyz(readyTimeout))
+	if serveMetrics {
+		// scrapes are not logged and measured, so they don't pollute requests metrics
+		e.GET("/metrics", recovery(), metrics.Handler())
+	}
+	initSystemMiddlewares(e)
+	initHooks(e, ss, gitHookSecret)
+	initMiddlewares(e, ss, verifier)
+	initRoutes(e)
+	return e
+}
+
+//CreateMetricsRouter initialises router serving only metrics
+func CreateMetricsRouter() http.Handler {
+	e := gin.New()
+	e.GET("/metrics", recovery(), metrics.Handler())
 	return e
 }
 
-func initMiddlewares(e *gin.Engine, ss *server.SolutionsService) {
-	/* System */
+func recovery() gin.HandlerFunc {
+	return gonic.Recovery(solerrors.ErrInternalError, cherrylog.NewLogrusAdapter(logrus.WithField("component", "gin")))
+}
+
+//pathSegment aborts request with 404 if path parameter is not equal to value
+func pathSegment(param, value string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Param(param) != value {
+			ctx.AbortWithStatus(http.StatusNotFound)
+		}
+	}
+}
+
+func initSystemMiddlewares(e *gin.Engine) {
+	e.Use(m.RequestID)
 	e.Use(ginrus.Ginrus(logrus.WithField("component", "gin"), time.RFC3339, true))
-	e.Use(gonic.Recovery(solerrors.ErrInternalError, cherrylog.NewLogrusAdapter(logrus.WithField("component", "gin"))))
-	/* Custom */
+	e.Use(m.Metrics(e))
+	e.Use(m.Tracing(e))
+	e.Use(recovery())
+}
+
+// initHooks sets up routes for external services webhooks.
+// They don't have identity headers and are authenticated by secret.
+func initHooks(e *gin.Engine, ss *server.SolutionsService, gitHookSecret string) {
+	e.POST("/hooks/git/:template", m.VerifyGitHook(gitHookSecret), m.RegisterServices(ss), h.TemplateGitHook)
+}
+
+func initMiddlewares(e *gin.Engine, ss *server.SolutionsService, verifier *auth.Verifier) {
+	if verifier != nil {
+		e.Use(m.JWTIdentity(verifier))
+	}
 	e.Use(httputil.SaveHeaders)
 	e.Use(httputil.PrepareContext)
 	e.Use(m.RequiredUserHeaders())
+	e.Use(m.SaveIfMatch)
 	e.Use(m.RegisterServices(ss))
 }
 
 // SetupRoutes sets up http router needed to handle requests from clients.
-func initRoutes(app *gin.Engine, enableCORS bool) {
+func initRoutes(app *gin.Engine) {
 	requireIdentityHeaders := httputil.RequireHeaders(solerrors.ErrRequiredHeadersNotProvided, httputil.UserIDXHeader, httputil.UserRoleXHeader)
 
-	if enableCORS {
-		cfg := cors.DefaultConfig()
-		cfg.AllowAllOrigins = true
-		cfg.AddAllowMethods(http.MethodDelete)
-		cfg.AddAllowHeaders(httputil.UserIDXHeader, httputil.UserRoleXHeader, httputil.UserNamespacesXHeader)
-		app.Use(cors.New(cfg))
-	}
 	app.Group("/static").
 		StaticFS("/", static.HTTP)
 
@@ -59,6 +102,7 @@ func initRoutes(app *gin.Engine, enableCORS bool) {
 	templates := app.Group("/templates")
 	{
 		templates.GET("", h.GetTemplatesList)
+		templates.GET("/:template", h.GetTemplate)
 		templates.GET("/:template/env", h.GetTemplatesEnv)
 		templates.GET("/:template/resources", h.GetTemplatesResources)
 		templates.POST("", httputil.RequireAdminRole(solerrors.ErrAdminRequired), h.AddTemplate)
@@ -77,8 +121,42 @@ func initRoutes(app *gin.Engine, enableCORS bool) {
 		namespaceSolutions.GET("/:solution", m.ReadAccess, h.GetSolution)
 		namespaceSolutions.GET("/:solution/deployments", m.ReadAccess, h.GetSolutionsDeployments)
 		namespaceSolutions.GET("/:solution/services", m.ReadAccess, h.GetSolutionsServices)
+		namespaceSolutions.GET("/:solution/resources", m.ReadAccess, h.GetSolutionResources)
+		namespaceSolutions.GET("/:solution/drift", m.ReadAccess, h.GetSolutionDrift)
+		namespaceSolutions.GET("/:solution/export", m.ReadAccess, h.ExportSolution)
+		namespaceSolutions.GET("/:solution/events", m.ReadAccess, h.GetSolutionEvents)
 		namespaceSolutions.POST("", m.WriteAccess, h.RunSolution)
+		// gin can't route static segment next to :solution, so import segment is matched by parameter
+		namespaceSolutions.POST("/:solution", pathSegment("solution", "import"), m.WriteAccess, h.ImportSolution)
+		namespaceSolutions.POST("/:solution/clone", m.ReadAccess, h.CloneSolution)
+		namespaceSolutions.POST("/:solution/stop", m.WriteAccess, h.StopSolution)
+		namespaceSolutions.POST("/:solution/start", m.WriteAccess, h.StartSolution)
+		namespaceSolutions.PUT("/:solution/schedule", m.WriteAccess, h.SetSolutionSchedule)
+		namespaceSolutions.POST("/:solution/extend", m.WriteAccess, h.ExtendSolution)
+		namespaceSolutions.POST("/:solution/upgrade", m.WriteAccess, h.UpgradeSolution)
+		namespaceSolutions.PUT("/:solution/track", m.WriteAccess, h.SetSolutionTracking)
 		namespaceSolutions.DELETE("/:solution", m.DeleteAccess, h.DeleteSolution)
 		namespaceSolutions.DELETE("", m.DeleteAccess, h.DeleteNamespaceSolutions)
 	}
+	namespaceWebhooks := app.Group("/namespaces/:namespace/webhooks")
+	{
+		namespaceWebhooks.GET("", m.ReadAccess, h.GetWebhooksList)
+		namespaceWebhooks.POST("", m.WriteAccess, h.CreateWebhook)
+		namespaceWebhooks.DELETE("/:webhook", m.WriteAccess, h.DeleteWebhook)
+		namespaceWebhooks.GET("/:webhook/deliveries", m.ReadAccess, h.GetWebhookDeliveries)
+		namespaceWebhooks.POST("/:webhook/deliveries/:delivery/redeliver", m.WriteAccess, h.RedeliverWebhook)
+	}
+	admin := app.Group("/admin", httputil.RequireAdminRole(solerrors.ErrAdminRequired))
+	{
+		admin.GET("/drift", h.GetSolutionsDrift)
+		admin.POST("/drift", h.ReconcileSolutions)
+		admin.GET("/orphans", h.GetOrphanResources)
+		admin.DELETE("/orphans", h.DeleteOrphanResources)
+		admin.GET("/audit", h.GetAuditEvents)
+		admin.GET("/webhooks", h.GetWebhooksList)
+		admin.POST("/webhooks", h.CreateWebhook)
+		admin.DELETE("/webhooks/:webhook", h.DeleteWebhook)
+		admin.GET("/webhooks/:webhook/deliveries", h.GetWebhookDeliveries)
+		admin.POST("/webhooks/:webhook/deliveries/:delivery/redeliver", h.RedeliverWebhook)
+	}
 }
@@ -0,0 +1,60 @@
+package router_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/db/memory"
+	"git.containerum.net/ch/solutions/pkg/db/sqlite"
+	"git.containerum.net/ch/solutions/pkg/router"
+	"git.containerum.net/ch/solutions/pkg/router/routertest"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.ReleaseMode)
+}
+
+func TestRoutesMemory(t *testing.T) {
+	routertest.RunRoutes(t, func(t *testing.T) db.DB {
+		return memory.NewDB()
+	})
+}
+
+func TestRoutesSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solutions-routes")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var database db.DB
+	defer func() {
+		if database != nil {
+			database.Close()
+		}
+	}()
+	routertest.RunRoutes(t, func(t *testing.T) db.DB {
+		if database, err = sqlite.DBConnect(filepath.Join(dir, "test.db"), "../migrations/sqlite"); err != nil {
+			t.Fatalf("Unable to open database: %v", err)
+		}
+		return database
+	})
+}
+
+func TestMetricsRouter(t *testing.T) {
+	resp := httptest.NewRecorder()
+	router.CreateMetricsRouter().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Unexpected metrics status %d", resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), "# TYPE solutions_http_requests_total counter") {
+		t.Fatalf("Metrics are not written: %s", resp.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+package routertest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/router"
+	"git.containerum.net/ch/solutions/pkg/router/middleware"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// testCORS checks preflight requests to every routes group through router with CORS enabled
+func testCORS(t *testing.T, h *Harness) {
+	config := middleware.CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com", "https://web.example.org"},
+		AllowMethods:     []string{http.MethodGet, http.MethodDelete},
+		AllowHeaders:     []string{"Content-Type", "if-match"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+	cors, err := middleware.NewCORS(config)
+	if err != nil {
+		t.Fatalf("Unable to create CORS policy: %v", err)
+	}
+	status := kube_types.ServiceStatus{Name: "solutions", Version: "test"}
+	handler := router.CreateRouter(&h.Service, &status, cors, nil, GitHookSecret, readyTimeout, true)
+
+	preflight := func(origin, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		req.Header.Set("Access-Control-Request-Headers", "Authorization, If-Match, Content-Type")
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for _, path := range []string{"/status", "/static/", "/hooks/git/fixture", "/templates", solutionPath, "/admin/drift"} {
+		for _, origin := range []string{"https://app.example.com", "https://a.b.example.com", "https://web.example.org"} {
+			resp := preflight(origin, path)
+			if resp.Code != http.StatusOK || resp.Header().Get("Access-Control-Allow-Origin") != origin {
+				t.Fatalf("Preflight request from %s to %s is not allowed: %d", origin, path, resp.Code)
+			}
+			if resp.Header().Get("Access-Control-Allow-Credentials") != "true" || resp.Header().Get("Access-Control-Max-Age") != "3600" {
+				t.Fatalf("Unexpected preflight response headers: %v", resp.Header())
+			}
+		}
+		for _, origin := range []string{"https://example.com", "http://app.example.com", "https://evil.org", "https://app.example.com.evil.org"} {
+			if resp := preflight(origin, path); resp.Code != http.StatusForbidden {
+				t.Fatalf("Preflight request from %s to %s is allowed: %d", origin, path, resp.Code)
+			}
+		}
+	}
+
+	config.AllowOrigins = []string{"https://evil.org"}
+	if err := cors.Set(config); err != nil {
+		t.Fatalf("Unable to replace CORS policy: %v", err)
+	}
+	if resp := preflight("https://evil.org", "/templates"); resp.Code != http.StatusOK {
+		t.Fatalf("Replaced CORS policy is not applied: %d", resp.Code)
+	}
+	if resp := preflight("https://app.example.com", "/templates"); resp.Code != http.StatusForbidden {
+		t.Fatalf("Replaced CORS policy is not applied: %d", resp.Code)
+	}
+
+	// origins are required, all origins can't be allowed with credentials
+	for _, origins := range [][]string{nil, {"*"}, {"evil.org"}, {"https://*evil.org"}, {"https://app.*.example.com"}} {
+		config.AllowOrigins = origins
+		if err := cors.Set(config); err == nil {
+			t.Fatalf("Invalid CORS origins %v are accepted", origins)
+		}
+	}
+	config.AllowOrigins = nil
+	config.AllowCredentials = false
+	if err := cors.Set(config); err == nil {
+		t.Fatalf("Empty CORS origins are accepted")
+	}
+	if resp := preflight("https://evil.org", "/templates"); resp.Code != http.StatusOK {
+		t.Fatalf("CORS policy is replaced by invalid one: %d", resp.Code)
+	}
+}
@@ -0,0 +1,269 @@
+// Package routertest runs solutions service behind router.CreateRouter with fake clients,
+// so HTTP API may be tested end-to-end without cluster, GitHub and (with memory DB) postgres:
+//
+//	func TestRoutes(t *testing.T) {
+//		routertest.RunRoutes(t, func(t *testing.T) db.DB {
+//			return memory.NewDB()
+//		})
+//	}
+//
+// Harness may be used directly to test specific scenarios.
+package routertest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/clients/fake"
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/lock"
+	"git.containerum.net/ch/solutions/pkg/router"
+	m "git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/server/impl"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/containerum/cherry"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+)
+
+// Defaults of harness
+const (
+	GitHookSecret = "githook-secret"
+	// FixtureTemplate is a name of template added by AddFixtureTemplate
+	FixtureTemplate = "fixture"
+	// SecretKey encrypts webhook secrets
+	SecretKey = "secret-key"
+
+	readyTimeout = time.Second
+)
+
+// User is an identity which requests are sent on behalf of
+type User struct {
+	ID   string
+	Role string
+	// namespaces available to user, ignored for admins
+	Namespaces []kube_types.UserHeaderData
+}
+
+// Admin returns admin identity
+func Admin() User {
+	return User{ID: "00000000-0000-0000-0000-00000000000a", Role: m.RoleAdmin}
+}
+
+// NamespaceUser returns user identity with access to namespaces
+func NamespaceUser(id string, access kube_types.AccessLevel, namespaces ...string) User {
+	user := User{ID: id, Role: m.RoleUser}
+	for _, ns := range namespaces {
+		user.Namespaces = append(user.Namespaces, kube_types.UserHeaderData{ID: ns, Label: ns, Access: access})
+	}
+	return user
+}
+
+// Headers returns identity headers of user
+func (user User) Headers() http.Header {
+	headers := http.Header{}
+	if user.ID != "" {
+		headers.Set(httputil.UserIDXHeader, user.ID)
+	}
+	if user.Role != "" {
+		headers.Set(httputil.UserRoleXHeader, user.Role)
+	}
+	if user.Role == m.RoleUser {
+		namespaces := user.Namespaces
+		if namespaces == nil {
+			namespaces = []kube_types.UserHeaderData{}
+		}
+		data, _ := json.Marshal(namespaces)
+		headers.Set(httputil.UserNamespacesXHeader, base64.StdEncoding.EncodeToString(data))
+	}
+	return headers
+}
+
+// Harness is a solutions service with fake clients served by router.CreateRouter
+type Harness struct {
+	DB        db.DB
+	Cluster   *fake.Cluster
+	Resource  *fake.ResourceClient
+	KubeAPI   *fake.KubeAPIClient
+	Templates *fake.TemplateSource
+	Webhooks  *fake.WebhookClient
+	Service   server.SolutionsService
+	Handler   http.Handler
+
+	stopJobs context.CancelFunc
+	mu       sync.Mutex
+	requests []request
+}
+
+type request struct {
+	method, path string
+}
+
+// New returns harness using database and cluster with namespaces.
+// Fixture template repository is pushed to template source master branch.
+func New(database db.DB, namespaces ...string) *Harness {
+	cluster := fake.NewCluster(namespaces...)
+	h := &Harness{
+		DB:        database,
+		Cluster:   cluster,
+		Resource:  fake.NewResourceClient(cluster),
+		KubeAPI:   fake.NewKubeAPIClient(cluster),
+		Templates: fake.NewTemplateSource(),
+		Webhooks:  fake.NewWebhookClient(),
+	}
+	h.Templates.PushFixture("master")
+	secrets, _ := utils.NewSecretBox(SecretKey)
+	h.Service = impl.NewSolutionsImpl(server.Services{
+		DB:             h.DB,
+		DownloadClient: h.Templates,
+		ResourceClient: h.Resource,
+		KubeAPIClient:  h.KubeAPI,
+		WebhookClient:  h.Webhooks,
+		Locker:         lock.NewLocker(h.DB, lock.DefaultTTL),
+		Secrets:        secrets,
+		TemplateSource: "https://" + fake.RawFilesHost + "/",
+	})
+	status := kube_types.ServiceStatus{Name: "solutions", Version: "test"}
+	h.Handler = router.CreateRouter(&h.Service, &status, nil, nil, GitHookSecret, readyTimeout, true)
+
+	var jobsCtx context.Context
+	jobsCtx, h.stopJobs = context.WithCancel(context.Background())
+	go h.Service.RunTrackingUpgrades(jobsCtx)
+	return h
+}
+
+// Close stops background jobs of service
+func (h *Harness) Close() {
+	h.stopJobs()
+}
+
+// Response is a recorded router response
+type Response struct {
+	*httptest.ResponseRecorder
+}
+
+// Decode decodes JSON response body to v
+func (resp Response) Decode(v interface{}) error {
+	return json.Unmarshal(resp.Body.Bytes(), v)
+}
+
+// Err returns error from response body or nil if request succeeded
+func (resp Response) Err() *cherry.Err {
+	if resp.Code < http.StatusBadRequest {
+		return nil
+	}
+	var err cherry.Err
+	if json.Unmarshal(resp.Body.Bytes(), &err) != nil {
+		return &cherry.Err{StatusHTTP: resp.Code, Message: resp.Body.String()}
+	}
+	return &err
+}
+
+// Do sends request on behalf of user. Body is encoded to JSON unless it's nil or []byte.
+func (h *Harness) Do(user User, method, path string, body interface{}) Response {
+	return h.DoWithHeaders(user.Headers(), method, path, body)
+}
+
+// DoWithHeaders sends request with headers. Body is encoded to JSON unless it's nil or []byte.
+func (h *Harness) DoWithHeaders(headers http.Header, method, path string, body interface{}) Response {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	h.mu.Lock()
+	h.requests = append(h.requests, request{method: method, path: req.URL.Path})
+	h.mu.Unlock()
+
+	resp := Response{httptest.NewRecorder()}
+	h.Handler.ServeHTTP(resp, req)
+	return resp
+}
+
+// FixtureTemplateRequest returns request to add or update template of fixture repository
+func FixtureTemplateRequest() kube_types.SolutionTemplate {
+	return kube_types.SolutionTemplate{
+		Name:   FixtureTemplate,
+		URL:    fake.FixtureURL,
+		Limits: &kube_types.SolutionLimits{CPU: 100, RAM: 128},
+		Images: []string{fake.FixtureImage},
+	}
+}
+
+// AddFixtureTemplate adds template of fixture repository as admin
+func (h *Harness) AddFixtureTemplate() Response {
+	return h.Do(Admin(), http.MethodPost, "/templates", FixtureTemplateRequest())
+}
+
+// GitHubPush sends signed GitHub push webhook of template branch
+func (h *Harness) GitHubPush(template, repo, branch, commit string) Response {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"ref":        "refs/heads/" + branch,
+		"after":      commit,
+		"repository": map[string]string{"full_name": repo},
+	})
+	mac := hmac.New(sha256.New, []byte(GitHookSecret))
+	mac.Write(payload)
+
+	headers := http.Header{}
+	headers.Set("X-GitHub-Event", "push")
+	headers.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return h.DoWithHeaders(headers, http.MethodPost, "/hooks/git/"+template, payload)
+}
+
+// Requested returns true if request matching route pattern (i.e. "/namespaces/:namespace/solutions") was sent
+func (h *Harness) Requested(method, route string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, req := range h.requests {
+		if req.method == method && matchRoute(route, req.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute returns true if path matches gin route pattern
+func matchRoute(route, path string) bool {
+	routeSegments, pathSegments := strings.Split(route, "/"), strings.Split(path, "/")
+	for i, seg := range routeSegments {
+		if strings.HasPrefix(seg, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if !strings.HasPrefix(seg, ":") && seg != pathSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(pathSegments)
+}
@@ -0,0 +1,214 @@
+package routertest
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/auth"
+	"git.containerum.net/ch/solutions/pkg/router"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// testIssuer is OpenID Connect provider signing tokens with RSA and ECDSA keys
+type testIssuer struct {
+	*httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	// jwksRequests is a number of keys requests
+	jwksRequests int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate ECDSA key: %v", err)
+	}
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	jwks := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+	}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.URL, "jwks_uri": issuer.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&issuer.jwksRequests, 1)
+		json.NewEncoder(w).Encode(jwks)
+	})
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+// token returns token signed with RSA key ("rsa" kid) or ECDSA key ("ec" kid)
+func (issuer *testIssuer) token(t *testing.T, kid string, claims map[string]interface{}) string {
+	return issuer.sign(t, map[string]string{"rsa": "RS256", "ec": "ES256"}[kid], kid, claims)
+}
+
+// sign returns token signed with RS256, PS256 or ES256 with any key ID in header
+func (issuer *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	var err error
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, issuer.rsaKey, crypto.SHA256, digest[:])
+	case "PS256":
+		signature, err = rsa.SignPSS(rand.Reader, issuer.rsaKey, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, issuer.ecKey, digest[:])
+		if err == nil {
+			signature = make([]byte, 64)
+			copy(signature[32-len(r.Bytes()):32], r.Bytes())
+			copy(signature[64-len(s.Bytes()):], s.Bytes())
+		}
+	}
+	if err != nil {
+		t.Fatalf("Unable to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// claims returns valid claims of token issued for solutions service
+func (issuer *testIssuer) claims(userID string, extra map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss": issuer.URL,
+		"aud": []string{"solutions", "other"},
+		"sub": userID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+func bearer(token string, headers http.Header) http.Header {
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set("Authorization", "Bearer "+token)
+	return headers
+}
+
+// testJWT checks that identity is derived from bearer token claims and identity headers are not trusted
+func testJWT(t *testing.T, h *Harness) {
+	issuer := newTestIssuer(t)
+	defer issuer.Close()
+
+	config := auth.Config{
+		Issuer:          issuer.URL,
+		Audience:        "solutions",
+		UserClaim:       "sub",
+		RoleClaim:       "realm_access.roles",
+		NamespacesClaim: "namespaces",
+	}
+	if _, err := auth.NewVerifier(context.Background(), config); err == nil {
+		t.Fatalf("Verifier loads keys by http")
+	}
+	config.Insecure = true
+	verifier, err := auth.NewVerifier(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Unable to create verifier: %v", err)
+	}
+	status := kube_types.ServiceStatus{Name: "solutions", Version: "test"}
+	handler := router.CreateRouter(&h.Service, &status, nil, verifier, GitHookSecret, readyTimeout, true)
+	do := func(headers http.Header, method, path string) Response {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header = headers
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return Response{resp}
+	}
+
+	// identity headers are not accepted without token
+	expectError(t, do(owner.Headers(), http.MethodGet, "/namespaces/"+namespace+"/solutions"), solerrors.ErrInvalidToken())
+	expectError(t, do(Admin().Headers(), http.MethodGet, "/admin/drift"), solerrors.ErrInvalidToken())
+	expectStatus(t, do(http.Header{}, http.MethodGet, "/healthz"), http.StatusOK)
+
+	// namespaces claim as object, forged role header is replaced
+	readerToken := issuer.token(t, "rsa", issuer.claims(reader.ID, map[string]interface{}{
+		"realm_access": map[string]interface{}{"roles": []string{"offline_access"}},
+		"namespaces":   map[string]string{namespace: string(kube_types.Read)},
+	}))
+	expectStatus(t, do(bearer(readerToken, Admin().Headers()), http.MethodGet, "/namespaces/"+namespace+"/solutions"), http.StatusOK)
+	expectError(t, do(bearer(readerToken, Admin().Headers()), http.MethodGet, "/admin/drift"), solerrors.ErrAdminRequired())
+	expectError(t, do(bearer(readerToken, nil), http.MethodPost, "/namespaces/"+namespace+"/solutions"), solerrors.ErrAccessError())
+	expectError(t, do(bearer(readerToken, nil), http.MethodGet, "/namespaces/"+cloneNamespace+"/solutions"), solerrors.ErrSolutionNotExist())
+
+	// namespaces claim in header format, forged namespaces header is replaced
+	ownerToken := issuer.token(t, "ec", issuer.claims(owner.ID, map[string]interface{}{
+		"namespaces": []kube_types.UserHeaderData{{ID: cloneNamespace, Label: cloneNamespace, Access: kube_types.Owner}},
+	}))
+	expectStatus(t, do(bearer(ownerToken, nil), http.MethodGet, "/namespaces/"+cloneNamespace+"/solutions"), http.StatusOK)
+	expectError(t, do(bearer(ownerToken, owner.Headers()), http.MethodGet, "/namespaces/"+namespace+"/solutions"), solerrors.ErrSolutionNotExist())
+
+	adminToken := issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{
+		"realm_access": map[string]interface{}{"roles": []string{"offline_access", "admin"}},
+	}))
+	expectStatus(t, do(bearer(adminToken, nil), http.MethodGet, "/namespaces/"+namespace+"/solutions"), http.StatusOK)
+	pssToken := issuer.sign(t, "PS256", "rsa", issuer.claims(Admin().ID, map[string]interface{}{
+		"realm_access": map[string]interface{}{"roles": []string{"admin"}},
+	}))
+	expectStatus(t, do(bearer(pssToken, nil), http.MethodGet, "/namespaces/"+namespace+"/solutions"), http.StatusOK)
+	// drift report is not collected yet, but admin is authorized to get it
+	expectError(t, do(bearer(adminToken, reader.Headers()), http.MethodGet, "/admin/drift"), solerrors.ErrDriftReportNotExist())
+
+	tampered := []byte(adminToken)
+	tampered[len(tampered)-2] ^= 1
+	for name, token := range map[string]string{
+		"expired":       issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"not yet valid": issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"no expiration": issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"exp": nil})),
+		"huge exp":      issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"exp": 1e300})),
+		"invalid nbf":   issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"nbf": -1e300})),
+		"other issuer":  issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"iss": "https://evil.org"})),
+		"other aud":     issuer.token(t, "rsa", issuer.claims(Admin().ID, map[string]interface{}{"aud": "other"})),
+		"no user":       issuer.token(t, "ec", issuer.claims("", nil)),
+		"unknown key":   issuer.sign(t, "ES256", "rotated", issuer.claims(Admin().ID, nil)),
+		"other alg":     issuer.sign(t, "RS256", "ec", issuer.claims(Admin().ID, nil)),
+		"tampered":      string(tampered),
+		"alg none":      base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + ".",
+		"malformed":     "token",
+	} {
+		if resp := do(bearer(token, nil), http.MethodGet, "/templates"); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("Token %s is accepted: %d", name, resp.Code)
+		}
+	}
+	// keys are reloaded on unknown key at most once per minute
+	if requests := atomic.LoadInt32(&issuer.jwksRequests); requests != 1 {
+		t.Fatalf("Keys are reloaded on unknown key: %d requests", requests)
+	}
+	if resp := do(http.Header{"Authorization": {"Basic " + ownerToken}}, http.MethodGet, "/templates"); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("Basic authorization is accepted: %d", resp.Code)
+	}
+	if _, err := auth.NewVerifier(context.Background(), auth.Config{JWKS: issuer.URL + "/unknown", Insecure: true}); err == nil {
+		t.Fatalf("Verifier is created without keys")
+	}
+}
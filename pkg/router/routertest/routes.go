@@ -0,0 +1,687 @@
+package routertest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/clients/fake"
+	"git.containerum.net/ch/solutions/pkg/db/dbtest"
+	"git.containerum.net/ch/solutions/pkg/lock"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/router/middleware"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/containerum/cherry"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Namespaces and users of routes tests
+const (
+	namespace         = "ns"
+	cloneNamespace    = "ns-clone"
+	importNamespace   = "ns-import"
+	redactedNamespace = "ns-redacted"
+	solution          = "app"
+
+	solutionPath = "/namespaces/" + namespace + "/solutions/" + solution
+
+	// how long to wait for background template push processing
+	pushTimeout = 5 * time.Second
+)
+
+var (
+	owner  = NamespaceUser("00000000-0000-0000-0000-000000000001", kube_types.Owner, namespace, cloneNamespace, importNamespace, redactedNamespace)
+	reader = NamespaceUser("00000000-0000-0000-0000-000000000002", kube_types.Read, namespace)
+)
+
+// RunRoutes runs solutions lifecycle scenario through all routes of router.CreateRouter
+// using database returned by newDB. Database must not contain solutions in test namespaces and "fixture" template.
+// Test fails if any registered route is not requested.
+func RunRoutes(t *testing.T, newDB dbtest.Factory) {
+	h := New(newDB(t), namespace, cloneNamespace, importNamespace, redactedNamespace)
+	defer h.Close()
+
+	for _, step := range []struct {
+		name string
+		run  func(t *testing.T, h *Harness)
+	}{
+		{"Health", testHealth},
+		{"Identity", testIdentity},
+		{"CORS", testCORS},
+		{"JWT", testJWT},
+		{"Templates", testTemplates},
+		{"RunSolution", testRunSolution},
+		{"RunSolutionFailure", testRunSolutionFailure},
+		{"GetSolution", testGetSolution},
+		{"ExportImportClone", testExportImportClone},
+		{"SolutionState", testSolutionState},
+		{"Upgrade", testUpgrade},
+		{"GitHook", testGitHook},
+		{"Drift", testDrift},
+		{"Orphans", testOrphans},
+		{"Webhooks", testWebhooks},
+		{"AdminWebhooks", testAdminWebhooks},
+		{"Audit", testAudit},
+		{"DeleteSolutions", testDeleteSolutions},
+	} {
+		// steps depend on previous ones
+		if !t.Run(step.name, func(t *testing.T) { step.run(t, h) }) {
+			return
+		}
+	}
+
+	if e, ok := h.Handler.(*gin.Engine); ok {
+		for _, route := range e.Routes() {
+			if !h.Requested(route.Method, route.Path) {
+				t.Errorf("Route %s %s is not tested", route.Method, route.Path)
+			}
+		}
+	}
+}
+
+func expectStatus(t *testing.T, resp Response, code int) {
+	t.Helper()
+	if resp.Code != code {
+		t.Fatalf("Expected status %d, got %d: %s", code, resp.Code, resp.Body.String())
+	}
+}
+
+func expectError(t *testing.T, resp Response, expected *cherry.Err) {
+	t.Helper()
+	if err := resp.Err(); err == nil || !cherry.Equals(err, expected) {
+		t.Fatalf("Expected error %v, got %d: %s", expected, resp.Code, resp.Body.String())
+	}
+}
+
+// ifMatch returns identity headers of user with "If-Match" precondition
+func ifMatch(user User, etag string) http.Header {
+	headers := user.Headers()
+	headers.Set("If-Match", etag)
+	return headers
+}
+
+// etag returns entity tag from response, failing test if there is no one
+func etag(t *testing.T, resp Response) string {
+	t.Helper()
+	expectStatus(t, resp, http.StatusOK)
+	tag := resp.Header().Get("ETag")
+	if tag == "" {
+		t.Fatalf("Response has no entity tag")
+	}
+	return tag
+}
+
+func decode(t *testing.T, resp Response, v interface{}) {
+	t.Helper()
+	if err := resp.Decode(v); err != nil {
+		t.Fatalf("Unable to decode response %s: %v", resp.Body.String(), err)
+	}
+}
+
+func solutionDeployment(t *testing.T, h *Harness, ns, solutionName string) kube_types.Deployment {
+	t.Helper()
+	deploys, err := h.Cluster.Deployments(ns, solutionName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deploys) != 1 {
+		t.Fatalf("Expected 1 deployment of solution %s, got %d", solutionName, len(deploys))
+	}
+	return deploys[0]
+}
+
+func findTemplate(templates kube_types.SolutionsTemplatesList, name string) (kube_types.SolutionTemplate, bool) {
+	for _, template := range templates.Solutions {
+		if template.Name == name {
+			return template, true
+		}
+	}
+	return kube_types.SolutionTemplate{}, false
+}
+
+func testHealth(t *testing.T, h *Harness) {
+	for _, path := range []string{"/status", "/healthz", "/readyz", "/metrics"} {
+		expectStatus(t, h.Do(User{}, http.MethodGet, path, nil), http.StatusOK)
+	}
+
+	h.Resource.FailNext("Ping", errors.New("resource-service is down"))
+	var report model.HealthReport
+	resp := h.Do(User{}, http.MethodGet, "/readyz", nil)
+	expectStatus(t, resp, http.StatusServiceUnavailable)
+	decode(t, resp, &report)
+	if report.OK {
+		t.Fatalf("Service is ready without resource-service")
+	}
+
+	// template source outage is reported by readiness without failing it and fails status
+	h.Templates.FailNext("Ping", errors.New("template source is down"))
+	resp = h.Do(User{}, http.MethodGet, "/readyz", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &report)
+	if check := report.Checks[len(report.Checks)-1]; check.Name != "template-source" || check.OK || !check.Optional {
+		t.Fatalf("Unexpected template source check %+v", check)
+	}
+	if calls := h.Templates.CallsTo("Ping"); calls[len(calls)-1].Args[0] != "https://"+fake.RawFilesHost+"/" {
+		t.Fatalf("Unexpected template source ping %+v", calls[len(calls)-1])
+	}
+	h.Templates.FailNext("Ping", errors.New("template source is down"))
+	expectStatus(t, h.Do(User{}, http.MethodGet, "/status", nil), http.StatusInternalServerError)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		if resp := h.Do(owner, method, "/static/", nil); resp.Code >= http.StatusInternalServerError {
+			t.Fatalf("Unable to get static files: %d", resp.Code)
+		}
+	}
+}
+
+func testIdentity(t *testing.T, h *Harness) {
+	expectError(t, h.Do(User{}, http.MethodGet, "/templates", nil), solerrors.ErrRequiredHeadersNotProvided())
+	expectError(t, h.Do(User{ID: owner.ID, Role: "guest"}, http.MethodGet, "/templates", nil), solerrors.ErrInvalidRole())
+	expectError(t, h.Do(owner, http.MethodPost, "/templates", FixtureTemplateRequest()), solerrors.ErrAdminRequired())
+	expectError(t, h.Do(owner, http.MethodGet, "/admin/drift", nil), solerrors.ErrAdminRequired())
+	expectError(t, h.Do(reader, http.MethodPost, "/namespaces/"+namespace+"/solutions", nil), solerrors.ErrAccessError())
+	expectError(t, h.Do(owner, http.MethodGet, "/namespaces/unknown/solutions", nil), solerrors.ErrSolutionNotExist())
+}
+
+func testTemplates(t *testing.T, h *Harness) {
+	expectStatus(t, h.AddFixtureTemplate(), http.StatusCreated)
+	missing := FixtureTemplateRequest()
+	missing.Name, missing.URL = "missing", "https://github.com/containerum/missing"
+	expectStatus(t, h.Do(Admin(), http.MethodPost, "/templates", missing), http.StatusBadRequest)
+
+	var templates kube_types.SolutionsTemplatesList
+	resp := h.Do(owner, http.MethodGet, "/templates", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &templates)
+	fixture, ok := findTemplate(templates, FixtureTemplate)
+	if !ok || len(fixture.Images) != 1 || fixture.Images[0] != fake.FixtureImage {
+		t.Fatalf("Unexpected templates %+v", templates.Solutions)
+	}
+	if _, ok := findTemplate(templates, missing.Name); ok {
+		t.Fatalf("Template with missing repository is added")
+	}
+
+	var env kube_types.SolutionEnv
+	resp = h.Do(owner, http.MethodGet, "/templates/"+FixtureTemplate+"/env", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &env)
+	if env.Env["PORT"] != "8080" {
+		t.Fatalf("Unexpected template env %v", env.Env)
+	}
+
+	var resources kube_types.SolutionResources
+	resp = h.Do(owner, http.MethodGet, "/templates/"+FixtureTemplate+"/resources", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &resources)
+	if resources.Resources[model.KindDeployment] != 1 || resources.Resources[model.KindService] != 1 {
+		t.Fatalf("Unexpected template resources %v", resources.Resources)
+	}
+
+	var template model.Template
+	resp = h.Do(owner, http.MethodGet, "/templates/"+FixtureTemplate, nil)
+	tag := etag(t, resp)
+	decode(t, resp, &template)
+	if template.Name != FixtureTemplate || template.ID != "" {
+		t.Fatalf("Unexpected template %+v", template)
+	}
+
+	// concurrent update is detected by entity tag
+	expectStatus(t, h.DoWithHeaders(ifMatch(Admin(), tag), http.MethodPut, "/templates/"+FixtureTemplate, FixtureTemplateRequest()), http.StatusAccepted)
+	expectError(t, h.DoWithHeaders(ifMatch(Admin(), tag), http.MethodPut, "/templates/"+FixtureTemplate, FixtureTemplateRequest()), solerrors.ErrPreconditionFailed())
+	expectStatus(t, h.Do(Admin(), http.MethodPut, "/templates/"+FixtureTemplate, FixtureTemplateRequest()), http.StatusAccepted)
+
+	expectStatus(t, h.Do(Admin(), http.MethodPost, "/templates/"+FixtureTemplate+"/deactivate", nil), http.StatusAccepted)
+	resp = h.Do(owner, http.MethodGet, "/templates", nil)
+	decode(t, resp, &templates)
+	if _, ok := findTemplate(templates, FixtureTemplate); ok {
+		t.Fatalf("Inactive template is listed for user")
+	}
+	expectStatus(t, h.Do(Admin(), http.MethodPost, "/templates/"+FixtureTemplate+"/activate", nil), http.StatusAccepted)
+}
+
+func testRunSolution(t *testing.T, h *Harness) {
+	var ret kube_types.RunSolutionResponse
+	resp := h.Do(owner, http.MethodPost, "/namespaces/"+namespace+"/solutions", model.RunSolutionRequest{
+		Solution: kube_types.Solution{Name: solution, Template: FixtureTemplate},
+	})
+	expectStatus(t, resp, http.StatusAccepted)
+	decode(t, resp, &ret)
+	if ret.Created != 2 || ret.NotCreated != 0 {
+		t.Fatalf("Unexpected run result %+v", ret)
+	}
+
+	deploy := solutionDeployment(t, h, namespace, solution)
+	if deploy.Containers[0].Image != fake.FixtureImage || deploy.Replicas != 1 || len(deploy.Containers[0].Env[0].Value) != 16 {
+		t.Fatalf("Unexpected deployment %+v", deploy)
+	}
+	services, _ := h.Cluster.Services(namespace, solution)
+	if len(services) != 1 || *services[0].Ports[0].Port != 8080 {
+		t.Fatalf("Unexpected services %+v", services)
+	}
+
+	expectError(t, h.Do(owner, http.MethodPost, "/namespaces/"+namespace+"/solutions", model.RunSolutionRequest{
+		Solution: kube_types.Solution{Name: solution, Template: FixtureTemplate},
+	}), solerrors.ErrResourceAlreadyExists())
+}
+
+func testRunSolutionFailure(t *testing.T, h *Harness) {
+	const broken = "broken"
+	// all resources are failed, so solution is not saved
+	h.Resource.FailNext("CreateDeployment", errors.New("resource-service failed"))
+	h.Resource.FailNext("CreateService", errors.New("resource-service failed"))
+	resp := h.Do(owner, http.MethodPost, "/namespaces/"+cloneNamespace+"/solutions", model.RunSolutionRequest{
+		Solution: kube_types.Solution{Name: broken, Template: FixtureTemplate},
+	})
+	if resp.Code < http.StatusBadRequest {
+		t.Fatalf("Solution without resources is created: %s", resp.Body.String())
+	}
+	expectError(t, h.Do(owner, http.MethodGet, "/namespaces/"+cloneNamespace+"/solutions/"+broken, nil), solerrors.ErrSolutionNotExist())
+}
+
+func testGetSolution(t *testing.T, h *Harness) {
+	for _, path := range []string{"/solutions", "/namespaces/" + namespace + "/solutions"} {
+		var solutions model.SolutionsList
+		resp := h.Do(owner, http.MethodGet, path, nil)
+		expectStatus(t, resp, http.StatusOK)
+		decode(t, resp, &solutions)
+		if len(solutions.Solutions) != 1 || solutions.Solutions[0].Name != solution {
+			t.Fatalf("Unexpected %s solutions %+v", path, solutions.Solutions)
+		}
+	}
+
+	var sol model.Solution
+	resp := h.Do(reader, http.MethodGet, solutionPath, nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &sol)
+	if sol.Template != FixtureTemplate || sol.Status != model.SolutionRunning || sol.Env["PORT"] != "8080" {
+		t.Fatalf("Unexpected solution %+v", sol)
+	}
+
+	var deploys kube_types.DeploymentsList
+	resp = h.Do(reader, http.MethodGet, solutionPath+"/deployments", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &deploys)
+	if len(deploys.Deployments) != 1 {
+		t.Fatalf("Unexpected deployments %+v", deploys.Deployments)
+	}
+
+	var services kube_types.ServicesList
+	resp = h.Do(reader, http.MethodGet, solutionPath+"/services", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &services)
+	if len(services.Services) != 1 {
+		t.Fatalf("Unexpected services %+v", services.Services)
+	}
+
+	var resources model.SolutionResourcesList
+	resp = h.Do(reader, http.MethodGet, solutionPath+"/resources", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &resources)
+	if len(resources.Resources) != 2 || resources.Resources[0].Kind != model.KindDeployment || resources.Resources[1].Kind != model.KindService {
+		t.Fatalf("Unexpected resources %+v", resources.Resources)
+	}
+	for _, res := range resources.Resources {
+		if res.Status != model.ResourceCreated || res.ManifestHash == "" {
+			t.Fatalf("Unexpected resource %+v", res)
+		}
+	}
+
+	var drift model.SolutionDrift
+	resp = h.Do(reader, http.MethodGet, solutionPath+"/drift", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &drift)
+	if drift.IsDrifted() {
+		t.Fatalf("New solution is drifted: %+v", drift)
+	}
+
+	var events model.EventsList
+	resp = h.Do(reader, http.MethodGet, solutionPath+"/events", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &events)
+	// newest first: duplicate run attempt and successful run
+	if len(events.Events) != 2 || events.Events[0].Outcome != model.EventFailure ||
+		events.Events[1].Action != model.EventRunSolution || events.Events[1].Outcome != model.EventSuccess ||
+		events.Events[1].UserID != owner.ID {
+		t.Fatalf("Unexpected solution events %+v", events.Events)
+	}
+}
+
+func testExportImportClone(t *testing.T, h *Harness) {
+	var bundle model.SolutionBundle
+	resp := h.Do(reader, http.MethodGet, solutionPath+"/export?redact=false", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &bundle)
+	commit, _ := h.Templates.Commit(fake.FixtureRepo, "master")
+	if bundle.Template != FixtureTemplate || bundle.Commit != commit || len(bundle.Resources) != 2 {
+		t.Fatalf("Unexpected bundle %+v", bundle)
+	}
+
+	invalid := bundle
+	invalid.Template = ""
+	expectError(t, h.Do(owner, http.MethodPost, "/namespaces/"+importNamespace+"/solutions/import", model.SolutionImportRequest{Bundle: invalid}),
+		solerrors.ErrRequestValidationFailed())
+	expectError(t, h.Do(reader, http.MethodPost, "/namespaces/"+namespace+"/solutions/import", model.SolutionImportRequest{Bundle: bundle}),
+		solerrors.ErrAccessError())
+	expectStatus(t, h.Do(owner, http.MethodPost, "/namespaces/"+importNamespace+"/solutions/import", model.SolutionImportRequest{
+		Bundle: bundle,
+		Name:   "imported",
+	}), http.StatusAccepted)
+	imported := solutionDeployment(t, h, importNamespace, "imported")
+	original := solutionDeployment(t, h, namespace, solution)
+	if imported.Containers[0].Env[0].Value != original.Containers[0].Env[0].Value {
+		t.Fatalf("Imported solution env differs from exported")
+	}
+	expectStatus(t, h.Do(owner, http.MethodPost, "/namespaces/"+importNamespace+"/solutions/other", model.SolutionImportRequest{Bundle: bundle}),
+		http.StatusNotFound)
+
+	// manifests of redacted bundle require values of redacted env
+	var redacted model.SolutionBundle
+	decode(t, h.Do(reader, http.MethodGet, solutionPath+"/export?redact=true", nil), &redacted)
+	if len(redacted.Redacted) != 1 || redacted.Env["PASSWORD"] == original.Containers[0].Env[0].Value {
+		t.Fatalf("Secret env is not redacted: %+v", redacted)
+	}
+	deployOnly := redacted
+	deployOnly.Resources = redacted.Resources[:1]
+	expectError(t, h.Do(owner, http.MethodPost, "/namespaces/"+redactedNamespace+"/solutions/import", model.SolutionImportRequest{Bundle: deployOnly}),
+		solerrors.ErrUnableCreateSolution())
+	var result kube_types.ImportResponse
+	resp = h.Do(owner, http.MethodPost, "/namespaces/"+redactedNamespace+"/solutions/import", model.SolutionImportRequest{
+		Bundle: redacted,
+		Env:    map[string]string{"PASSWORD": "password"},
+	})
+	expectStatus(t, resp, http.StatusAccepted)
+	decode(t, resp, &result)
+	if len(result.Imported) != 1 || len(result.Failed) != 0 {
+		t.Fatalf("Unexpected import result: %+v", result)
+	}
+	if deploy := solutionDeployment(t, h, redactedNamespace, solution); deploy.Containers[0].Env[0].Value != "password" {
+		t.Fatalf("Redacted env is not substituted: %+v", deploy.Containers[0].Env)
+	}
+	expectStatus(t, h.Do(owner, http.MethodDelete, "/namespaces/"+redactedNamespace+"/solutions/"+solution, nil), http.StatusAccepted)
+
+	expectError(t, h.Do(reader, http.MethodPost, solutionPath+"/clone", model.SolutionCloneRequest{Name: "cloned"}), solerrors.ErrAccessError())
+	expectStatus(t, h.Do(owner, http.MethodPost, solutionPath+"/clone", model.SolutionCloneRequest{
+		Name:      "cloned",
+		Namespace: cloneNamespace,
+	}), http.StatusAccepted)
+	solutionDeployment(t, h, cloneNamespace, "cloned")
+}
+
+func testSolutionState(t *testing.T, h *Harness) {
+	// solution locked by other replica isn't modified until lock is released
+	_, unlock, err := lock.NewLocker(h.DB, time.Minute).LockSolution(context.Background(), namespace, solution)
+	if err != nil {
+		t.Fatalf("Unable to lock solution: %v", err)
+	}
+	expectError(t, h.Do(owner, http.MethodPost, solutionPath+"/stop", nil), solerrors.ErrSolutionLocked())
+	unlock()
+
+	// failed scaling reverts solution state
+	h.Resource.FailNext("SetDeploymentReplicas", errors.New("scaling failed"))
+	if resp := h.Do(owner, http.MethodPost, solutionPath+"/stop", nil); resp.Code < http.StatusBadRequest {
+		t.Fatalf("Solution is stopped with failed scaling: %d", resp.Code)
+	}
+	var sol model.Solution
+	decode(t, h.Do(owner, http.MethodGet, solutionPath, nil), &sol)
+	if deploy := solutionDeployment(t, h, namespace, solution); sol.Status != model.SolutionRunning || deploy.Replicas != 1 {
+		t.Fatalf("Solution state is not reverted: %s, %d replicas", sol.Status, deploy.Replicas)
+	}
+
+	expectStatus(t, h.Do(owner, http.MethodPost, solutionPath+"/stop", nil), http.StatusAccepted)
+	if deploy := solutionDeployment(t, h, namespace, solution); deploy.Replicas != 0 {
+		t.Fatalf("Stopped solution has %d replicas", deploy.Replicas)
+	}
+	expectError(t, h.Do(owner, http.MethodPost, solutionPath+"/stop", nil), solerrors.ErrInvalidSolutionState())
+	expectStatus(t, h.Do(owner, http.MethodPost, solutionPath+"/start", nil), http.StatusAccepted)
+	if deploy := solutionDeployment(t, h, namespace, solution); deploy.Replicas != 1 {
+		t.Fatalf("Started solution has %d replicas", deploy.Replicas)
+	}
+
+	tag := etag(t, h.Do(reader, http.MethodGet, solutionPath, nil))
+	expectStatus(t, h.DoWithHeaders(ifMatch(owner, tag), http.MethodPut, solutionPath+"/schedule", model.SolutionSchedule{Start: "0 8 * * 1-5", Stop: "0 20 * * 1-5"}), http.StatusAccepted)
+	expectError(t, h.DoWithHeaders(ifMatch(owner, tag), http.MethodPut, solutionPath+"/schedule", model.SolutionSchedule{}), solerrors.ErrPreconditionFailed())
+	expectError(t, h.Do(owner, http.MethodPut, solutionPath+"/schedule", model.SolutionSchedule{Start: "invalid"}), solerrors.ErrRequestValidationFailed())
+
+	expectStatus(t, h.Do(owner, http.MethodPost, solutionPath+"/extend", model.SolutionExpiry{TTL: "24h"}), http.StatusAccepted)
+	expectStatus(t, h.Do(owner, http.MethodPut, solutionPath+"/track", model.SolutionTracking{TrackBranch: true}), http.StatusAccepted)
+
+	decode(t, h.Do(owner, http.MethodGet, solutionPath, nil), &sol)
+	if sol.StartSchedule != "0 8 * * 1-5" || sol.ExpiresAt == "" || !sol.TrackBranch {
+		t.Fatalf("Solution settings are not saved: %+v", sol)
+	}
+}
+
+func testUpgrade(t *testing.T, h *Harness) {
+	h.Templates.Push(fake.FixtureRepo, "master", fake.FixtureFiles("nginx:1.16"))
+
+	var ret model.UpgradeSolutionResponse
+	resp := h.Do(owner, http.MethodPost, solutionPath+"/upgrade", nil)
+	expectStatus(t, resp, http.StatusAccepted)
+	decode(t, resp, &ret)
+	// service manifest is not changed
+	if ret.Updated != 1 || ret.Unchanged != 1 || ret.Created != 0 || ret.Deleted != 0 || len(ret.Errors) != 0 {
+		t.Fatalf("Unexpected upgrade result %+v", ret)
+	}
+	if deploy := solutionDeployment(t, h, namespace, solution); deploy.Containers[0].Image != "nginx:1.16" {
+		t.Fatalf("Deployment is not upgraded: %s", deploy.Containers[0].Image)
+	}
+}
+
+func testGitHook(t *testing.T, h *Harness) {
+	commit := h.Templates.Push(fake.FixtureRepo, "master", fake.FixtureFiles("nginx:1.17"))
+
+	resp := h.GitHubPush(FixtureTemplate, fake.FixtureRepo, "master", commit)
+	expectStatus(t, resp, http.StatusAccepted)
+	var ret model.TemplatePushResult
+	decode(t, resp, &ret)
+	if !ret.Valid || !ret.ImagesRefreshed || len(ret.Upgrading) != 1 || ret.Upgrading[0] != namespace+"/"+solution {
+		t.Fatalf("Unexpected push result %+v", ret)
+	}
+
+	// tracking solutions are upgraded in background
+	deadline := time.Now().Add(pushTimeout)
+	for solutionDeployment(t, h, namespace, solution).Containers[0].Image != "nginx:1.17" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Tracking solution is not upgraded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	deadline = time.Now().Add(pushTimeout)
+	for {
+		var events model.EventsList
+		decode(t, h.Do(owner, http.MethodGet, solutionPath+"/events?action="+model.EventUpgradeSolution, nil), &events)
+		if len(events.Events) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Tracking solution upgrade is not finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	headers := http.Header{}
+	headers.Set("X-GitHub-Event", "push")
+	headers.Set("X-Hub-Signature-256", "sha256=00")
+	expectError(t, h.DoWithHeaders(headers, http.MethodPost, "/hooks/git/"+FixtureTemplate, []byte(`{}`)), solerrors.ErrInvalidGitHookSignature())
+	expectError(t, h.DoWithHeaders(headers, http.MethodPost, "/hooks/git/"+FixtureTemplate, make([]byte, middleware.MaxGitHookBody+1)), solerrors.ErrRequestTooLarge())
+}
+
+func testDrift(t *testing.T, h *Harness) {
+	expectError(t, h.Do(Admin(), http.MethodGet, "/admin/drift", nil), solerrors.ErrDriftReportNotExist())
+
+	deploy := solutionDeployment(t, h, namespace, solution)
+	deploy.Containers[0].Image = "nginx:manual"
+	if err := h.Cluster.PutDeployment(namespace, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Resource.DeleteServices(context.Background(), namespace, solution); err != nil {
+		t.Fatal(err)
+	}
+
+	var drift model.SolutionDrift
+	decode(t, h.Do(owner, http.MethodGet, solutionPath+"/drift", nil), &drift)
+	if len(drift.Modified) != 1 || len(drift.Missing) != 1 {
+		t.Fatalf("Unexpected solution drift %+v", drift)
+	}
+
+	var report model.SolutionsDriftReport
+	resp := h.Do(Admin(), http.MethodPost, "/admin/drift?heal=true", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &report)
+	// cloned solution does not track template, so it's behind template image
+	if report.Drifted != 2 || len(report.Solutions) != 2 {
+		t.Fatalf("Unexpected drift report %+v", report)
+	}
+	for _, sol := range report.Solutions {
+		if sol.Name == solution && len(sol.Healed) != 1 {
+			t.Fatalf("Unexpected solution drift report %+v", sol)
+		}
+	}
+	if services, _ := h.Cluster.Services(namespace, solution); len(services) != 1 {
+		t.Fatalf("Missing service is not healed")
+	}
+
+	resp = h.Do(Admin(), http.MethodGet, "/admin/drift", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &report)
+	if report.Drifted != 2 {
+		t.Fatalf("Last drift report is not returned: %+v", report)
+	}
+}
+
+func testOrphans(t *testing.T, h *Harness) {
+	if err := h.Cluster.PutDeployment(namespace, kube_types.Deployment{Name: "ghost-web", SolutionID: "ghost", Replicas: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var report model.OrphanResourcesReport
+	resp := h.Do(Admin(), http.MethodGet, "/admin/orphans", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &report)
+	if len(report.Solutions) != 1 || report.Solutions[0].SolutionID != "ghost" {
+		t.Fatalf("Unexpected orphans %+v", report.Solutions)
+	}
+
+	expectStatus(t, h.Do(Admin(), http.MethodDelete, "/admin/orphans", nil), http.StatusAccepted)
+	if deploys, _ := h.Cluster.Deployments(namespace, "ghost"); len(deploys) != 0 {
+		t.Fatalf("Orphan resources are not deleted")
+	}
+}
+
+// testWebhookRoutes creates webhook using routes prefix, triggers event and checks its delivery
+func testWebhookRoutes(t *testing.T, h *Harness, user User, prefix string) {
+	request := model.Webhook{
+		URL:    "http://example.com/hook",
+		Secret: "secret",
+		Events: []string{model.EventStopSolution},
+	}
+	// destination is checked by webhook client
+	h.Webhooks.FailNext("CheckURL", errors.New("destination address 127.0.0.1 is not allowed"))
+	expectError(t, h.Do(user, http.MethodPost, prefix, request), solerrors.ErrRequestValidationFailed())
+
+	var webhook model.Webhook
+	resp := h.Do(user, http.MethodPost, prefix, request)
+	expectStatus(t, resp, http.StatusCreated)
+	decode(t, resp, &webhook)
+	if stored, err := h.DB.GetWebhook(context.Background(), webhook.Namespace, webhook.ID); err != nil || stored.Secret == request.Secret {
+		t.Fatalf("Webhook secret is not encrypted: %v", err)
+	}
+
+	var webhooks model.WebhooksList
+	resp = h.Do(user, http.MethodGet, prefix, nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &webhooks)
+	if len(webhooks.Webhooks) != 1 || webhooks.Webhooks[0].ID != webhook.ID || webhooks.Webhooks[0].Secret != "" {
+		t.Fatalf("Unexpected webhooks %+v", webhooks.Webhooks)
+	}
+
+	expectStatus(t, h.Do(owner, http.MethodPost, solutionPath+"/stop", nil), http.StatusAccepted)
+	expectStatus(t, h.Do(owner, http.MethodPost, solutionPath+"/start", nil), http.StatusAccepted)
+
+	var deliveries model.WebhookDeliveriesList
+	resp = h.Do(user, http.MethodGet, prefix+"/"+webhook.ID+"/deliveries", nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &deliveries)
+	if len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].Action != model.EventStopSolution {
+		t.Fatalf("Unexpected deliveries %+v", deliveries.Deliveries)
+	}
+
+	h.Webhooks.Reset()
+	if err := h.Service.DeliverWebhooks(utils.AdminContext(context.Background()), time.Now(), 3, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	calls := h.Webhooks.CallsTo("SendWebhook")
+	if len(calls) != 1 || calls[0].Args[0] != webhook.URL {
+		t.Fatalf("Unexpected sent webhooks %+v", calls)
+	}
+	mac := hmac.New(sha256.New, []byte(request.Secret))
+	mac.Write(calls[0].Args[2].([]byte))
+	if signature := calls[0].Args[1].(map[string]string)[model.WebhookSignatureHeader]; signature != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("Webhook is signed with invalid secret: %s", signature)
+	}
+
+	expectStatus(t, h.Do(user, http.MethodPost, prefix+"/"+webhook.ID+"/deliveries/"+deliveries.Deliveries[0].ID+"/redeliver", nil), http.StatusAccepted)
+	expectStatus(t, h.Do(user, http.MethodDelete, prefix+"/"+webhook.ID, nil), http.StatusAccepted)
+	expectError(t, h.Do(user, http.MethodDelete, prefix+"/"+webhook.ID, nil), solerrors.ErrWebhookNotExist())
+}
+
+func testWebhooks(t *testing.T, h *Harness) {
+	testWebhookRoutes(t, h, owner, "/namespaces/"+namespace+"/webhooks")
+}
+
+func testAdminWebhooks(t *testing.T, h *Harness) {
+	testWebhookRoutes(t, h, Admin(), "/admin/webhooks")
+}
+
+func testAudit(t *testing.T, h *Harness) {
+	var events model.EventsList
+	resp := h.Do(Admin(), http.MethodGet, "/admin/audit?action="+model.EventAddTemplate, nil)
+	expectStatus(t, resp, http.StatusOK)
+	decode(t, resp, &events)
+	if len(events.Events) != 1 || events.Events[0].Template != FixtureTemplate || events.Events[0].Outcome != model.EventSuccess {
+		t.Fatalf("Unexpected audit events %+v", events.Events)
+	}
+}
+
+func testDeleteSolutions(t *testing.T, h *Harness) {
+	h.Resource.Reset()
+	expectError(t, h.Do(reader, http.MethodDelete, solutionPath, nil), solerrors.ErrAccessError())
+	expectError(t, h.DoWithHeaders(ifMatch(owner, `"stale"`), http.MethodDelete, solutionPath, nil), solerrors.ErrPreconditionFailed())
+	expectStatus(t, h.Do(owner, http.MethodGet, solutionPath, nil), http.StatusOK)
+	expectStatus(t, h.Do(owner, http.MethodDelete, solutionPath, nil), http.StatusAccepted)
+	expectError(t, h.Do(owner, http.MethodGet, solutionPath, nil), solerrors.ErrSolutionNotExist())
+	if deploys, _ := h.Cluster.Deployments(namespace, solution); len(deploys) != 0 {
+		t.Fatalf("Solution deployments are not deleted")
+	}
+	// recorded resources are deleted one by one instead of deleting all resources labelled with solution
+	if calls := h.Resource.CallsTo("DeleteDeployments"); len(calls) != 0 || len(h.Resource.CallsTo("DeleteDeployment")) != 1 {
+		t.Fatalf("Solution resources are deleted by label: %+v", h.Resource.Calls())
+	}
+
+	var ret model.DeleteSolutionsResponse
+	resp := h.Do(owner, http.MethodDelete, "/namespaces/"+cloneNamespace+"/solutions", nil)
+	expectStatus(t, resp, http.StatusAccepted)
+	decode(t, resp, &ret)
+	if ret.Deleted != 1 || ret.NotDeleted != 0 {
+		t.Fatalf("Unexpected namespace solutions deletion result %+v", ret)
+	}
+
+	resp = h.Do(owner, http.MethodDelete, "/solutions", nil)
+	expectStatus(t, resp, http.StatusAccepted)
+	decode(t, resp, &ret)
+	if ret.Deleted != 1 || ret.Solutions[0].Name != "imported" {
+		t.Fatalf("Unexpected user solutions deletion result %+v", ret)
+	}
+	for _, ns := range h.Cluster.Namespaces() {
+		if deploys, _ := h.Cluster.Deployments(ns, ""); len(deploys) != 0 {
+			t.Fatalf("Namespace %s deployments are not deleted: %+v", ns, deploys)
+		}
+	}
+}
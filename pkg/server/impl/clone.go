@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/server"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// randomEnvNames returns names of env variables which solution template fills with random strings
+func randomEnvNames(ctx context.Context, s *serverImpl, solution kube_types.Solution) ([]string, error) {
+	solutionURL, err := url.Parse(solutionTemplateURL(solution))
+	if err != nil {
+		return nil, err
+	}
+
+	solutionConfigFile, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solutionURL.Path[1:], solution.Branch, ".containerum.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	// random values are generated by template functions inside of JSON strings, so config can be parsed as is
+	var solutionConfig server.Solution
+	if err := json.Unmarshal(solutionConfigFile, &solutionConfig); err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for k, v := range solutionConfig.Env {
+		if strings.Contains(v, "rand_string") {
+			ret = append(ret, k)
+		}
+	}
+	return ret, nil
+}
+
+func (s *serverImpl) CloneSolution(ctx context.Context, namespace, solutionName string, req model.SolutionCloneRequest) (*kube_types.RunSolutionResponse, error) {
+	s.logger(ctx).Infof("Cloning solution %s to %s/%s", solutionName, req.Namespace, req.Name)
+	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	clone := kube_types.Solution{
+		Name:      req.Name,
+		Namespace: req.Namespace,
+		Template:  solution.Template,
+		Branch:    solution.Branch,
+		Env:       make(map[string]string, len(solution.Env)),
+	}
+	for k, v := range solution.Env {
+		clone.Env[k] = v
+	}
+
+	if req.RegenerateRandom {
+		names, err := randomEnvNames(ctx, s, solution.Solution)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			delete(clone.Env, name)
+		}
+	}
+
+	return s.RunSolution(ctx, model.RunSolutionRequest{Solution: clone})
+}
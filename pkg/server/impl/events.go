@@ -0,0 +1,48 @@
+package impl
+
+import (
+	"context"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	"github.com/containerum/utils/httputil"
+	"github.com/google/uuid"
+)
+
+// contextValue returns value saved to context by PrepareContext middleware or empty string
+func contextValue(ctx context.Context, key interface{}) string {
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+// recordEvent saves event of state-changing operation, err is an operation result.
+// Event is delivered to subscribed webhooks. Errors are only logged to not affect operation result.
+func (s *serverImpl) recordEvent(ctx context.Context, event model.Event, err error) {
+	// event is recorded even if operation context is cancelled (i.e. client disconnected or solution lock released)
+	ctx = utils.DetachedContext(ctx)
+	event.ID = uuid.New().String()
+	event.UserID = contextValue(ctx, httputil.UserIDContextKey)
+	event.UserRole = contextValue(ctx, httputil.UserRoleContextKey)
+	event.RequestID = contextValue(ctx, httputil.RequestIDContextKey)
+	event.Outcome = model.EventSuccess
+	if err != nil {
+		event.Outcome = model.EventFailure
+		event.Error = err.Error()
+	}
+
+	if err := s.svc.DB.AddEvent(ctx, event); err != nil {
+		s.logger(ctx).WithError(err).Errorf("Unable to save %s event", event.Action)
+		return
+	}
+	if err := s.enqueueWebhooks(ctx, event); err != nil {
+		s.logger(ctx).WithError(err).Errorf("Unable to enqueue %s event webhooks", event.Action)
+	}
+}
+
+func (s *serverImpl) GetEvents(ctx context.Context, filter model.EventsFilter) (*model.EventsList, error) {
+	events, err := s.svc.DB.GetEvents(ctx, filter)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,76 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *serverImpl) ExtendSolution(ctx context.Context, namespace, solutionName string, expiry model.SolutionExpiry) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventExtendSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	s.logger(ctx).Infoln("Extending solution ", solutionName)
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// expiration time is calculated from locked solution, so concurrent extensions are added up
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		solution, err := lockSolution(ctx, tx, namespace, solutionName)
+		if err != nil {
+			return err
+		}
+
+		// ttl is added to current expiration time unless solution already expired
+		from := time.Now().UTC()
+		if solution.ExpiresAt != "" {
+			if current, err := time.Parse(time.RFC3339, solution.ExpiresAt); err == nil && current.After(from) {
+				from = current
+			}
+		}
+		expiresAt, err := expiry.Time(from)
+		if err != nil {
+			return err
+		}
+		return tx.SetSolutionExpiry(ctx, solution.Namespace, solution.Name, expiresAt)
+	})
+	return s.handleDBError(err)
+}
+
+func (s *serverImpl) ExpireSolutions(ctx context.Context, now time.Time, warnBefore time.Duration) error {
+	if warnBefore > 0 {
+		var expiring *model.SolutionsList
+		err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) (err error) {
+			expiring, err = tx.MarkSolutionsExpiryWarned(ctx, now, now.Add(warnBefore))
+			return err
+		})
+		if err := s.handleDBError(err); err != nil {
+			return err
+		}
+		for _, sol := range expiring.Solutions {
+			s.logger(ctx).WithFields(logrus.Fields{
+				"solution":   sol.Name,
+				"namespace":  sol.Namespace,
+				"expires_at": sol.ExpiresAt,
+			}).Warnln("Solution expires soon")
+			s.recordEvent(ctx, model.Event{Action: model.EventSolutionExpiring, Namespace: sol.Namespace, Solution: sol.Name}, nil)
+		}
+	}
+
+	expired, err := s.svc.DB.GetExpiredSolutions(ctx, now)
+	if err := s.handleDBError(err); err != nil {
+		return err
+	}
+	for _, sol := range expired.Solutions {
+		s.logger(ctx).Infof("Solution %s expired at %s", sol.Name, sol.ExpiresAt)
+		if err := s.DeleteSolution(ctx, sol.Namespace, sol.Name); err != nil {
+			s.logger(ctx).WithError(err).Errorf("Unable to delete expired solution %s", sol.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+	"github.com/google/uuid"
+)
+
+const (
+	redactedValue = "<redacted>"
+)
+
+// redactedPlaceholder replaces value of redacted env variable in bundle manifests,
+// so value set on import is substituted
+func redactedPlaceholder(name string) string {
+	return "__redacted_" + name + "__"
+}
+
+// env variables containing any of this substrings are treated as secrets
+var secretEnvMarkers = []string{"PASS", "SECRET", "TOKEN", "KEY"}
+
+func isSecretEnv(name string) bool {
+	name = strings.ToUpper(name)
+	for _, marker := range secretEnvMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveCommit returns commit which solution template branch currently points to
+func resolveCommit(ctx context.Context, s *serverImpl, solutionPath, branch string) (string, error) {
+	commitJSON, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://api.github.com/repos/%s/commits/%s", solutionPath, branch))
+	if err != nil {
+		return "", err
+	}
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.Unmarshal(commitJSON, &commit); err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+func (s *serverImpl) ExportSolution(ctx context.Context, namespace, solutionName string, redact bool) (*model.SolutionBundle, error) {
+	s.logger(ctx).Infoln("Exporting solution ", solutionName)
+	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	ret := model.SolutionBundle{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Name:       solution.Name,
+		Namespace:  solution.Namespace,
+		Template:   solution.Template,
+		URL:        solutionTemplateURL(solution.Solution),
+		Branch:     solution.Branch,
+		Env:        make(map[string]string, len(solution.Env)),
+		Resources:  make([]model.SolutionBundleResource, 0),
+	}
+
+	renderEnv := make(map[string]string, len(solution.Env))
+	for k, v := range solution.Env {
+		renderEnv[k] = v
+		if redact && isSecretEnv(k) {
+			ret.Redacted = append(ret.Redacted, k)
+			renderEnv[k] = redactedPlaceholder(k)
+			v = redactedValue
+		}
+		ret.Env[k] = v
+	}
+	sort.Strings(ret.Redacted)
+
+	solutionURL, err := url.Parse(ret.URL)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Commit, err = resolveCommit(ctx, s, solutionURL.Path[1:], solution.Branch); err != nil {
+		s.logger(ctx).WithError(err).Warnln("Unable to resolve solution commit")
+	}
+
+	// manifests are rendered with placeholders of redacted env to not expose secrets
+	rendered := solution.Solution
+	rendered.Env = renderEnv
+	if ret.Commit != "" {
+		rendered = solutionAtRef(rendered, ret.Commit)
+	}
+	resources, err := renderSolution(ctx, s, rendered)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range resources {
+		ret.Resources = append(ret.Resources, model.SolutionBundleResource{
+			Type:       res.config.Type,
+			ConfigFile: res.config.Name,
+			Name:       res.name,
+			Manifest:   res.manifest.Bytes(),
+		})
+	}
+
+	return &ret, nil
+}
+
+// importTemplate returns template which bundle was exported from.
+// Template is found by URL, so it is found even if it is renamed or deactivated.
+func importTemplate(ctx context.Context, s *serverImpl, bundle model.SolutionBundle) (*kube_types.SolutionTemplate, error) {
+	if bundle.URL == "" {
+		template, err := s.svc.DB.GetTemplate(ctx, bundle.Template)
+		if err := s.handleDBError(err); err != nil {
+			return nil, err
+		}
+		return &template.SolutionTemplate, nil
+	}
+
+	templates, err := s.svc.DB.GetTemplatesList(ctx, true)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	var found *kube_types.SolutionTemplate
+	for i, template := range templates.Solutions {
+		if strings.TrimSuffix(template.URL, "/") != strings.TrimSuffix(bundle.URL, "/") {
+			continue
+		}
+		// template with bundle name is preferred, then active one
+		if found == nil || template.Name == bundle.Template || (found.Name != bundle.Template && template.Active && !found.Active) {
+			found = &templates.Solutions[i]
+		}
+	}
+	if found == nil {
+		return nil, solerrors.ErrTemplateNotExist().AddDetailF("template %s is not added", bundle.URL)
+	}
+	return found, nil
+}
+
+// bundleResource returns resource from bundle manifest with redacted env values substituted
+func bundleResource(res model.SolutionBundleResource, redacted []string, env map[string]string) (*renderedResource, error) {
+	f := server.ConfigFile{Name: res.ConfigFile, Type: res.Type}
+	manifest := []byte(res.Manifest)
+	for _, name := range redacted {
+		placeholder := []byte(redactedPlaceholder(name))
+		if !bytes.Contains(manifest, placeholder) {
+			continue
+		}
+		value, ok := env[name]
+		if !ok {
+			return nil, fmt.Errorf(unableToCreate, f.Type, f.Name, fmt.Sprintf("redacted env %s is not set", name))
+		}
+		// value is substituted in JSON string
+		quoted, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		manifest = bytes.Replace(manifest, placeholder, quoted[1:len(quoted)-1], -1)
+	}
+	return decodeResource(f, *bytes.NewBuffer(manifest))
+}
+
+// ImportSolution creates solution from bundle manifests, so solution is the same as exported one
+// even if template is changed since export.
+func (s *serverImpl) ImportSolution(ctx context.Context, namespace string, req model.SolutionImportRequest) (_ *kube_types.ImportResponse, err error) {
+	solution := req.Solution(namespace)
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventRunSolution, Namespace: solution.Namespace, Solution: solution.Name, Template: solution.Template}, err)
+		if err != nil {
+			metrics.SolutionRuns.Inc(solution.Template, metrics.ResultFailure)
+		} else {
+			metrics.SolutionRuns.Inc(solution.Template, metrics.ResultSuccess)
+		}
+	}()
+	s.logger(ctx).Infof("Importing solution %s from namespace %s", solution.Name, req.Bundle.Namespace)
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, solution.Namespace, solution.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	template, err := importTemplate(ctx, s, req.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	solution.Template = template.Name
+
+	solutionUUID := uuid.New().String()
+	if err := createSolution(ctx, s, &server.Solution{Env: solution.Env}, template.ID, solutionUUID, solution, nil, false); err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	created := 0
+	for _, bundleRes := range req.Bundle.Resources {
+		res, err := bundleResource(bundleRes, req.Bundle.Redacted, req.Env)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if res == nil {
+			errs = append(errs, fmt.Sprintf("Unknown resource type: %v. Skipping.", bundleRes.Type))
+			continue
+		}
+		if err := createResource(ctx, s, *res, solution); err != nil {
+			recordResource(ctx, s, solution.Namespace, solution.Name, res.record(model.ResourceFailed))
+			errs = append(errs, err.Error())
+			continue
+		}
+		recordResource(ctx, s, solution.Namespace, solution.Name, res.record(model.ResourceCreated))
+		created++
+	}
+
+	if created == 0 {
+		rollbackSolution(ctx, s, solution.Name, solution.Namespace, solutionUUID)
+		return nil, solerrors.ErrUnableCreateSolution().AddDetails(errs...)
+	}
+
+	ret := kube_types.ImportResponse{
+		Imported: []kube_types.ImportResult{},
+		Failed:   []kube_types.ImportResult{},
+	}
+	if len(errs) > 0 {
+		ret.ImportFailed(solution.Name, solution.Namespace, strings.Join(errs, "; "))
+	} else {
+		ret.ImportSuccessful(solution.Name, solution.Namespace)
+	}
+	return &ret, nil
+}
@@ -0,0 +1,119 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/containerum/cherry"
+)
+
+func (s *serverImpl) CollectOrphanResources(ctx context.Context, dryRun bool) (*model.OrphanResourcesReport, error) {
+	s.logger(ctx).WithField("dry_run", dryRun).Infoln("Collecting orphan resources")
+	namespaces, err := s.svc.KubeAPIClient.GetNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := model.OrphanResourcesReport{
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		DryRun:    dryRun,
+		Solutions: make([]model.OrphanSolution, 0),
+	}
+
+	found := make(map[string]*model.OrphanSolution)
+	addResource := func(namespace, solutionID, kind, name string) {
+		key := namespace + "/" + solutionID
+		if found[key] == nil {
+			found[key] = &model.OrphanSolution{SolutionID: solutionID, Namespace: namespace}
+		}
+		found[key].Resources = append(found[key].Resources, model.DriftResource{Kind: kind, Name: name, Namespace: namespace, SolutionID: solutionID})
+	}
+
+	for _, ns := range namespaces.Namespaces {
+		deploys, err := s.svc.KubeAPIClient.GetNamespaceDeployments(ctx, ns.ID)
+		if err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("unable to get namespace %s deployments: %v", ns.ID, err))
+			continue
+		}
+		services, err := s.svc.KubeAPIClient.GetNamespaceServices(ctx, ns.ID)
+		if err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("unable to get namespace %s services: %v", ns.ID, err))
+			continue
+		}
+		for _, d := range deploys.Deployments {
+			if d.SolutionID != "" {
+				addResource(ns.ID, d.SolutionID, model.KindDeployment, d.Name)
+			}
+		}
+		for _, svc := range services.Services {
+			if svc.SolutionID != "" {
+				addResource(ns.ID, svc.SolutionID, model.KindService, svc.Name)
+			}
+		}
+	}
+
+	// solutions are fetched after resources,
+	// so resources of solutions created during the scan are never treated as orphans
+	solutions, err := s.svc.DB.GetAllSolutionsList(ctx)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	for _, sol := range solutions.Solutions {
+		delete(found, sol.Namespace+"/"+sol.Name)
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		orphan := *found[key]
+		if !dryRun {
+			deleted, err := s.deleteOrphanResources(ctx, orphan)
+			switch {
+			case err != nil:
+				orphan.Error = err.Error()
+			case !deleted:
+				s.logger(ctx).Infof("Solution %s in namespace %s is created during collection, its resources are kept", orphan.SolutionID, orphan.Namespace)
+				continue
+			default:
+				orphan.Deleted = true
+				ret.Deleted += len(orphan.Resources)
+			}
+		}
+		ret.Found += len(orphan.Resources)
+		ret.Solutions = append(ret.Solutions, orphan)
+	}
+
+	s.logger(ctx).Infof("Orphan resources found: %v, deleted: %v", ret.Found, ret.Deleted)
+	return &ret, nil
+}
+
+// deleteOrphanResources deletes resources of orphan solution holding solution lock.
+// Solution may be created after solutions list was read, so its resources are kept if it exists now.
+func (s *serverImpl) deleteOrphanResources(ctx context.Context, orphan model.OrphanSolution) (deleted bool, err error) {
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, orphan.Namespace, orphan.SolutionID)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	_, err = s.svc.DB.GetSolution(ctx, orphan.Namespace, orphan.SolutionID)
+	if err == nil {
+		return false, nil
+	}
+	if !cherry.Equals(err, solerrors.ErrSolutionNotExist()) {
+		return false, s.handleDBError(err)
+	}
+
+	s.logger(ctx).Infof("Deleting orphan resources of solution %s in namespace %s", orphan.SolutionID, orphan.Namespace)
+	if err := deleteLabeledResources(ctx, s, orphan.Namespace, orphan.SolutionID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
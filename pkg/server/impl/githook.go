@@ -0,0 +1,192 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// templateImages returns images of rendered template deployments
+func templateImages(resources []renderedResource) []string {
+	images := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, res := range resources {
+		if res.deployment == nil {
+			continue
+		}
+		for _, c := range res.deployment.Containers {
+			if !seen[c.Image] {
+				seen[c.Image] = true
+				images = append(images, c.Image)
+			}
+		}
+	}
+	return images
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// maxPendingUpgrades limits count of solutions waiting for upgrade after template pushes
+const maxPendingUpgrades = 1000
+
+// upgradeQueue holds solutions waiting for upgrade to pushed commit.
+// Solution is queued once: repeated pushes replace pending ref, so only the latest commit is applied.
+type upgradeQueue struct {
+	mu      sync.Mutex
+	pending map[string]string // namespace/name -> ref
+	order   []model.Solution
+	wake    chan struct{}
+}
+
+func newUpgradeQueue() *upgradeQueue {
+	return &upgradeQueue{
+		pending: make(map[string]string),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// push queues solution upgrade to ref. Returns false if queue is full.
+func (q *upgradeQueue) push(sol model.Solution, ref string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := sol.Namespace + "/" + sol.Name
+	if _, queued := q.pending[key]; !queued {
+		if len(q.order) >= maxPendingUpgrades {
+			return false
+		}
+		q.order = append(q.order, sol)
+	}
+	q.pending[key] = ref
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop returns next solution and ref to upgrade it to
+func (q *upgradeQueue) pop() (model.Solution, string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.order) == 0 {
+		return model.Solution{}, "", false
+	}
+	sol := q.order[0]
+	q.order = q.order[1:]
+	key := sol.Namespace + "/" + sol.Name
+	ref := q.pending[key]
+	delete(q.pending, key)
+	return sol, ref, true
+}
+
+func (s *serverImpl) RunTrackingUpgrades(ctx context.Context) {
+	ctx = utils.AdminContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.upgrades.wake:
+		}
+		for ctx.Err() == nil {
+			sol, ref, ok := s.upgrades.pop()
+			if !ok {
+				break
+			}
+			s.upgradeTrackingSolution(ctx, sol, ref)
+		}
+	}
+}
+
+// upgradeTrackingSolution upgrades solution tracking pushed branch to pushed commit
+func (s *serverImpl) upgradeTrackingSolution(ctx context.Context, sol model.Solution, ref string) {
+	s.logger(ctx).Infof("Upgrading solution %s tracking template branch", sol.Name)
+	solution, err := s.svc.DB.GetSolution(ctx, sol.Namespace, sol.Name)
+	if err == nil {
+		_, err = upgradeSolution(ctx, s, *solution, ref)
+	}
+	if err != nil {
+		s.logger(ctx).WithError(err).Errorf("Unable to upgrade solution %s", sol.Name)
+	}
+	s.recordEvent(ctx, model.Event{Action: model.EventUpgradeSolution, Namespace: sol.Namespace, Solution: sol.Name}, err)
+}
+
+func (s *serverImpl) RefreshTemplate(ctx context.Context, templateName string, push model.TemplatePush) (*model.TemplatePushResult, error) {
+	s.logger(ctx).Infof("Refreshing template %s after %s push to %s", templateName, push.Provider, push.Branch)
+	template, err := s.svc.DB.GetTemplate(ctx, templateName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	templateURL, err := url.Parse(template.URL)
+	if err != nil {
+		return nil, err
+	}
+	if repo := strings.TrimSuffix(strings.Trim(templateURL.Path, "/"), ".git"); !strings.EqualFold(repo, push.Repository) {
+		return nil, solerrors.ErrRequestValidationFailed().AddDetailsErr(fmt.Errorf("pushed repository %s doesn't match template repository %s", push.Repository, repo))
+	}
+
+	ret := model.TemplatePushResult{
+		Template:  template.Name,
+		Branch:    push.Branch,
+		Commit:    push.Commit,
+		Upgrading: make([]string, 0),
+	}
+	// commit is used when it's known, because branch content may be cached by raw files server
+	ref := push.Branch
+	if push.Commit != "" {
+		ref = push.Commit
+	}
+
+	refreshErr := validateTemplate(ctx, s, template.URL, ref)
+	if refreshErr == nil && push.Branch == defaultBranch {
+		var resources []renderedResource
+		resources, refreshErr = renderSolution(ctx, s, kube_types.Solution{URL: template.URL, Branch: ref})
+		if images := templateImages(resources); refreshErr == nil && !equalStrings(images, template.Images) {
+			template.Images = images
+			refreshErr = s.handleDBError(s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+				return tx.UpdateTemplate(ctx, template.SolutionTemplate)
+			}))
+			ret.ImagesRefreshed = refreshErr == nil
+		}
+	}
+	s.recordEvent(ctx, model.Event{Action: model.EventRefreshTemplate, Template: template.Name}, refreshErr)
+	if refreshErr != nil {
+		s.logger(ctx).WithError(refreshErr).Warnf("Template %s is invalid on %s", template.Name, push.Branch)
+		ret.Errors = append(ret.Errors, refreshErr.Error())
+		return &ret, nil
+	}
+	ret.Valid = true
+
+	tracking, err := s.svc.DB.GetTrackingSolutions(ctx, template.Name, push.Branch)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	for _, sol := range tracking.Solutions {
+		if !s.upgrades.push(sol, ref) {
+			s.logger(ctx).Warnf("Upgrades queue is full, solution %s is not upgraded", sol.Name)
+			ret.Errors = append(ret.Errors, fmt.Sprintf("upgrades queue is full, %s/%s is not upgraded", sol.Namespace, sol.Name))
+			continue
+		}
+		ret.Upgrading = append(ret.Upgrading, sol.Namespace+"/"+sol.Name)
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,42 @@
+package impl
+
+import (
+	"fmt"
+	"testing"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+)
+
+func TestUpgradeQueue(t *testing.T) {
+	q := newUpgradeQueue()
+	app := model.Solution{Name: "app", Namespace: "ns"}
+	web := model.Solution{Name: "web", Namespace: "ns"}
+	q.push(app, "commit1")
+	q.push(web, "commit1")
+	// repeated push replaces pending ref of queued solution
+	q.push(app, "commit2")
+
+	for _, expected := range []struct {
+		name, ref string
+	}{{"app", "commit2"}, {"web", "commit1"}} {
+		sol, ref, ok := q.pop()
+		if !ok || sol.Name != expected.name || ref != expected.ref {
+			t.Fatalf("Expected %s upgrade to %s, got %s to %s", expected.name, expected.ref, sol.Name, ref)
+		}
+	}
+	if _, _, ok := q.pop(); ok {
+		t.Fatalf("Upgrade is queued twice")
+	}
+
+	for i := 0; i < maxPendingUpgrades; i++ {
+		if !q.push(model.Solution{Name: fmt.Sprint(i), Namespace: "ns"}, "commit") {
+			t.Fatalf("Upgrade %d is rejected", i)
+		}
+	}
+	if q.push(app, "commit") {
+		t.Fatalf("Upgrade is queued to full queue")
+	}
+	if !q.push(model.Solution{Name: "0", Namespace: "ns"}, "commit2") {
+		t.Fatalf("Queued upgrade is not replaced in full queue")
+	}
+}
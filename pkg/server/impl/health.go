@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+)
+
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+	// optional check failure is reported but doesn't fail report
+	optional bool
+}
+
+// readinessChecks are checks of dependencies required to serve requests.
+// Template source is external and its outage breaks only solutions runs,
+// so it is optional for readiness to keep replicas in rotation.
+func (s *serverImpl) readinessChecks() []healthCheck {
+	return []healthCheck{
+		{name: "db", check: s.svc.DB.Ping},
+		{name: "kube-api", check: s.svc.KubeAPIClient.Ping},
+		{name: "resource-service", check: s.svc.ResourceClient.Ping},
+		{name: "template-source", check: s.pingTemplateSource, optional: true},
+	}
+}
+
+func (s *serverImpl) pingTemplateSource(ctx context.Context) error {
+	return s.svc.DownloadClient.Ping(ctx, s.svc.TemplateSource+"/")
+}
+
+func (s *serverImpl) CheckReadiness(ctx context.Context, timeout time.Duration) *model.HealthReport {
+	return s.runHealthChecks(ctx, timeout, s.readinessChecks())
+}
+
+// CheckStatus runs readiness checks, all of them are required for status
+func (s *serverImpl) CheckStatus(ctx context.Context, timeout time.Duration) *model.HealthReport {
+	checks := s.readinessChecks()
+	for i := range checks {
+		checks[i].optional = false
+	}
+	return s.runHealthChecks(ctx, timeout, checks)
+}
+
+func (s *serverImpl) runHealthChecks(ctx context.Context, timeout time.Duration, checks []healthCheck) *model.HealthReport {
+	ret := model.HealthReport{
+		OK:     true,
+		Checks: make([]model.HealthCheck, len(checks)),
+	}
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := checks[i].check(checkCtx)
+			ret.Checks[i] = model.HealthCheck{
+				Name:     checks[i].name,
+				OK:       err == nil,
+				Duration: time.Since(start).String(),
+				Optional: checks[i].optional,
+			}
+			if err != nil {
+				s.logger(ctx).WithError(err).Warnf("%s readiness check failed", checks[i].name)
+				ret.Checks[i].Error = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, check := range ret.Checks {
+		ret.OK = ret.OK && (check.OK || check.Optional)
+	}
+	return &ret
+}
@@ -1,32 +1,51 @@
 package impl
 
 import (
+	"context"
 	"io"
 	"reflect"
+	"strings"
+	"sync"
 
 	"errors"
 
 	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
 	"git.containerum.net/ch/solutions/pkg/server"
 	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
 
-	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
 type serverImpl struct {
 	svc server.Services
 	log *logrus.Entry
+
+	driftMu   sync.RWMutex
+	lastDrift *model.SolutionsDriftReport
+
+	upgrades *upgradeQueue
 }
 
 // NewSolutionsImpl returns a main Solutions implementation
 func NewSolutionsImpl(services server.Services) server.SolutionsService {
+	if services.TemplateSource == "" {
+		services.TemplateSource = server.DefaultTemplateSource
+	}
+	services.TemplateSource = strings.TrimSuffix(services.TemplateSource, "/")
 	return &serverImpl{
-		svc: services,
-		log: logrus.WithField("component", "solutions_impl"),
+		svc:      services,
+		log:      logrus.WithField("component", "solutions_impl"),
+		upgrades: newUpgradeQueue(),
 	}
 }
 
+// templateFileURL returns URL of template repository file at git ref on template source
+func (s *serverImpl) templateFileURL(repoPath, ref, file string) string {
+	return s.svc.TemplateSource + "/" + repoPath + "/" + ref + "/" + file
+}
+
 func (s *serverImpl) Close() error {
 	var errs []error
 	sv := reflect.ValueOf(s.svc)
@@ -46,6 +65,11 @@ func (s *serverImpl) Close() error {
 	return errors.New(strerr)
 }
 
+// logger returns service log entry with request-scoped fields
+func (s *serverImpl) logger(ctx context.Context) *logrus.Entry {
+	return utils.LogEntry(ctx, s.log)
+}
+
 func (s *serverImpl) handleDBError(err error) error {
 	switch err {
 	case nil:
@@ -53,15 +77,9 @@ func (s *serverImpl) handleDBError(err error) error {
 	case db.ErrTransactionRollback, db.ErrTransactionCommit, db.ErrTransactionBegin:
 		s.log.WithError(err).Error("db transaction error")
 		return err
+	case db.ErrUniqueViolation:
+		return solerrors.ErrResourceAlreadyExists()
 	default:
-		if pqerr, ok := err.(*pq.Error); ok {
-			switch pqerr.Code {
-			case "23505": //unique_violation
-				return solerrors.ErrResourceAlreadyExists()
-			default:
-				s.log.WithError(pqerr)
-			}
-		}
 		s.log.WithError(err).Error("db error")
 		return err
 	}
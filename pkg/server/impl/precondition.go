@@ -0,0 +1,65 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
+)
+
+// checkPrecondition checks "If-Match" request header against entity tag of modified entity.
+// Requests without "If-Match" header are not checked.
+func checkPrecondition(ctx context.Context, etag string) error {
+	ifMatch := utils.IfMatch(ctx)
+	if ifMatch == "" || model.MatchETag(ifMatch, etag) {
+		return nil
+	}
+	return solerrors.ErrPreconditionFailed().AddDetailsErr(fmt.Errorf("entity tag %s doesn't match %s", etag, ifMatch))
+}
+
+// lockSolution takes solution lock in transaction and checks request precondition against locked solution
+func lockSolution(ctx context.Context, tx db.DB, namespace, solutionName string) (*model.Solution, error) {
+	if err := tx.LockSolution(ctx, namespace, solutionName); err != nil {
+		return nil, err
+	}
+	solution, err := tx.GetSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return nil, err
+	}
+	return solution, checkPrecondition(ctx, solution.ETag)
+}
+
+// lockSameSolution takes solution lock in transaction and checks that solution was not replaced
+// with other one with the same name since solutionID was read
+func lockSameSolution(ctx context.Context, tx db.DB, namespace, solutionName, solutionID string) error {
+	if err := tx.LockSolution(ctx, namespace, solutionName); err != nil {
+		return err
+	}
+	solution, err := tx.GetSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	if solution.ID != solutionID {
+		return solerrors.ErrPreconditionFailed().AddDetailsErr(fmt.Errorf("solution %s was replaced by concurrent request", solutionName))
+	}
+	return nil
+}
+
+// lockTemplate takes template lock in transaction and checks request precondition against locked template.
+// Template is not read without precondition, so inactive templates may be updated as before.
+func lockTemplate(ctx context.Context, tx db.DB, name string) error {
+	if err := tx.LockTemplate(ctx, name); err != nil {
+		return err
+	}
+	if utils.IfMatch(ctx) == "" {
+		return nil
+	}
+	template, err := tx.GetTemplate(ctx, name)
+	if err != nil {
+		return err
+	}
+	return checkPrecondition(ctx, template.ETag)
+}
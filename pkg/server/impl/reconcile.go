@@ -0,0 +1,389 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/server"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+type renderedResource struct {
+	config     server.ConfigFile
+	name       string
+	manifest   bytes.Buffer
+	hash       string
+	deployment *kube_types.Deployment
+	service    *kube_types.Service
+}
+
+// record returns resource record with status
+func (res renderedResource) record(status string) model.SolutionResource {
+	return model.SolutionResource{
+		Kind:         res.config.Type,
+		Name:         res.name,
+		Status:       status,
+		ManifestHash: res.hash,
+	}
+}
+
+// solutionTemplateURL returns template URL without branch suffix added by DB
+func solutionTemplateURL(solution kube_types.Solution) string {
+	return strings.TrimSuffix(solution.URL, "/tree/"+solution.Branch)
+}
+
+// solutionAtRef returns solution copy which renders template at git ref (branch or commit)
+func solutionAtRef(solution kube_types.Solution, ref string) kube_types.Solution {
+	solution.URL = solutionTemplateURL(solution)
+	solution.Branch = ref
+	return solution
+}
+
+// renderSolution renders solution template using stored solution env.
+// Resources of unknown types are skipped in the same way as in RunSolution.
+func renderSolution(ctx context.Context, s *serverImpl, solution kube_types.Solution) ([]renderedResource, error) {
+	solutionURL, err := url.Parse(solutionTemplateURL(solution))
+	if err != nil {
+		return nil, err
+	}
+	solutionPath := solutionURL.Path[1:]
+
+	solutionConfig, err := parseSolutionConfig(ctx, s, solutionPath, solution)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []renderedResource
+	for _, f := range solutionConfig.Run {
+		res, err := renderResource(ctx, s, f, solutionConfig, solutionPath, solution)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			ret = append(ret, *res)
+		}
+	}
+	return ret, nil
+}
+
+// renderResource renders resource template and decodes resource.
+// Returns nil resource if resource type is unknown.
+func renderResource(ctx context.Context, s *serverImpl, f server.ConfigFile, solutionConfig *server.Solution, solutionPath string, solution kube_types.Solution) (*renderedResource, error) {
+	parsedRes, err := parseResource(ctx, s, &f, solutionConfig, solutionPath, solution)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResource(f, *parsedRes)
+}
+
+// decodeResource decodes rendered resource manifest.
+// Returns nil resource if resource type is unknown.
+func decodeResource(f server.ConfigFile, manifest bytes.Buffer) (*renderedResource, error) {
+	res := renderedResource{config: f, manifest: manifest, hash: manifestHash(manifest.Bytes())}
+	switch f.Type {
+	case model.KindDeployment:
+		var deploy kube_types.Deployment
+		if err := json.Unmarshal(manifest.Bytes(), &deploy); err != nil {
+			return nil, fmt.Errorf(unableToCreate, f.Type, f.Name, err)
+		}
+		res.name, res.deployment = deploy.Name, &deploy
+	case model.KindService:
+		var svc kube_types.Service
+		if err := json.Unmarshal(manifest.Bytes(), &svc); err != nil {
+			return nil, fmt.Errorf(unableToCreate, f.Type, f.Name, err)
+		}
+		res.name, res.service = svc.Name, &svc
+	default:
+		return nil, nil
+	}
+	return &res, nil
+}
+
+func deploymentDiff(expected, actual kube_types.Deployment) string {
+	var diffs []string
+	if expected.Replicas != actual.Replicas {
+		diffs = append(diffs, fmt.Sprintf("replicas: expected %v, got %v", expected.Replicas, actual.Replicas))
+	}
+	images := make(map[string]string, len(actual.Containers))
+	for _, c := range actual.Containers {
+		images[c.Name] = c.Image
+	}
+	for _, c := range expected.Containers {
+		image, ok := images[c.Name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("container %s not found", c.Name))
+		case image != c.Image:
+			diffs = append(diffs, fmt.Sprintf("container %s image: expected %s, got %s", c.Name, c.Image, image))
+		}
+	}
+	return strings.Join(diffs, "; ")
+}
+
+func serviceDiff(expected, actual kube_types.Service) string {
+	var diffs []string
+	ports := make(map[string]kube_types.ServicePort, len(actual.Ports))
+	for _, p := range actual.Ports {
+		ports[p.Name] = p
+	}
+	for _, p := range expected.Ports {
+		port, ok := ports[p.Name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("port %s not found", p.Name))
+		case port.TargetPort != p.TargetPort || port.Protocol != p.Protocol:
+			diffs = append(diffs, fmt.Sprintf("port %s: expected %v/%v, got %v/%v", p.Name, p.TargetPort, p.Protocol, port.TargetPort, port.Protocol))
+		case p.Port != nil && (port.Port == nil || *port.Port != *p.Port):
+			diffs = append(diffs, fmt.Sprintf("port %s: external port changed", p.Name))
+		}
+	}
+	return strings.Join(diffs, "; ")
+}
+
+// checkSolutionDrift compares solution template and resources recorded for solution with actual solution resources.
+// If heal is true, missing resources are recreated from stored solution configuration.
+func checkSolutionDrift(ctx context.Context, s *serverImpl, solution model.Solution, deploys []kube_types.Deployment, services []kube_types.Service, heal bool) model.SolutionDrift {
+	ret := model.SolutionDrift{
+		Name:      solution.Name,
+		Namespace: solution.Namespace,
+	}
+
+	expected, err := renderSolution(ctx, s, solution.Solution)
+	if err != nil {
+		ret.Errors = append(ret.Errors, err.Error())
+		return ret
+	}
+
+	resources, err := s.svc.DB.GetSolutionResources(ctx, solution.Namespace, solution.Name)
+	if err := s.handleDBError(err); err != nil {
+		ret.Errors = append(ret.Errors, err.Error())
+		return ret
+	}
+	recorded := resourcesByKey(resources.Resources)
+
+	actualDeploys := make(map[string]kube_types.Deployment, len(deploys))
+	for _, d := range deploys {
+		actualDeploys[d.Name] = d
+	}
+	actualServices := make(map[string]kube_types.Service, len(services))
+	for _, svc := range services {
+		actualServices[svc.Name] = svc
+	}
+
+	for _, res := range expected {
+		driftRes := model.DriftResource{Kind: res.config.Type, Name: res.name}
+		record, isRecorded := recorded[resourceKey(res.config.Type, res.name)]
+		var diffs []string
+		var found bool
+		switch res.config.Type {
+		case model.KindDeployment:
+			var actual kube_types.Deployment
+			if actual, found = actualDeploys[res.name]; found {
+				if solution.Status == model.SolutionStopped {
+					res.deployment.Replicas = 0
+				}
+				diffs = append(diffs, deploymentDiff(*res.deployment, actual))
+				delete(actualDeploys, res.name)
+			}
+		case model.KindService:
+			var actual kube_types.Service
+			if actual, found = actualServices[res.name]; found {
+				diffs = append(diffs, serviceDiff(*res.service, actual))
+				delete(actualServices, res.name)
+			}
+		}
+		if found && isRecorded && record.Status == model.ResourceCreated && record.ManifestHash != res.hash {
+			diffs = append(diffs, "template changed since resource was applied")
+		}
+		diff := joinDiffs(diffs)
+
+		switch {
+		case !found:
+			if isRecorded && record.Status == model.ResourceFailed {
+				driftRes.Details = "resource creation failed"
+			}
+			ret.Missing = append(ret.Missing, driftRes)
+			if heal {
+				if err := recreateResource(ctx, s, res, solution.Solution); err != nil {
+					ret.Errors = append(ret.Errors, err.Error())
+					continue
+				}
+				recordResource(ctx, s, solution.Namespace, solution.Name, res.record(model.ResourceCreated))
+				ret.Healed = append(ret.Healed, model.DriftResource{Kind: res.config.Type, Name: res.name})
+			}
+		case diff != "":
+			driftRes.Details = diff
+			ret.Modified = append(ret.Modified, driftRes)
+		}
+	}
+
+	for name := range actualDeploys {
+		ret.Orphaned = append(ret.Orphaned, orphanedResource(recorded, model.KindDeployment, name))
+	}
+	for name := range actualServices {
+		ret.Orphaned = append(ret.Orphaned, orphanedResource(recorded, model.KindService, name))
+	}
+	sort.Slice(ret.Orphaned, func(i, j int) bool {
+		return ret.Orphaned[i].Kind+ret.Orphaned[i].Name < ret.Orphaned[j].Kind+ret.Orphaned[j].Name
+	})
+
+	return ret
+}
+
+// lockedSolutionDrift checks solution drift healing it under solution lock.
+// Solution locked by other operation is checked without healing.
+func lockedSolutionDrift(ctx context.Context, s *serverImpl, solution model.Solution, deploys []kube_types.Deployment, services []kube_types.Service, heal bool) model.SolutionDrift {
+	if heal {
+		lockCtx, unlock, err := s.svc.Locker.LockSolution(ctx, solution.Namespace, solution.Name)
+		if err != nil {
+			s.logger(ctx).WithError(err).Warnf("Solution %s drift is not healed", solution.Name)
+			return checkSolutionDrift(ctx, s, solution, deploys, services, false)
+		}
+		defer unlock()
+		ctx = lockCtx
+	}
+	return checkSolutionDrift(ctx, s, solution, deploys, services, heal)
+}
+
+func joinDiffs(diffs []string) string {
+	var ret []string
+	for _, diff := range diffs {
+		if diff != "" {
+			ret = append(ret, diff)
+		}
+	}
+	return strings.Join(ret, "; ")
+}
+
+// orphanedResource returns drift of solution resource which is not in template.
+// Resources of solutions run before resources were recorded have no details.
+func orphanedResource(recorded map[string]model.SolutionResource, kind, name string) model.DriftResource {
+	ret := model.DriftResource{Kind: kind, Name: name}
+	switch {
+	case len(recorded) == 0:
+	case recorded[resourceKey(kind, name)].Status == model.ResourceCreated:
+		ret.Details = "resource removed from template"
+	default:
+		ret.Details = "resource was not created by solution"
+	}
+	return ret
+}
+
+func recreateResource(ctx context.Context, s *serverImpl, res renderedResource, solution kube_types.Solution) error {
+	s.logger(ctx).Infof("Recreating %s %s of solution %s", res.config.Type, res.name, solution.Name)
+	return createResource(ctx, s, res, solution)
+}
+
+func (s *serverImpl) ReconcileSolutions(ctx context.Context, heal bool) (*model.SolutionsDriftReport, error) {
+	s.logger(ctx).Infoln("Reconciling solutions")
+	solutions, err := s.svc.DB.GetAllSolutionsList(ctx)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	ret := model.SolutionsDriftReport{
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		Solutions: make([]model.SolutionDrift, 0),
+	}
+
+	nsSolutions := make(map[string][]model.Solution)
+	for _, sol := range solutions.Solutions {
+		nsSolutions[sol.Namespace] = append(nsSolutions[sol.Namespace], sol)
+	}
+	namespaces := make([]string, 0, len(nsSolutions))
+	for ns := range nsSolutions {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		nsDeploys, err := s.svc.KubeAPIClient.GetNamespaceDeployments(ctx, ns)
+		if err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("unable to get namespace %s deployments: %v", ns, err))
+			continue
+		}
+		nsServices, err := s.svc.KubeAPIClient.GetNamespaceServices(ctx, ns)
+		if err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("unable to get namespace %s services: %v", ns, err))
+			continue
+		}
+
+		deploys := make(map[string][]kube_types.Deployment)
+		for _, d := range nsDeploys.Deployments {
+			if d.SolutionID != "" {
+				deploys[d.SolutionID] = append(deploys[d.SolutionID], d)
+			}
+		}
+		services := make(map[string][]kube_types.Service)
+		for _, svc := range nsServices.Services {
+			if svc.SolutionID != "" {
+				services[svc.SolutionID] = append(services[svc.SolutionID], svc)
+			}
+		}
+
+		for _, sol := range nsSolutions[ns] {
+			ret.Checked++
+			drift := lockedSolutionDrift(ctx, s, sol, deploys[sol.Name], services[sol.Name], heal)
+			delete(deploys, sol.Name)
+			delete(services, sol.Name)
+			if drift.IsDrifted() {
+				ret.Drifted++
+				ret.Solutions = append(ret.Solutions, drift)
+			}
+		}
+
+		for solutionID, solDeploys := range deploys {
+			for _, d := range solDeploys {
+				ret.Orphaned = append(ret.Orphaned, model.DriftResource{Kind: model.KindDeployment, Name: d.Name, Namespace: ns, SolutionID: solutionID})
+			}
+		}
+		for solutionID, solServices := range services {
+			for _, svc := range solServices {
+				ret.Orphaned = append(ret.Orphaned, model.DriftResource{Kind: model.KindService, Name: svc.Name, Namespace: ns, SolutionID: solutionID})
+			}
+		}
+	}
+
+	s.driftMu.Lock()
+	s.lastDrift = &ret
+	s.driftMu.Unlock()
+
+	s.logger(ctx).Infof("Solutions reconciled: %v checked, %v drifted, %v orphaned resources", ret.Checked, ret.Drifted, len(ret.Orphaned))
+	return &ret, nil
+}
+
+func (s *serverImpl) GetSolutionsDrift(ctx context.Context) (*model.SolutionsDriftReport, error) {
+	s.driftMu.RLock()
+	defer s.driftMu.RUnlock()
+	if s.lastDrift == nil {
+		return nil, solerrors.ErrDriftReportNotExist()
+	}
+	return s.lastDrift, nil
+}
+
+func (s *serverImpl) GetSolutionDrift(ctx context.Context, namespace, solutionName string) (*model.SolutionDrift, error) {
+	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	deploys, err := s.svc.KubeAPIClient.GetUserDeployments(ctx, solution.Namespace, solution.Name)
+	if err != nil {
+		return nil, err
+	}
+	services, err := s.svc.KubeAPIClient.GetUserServices(ctx, solution.Namespace, solution.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := checkSolutionDrift(ctx, s, *solution, deploys.Deployments, services.Services, false)
+	return &drift, nil
+}
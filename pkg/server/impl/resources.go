@@ -0,0 +1,139 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"github.com/containerum/cherry"
+	kube_types "github.com/containerum/kube-client/pkg/model"
+)
+
+// manifestHash returns hex encoded SHA-256 hash of rendered resource manifest.
+// JSON manifest is hashed in canonical form, so manifest reformatted in transfer (i.e. in imported bundle)
+// has the same hash as rendered one.
+func manifestHash(manifest []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(manifest))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err == nil {
+		if canonical, err := json.Marshal(value); err == nil {
+			manifest = canonical
+		}
+	}
+	sum := sha256.Sum256(manifest)
+	return hex.EncodeToString(sum[:])
+}
+
+func resourceKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+func resourcesByKey(resources []model.SolutionResource) map[string]model.SolutionResource {
+	ret := make(map[string]model.SolutionResource, len(resources))
+	for _, res := range resources {
+		ret[resourceKey(res.Kind, res.Name)] = res
+	}
+	return ret
+}
+
+// recordResource saves solution resource record.
+// Resource is already created or deleted at this point, so error is only logged.
+func recordResource(ctx context.Context, s *serverImpl, namespace, solutionName string, resource model.SolutionResource) {
+	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.SaveSolutionResource(ctx, namespace, solutionName, resource)
+	}); err != nil {
+		s.logger(ctx).WithError(s.handleDBError(err)).Errorf("Unable to record %s %s of solution %s", resource.Kind, resource.Name, solutionName)
+	}
+}
+
+// solutionResources returns resources recorded for solution in creation order.
+// Solutions run before resources were recorded have no records,
+// so their resources labelled with solution name are recorded as created.
+func solutionResources(ctx context.Context, s *serverImpl, namespace, solutionName string) ([]model.SolutionResource, error) {
+	resources, err := s.svc.DB.GetSolutionResources(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	if len(resources.Resources) > 0 {
+		return resources.Resources, nil
+	}
+
+	deploys, err := s.svc.KubeAPIClient.GetUserDeployments(ctx, namespace, solutionName)
+	if err != nil {
+		return nil, err
+	}
+	services, err := s.svc.KubeAPIClient.GetUserServices(ctx, namespace, solutionName)
+	if err != nil {
+		return nil, err
+	}
+	if len(deploys.Deployments)+len(services.Services) == 0 {
+		return nil, nil
+	}
+
+	s.logger(ctx).Infof("Recording labelled resources of solution %s", solutionName)
+	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		for _, d := range deploys.Deployments {
+			if err := tx.SaveSolutionResource(ctx, namespace, solutionName, model.SolutionResource{Kind: model.KindDeployment, Name: d.Name, Status: model.ResourceCreated}); err != nil {
+				return err
+			}
+		}
+		for _, svc := range services.Services {
+			if err := tx.SaveSolutionResource(ctx, namespace, solutionName, model.SolutionResource{Kind: model.KindService, Name: svc.Name, Status: model.ResourceCreated}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, s.handleDBError(err)
+	}
+
+	resources, err = s.svc.DB.GetSolutionResources(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	return resources.Resources, nil
+}
+
+func createResource(ctx context.Context, s *serverImpl, res renderedResource, solution kube_types.Solution) error {
+	switch res.config.Type {
+	case model.KindDeployment:
+		return createDeployment(ctx, s, &res.config, solution.Name, solution.Namespace, res.manifest)
+	case model.KindService:
+		return createService(ctx, s, &res.config, solution.Name, solution.Namespace, res.manifest)
+	}
+	return nil
+}
+
+// deleteResource deletes recorded solution resource.
+// Resource which is already deleted from cluster is not treated as error.
+func deleteResource(ctx context.Context, s *serverImpl, namespace string, resource model.SolutionResource) error {
+	var err error
+	switch resource.Kind {
+	case model.KindDeployment:
+		err = s.svc.ResourceClient.DeleteDeployment(ctx, namespace, resource.Name)
+	case model.KindService:
+		err = s.svc.ResourceClient.DeleteService(ctx, namespace, resource.Name)
+	default:
+		return fmt.Errorf("unable to delete %s %s: unknown resource type", resource.Kind, resource.Name)
+	}
+	if cherr, ok := err.(*cherry.Err); ok && cherr.StatusHTTP == http.StatusNotFound {
+		s.logger(ctx).Debugf("%s %s is already deleted", resource.Kind, resource.Name)
+		return nil
+	}
+	return err
+}
+
+func (s *serverImpl) GetSolutionResources(ctx context.Context, namespace, solutionName string) (*model.SolutionResourcesList, error) {
+	resp, err := s.svc.DB.GetSolutionResources(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -3,26 +3,33 @@ package impl
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/url"
+	"sync"
+	"time"
 
 	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/model"
 	"git.containerum.net/ch/solutions/pkg/server"
 	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/tracing"
 	"git.containerum.net/ch/solutions/pkg/utils"
 	kube_types "github.com/containerum/kube-client/pkg/model"
 	"github.com/containerum/utils/httputil"
 	"github.com/google/uuid"
-	"github.com/json-iterator/go"
 )
 
 const (
 	unableToCreate = "unable to create %s %s: %s"
+
+	bulkDeleteConcurrency = 4
 )
 
 func parseSolutionConfig(ctx context.Context, s *serverImpl, solutionPath string, solutionReq kube_types.Solution) (*server.Solution, error) {
-	solutionConfigFile, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/.containerum.json", solutionPath, solutionReq.Branch))
+	solutionConfigFile, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solutionPath, solutionReq.Branch, ".containerum.json"))
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +48,7 @@ func parseSolutionConfig(ctx context.Context, s *serverImpl, solutionPath string
 	}
 
 	var solutionConfig *server.Solution
-	if err = jsoniter.Unmarshal(solutionBuf.Bytes(), &solutionConfig); err != nil {
+	if err = json.Unmarshal(solutionBuf.Bytes(), &solutionConfig); err != nil {
 		return nil, err
 	}
 
@@ -55,41 +62,55 @@ func parseSolutionConfig(ctx context.Context, s *serverImpl, solutionPath string
 	return solutionConfig, nil
 }
 
-func createSolution(ctx context.Context, s *serverImpl, solutionConfig *server.Solution, templateID, solutionUUID string, solutionReq kube_types.Solution) error {
-	solutionEnvironments, err := jsoniter.MarshalToString(solutionConfig.Env)
+func createSolution(ctx context.Context, s *serverImpl, solutionConfig *server.Solution, templateID, solutionUUID string, solutionReq kube_types.Solution, expiresAt *time.Time, trackBranch bool) error {
+	solutionEnvironments, err := json.Marshal(solutionConfig.Env)
 	if err != nil {
 		return err
 	}
 
-	s.log.Debugln("Creating solution")
+	s.logger(ctx).Debugln("Creating solution")
 	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-		return tx.AddSolution(ctx, solutionReq, httputil.MustGetUserID(ctx), templateID, solutionUUID, solutionEnvironments)
+		if err := tx.LockSolution(ctx, solutionReq.Namespace, solutionReq.Name); err != nil {
+			return err
+		}
+		if err := tx.AddSolution(ctx, solutionReq, httputil.MustGetUserID(ctx), templateID, solutionUUID, string(solutionEnvironments), expiresAt); err != nil {
+			return err
+		}
+		if trackBranch {
+			return tx.SetSolutionTrackBranch(ctx, solutionReq.Namespace, solutionReq.Name, true)
+		}
+		return nil
 	}); err != nil {
 		return s.handleDBError(err)
 	}
 	return nil
 }
 
-func parseResource(ctx context.Context, s *serverImpl, resourceConfig *server.ConfigFile, solutionConfig *server.Solution, solutionPath string, solutionReq kube_types.Solution) (*bytes.Buffer, error) {
-	s.log.Infof("Creating %s %s", resourceConfig.Type, resourceConfig.Name)
-	s.log.Debugln("Downloading resource")
-	resF, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", solutionPath, solutionReq.Branch, resourceConfig.Name))
+func parseResource(ctx context.Context, s *serverImpl, resourceConfig *server.ConfigFile, solutionConfig *server.Solution, solutionPath string, solutionReq kube_types.Solution) (_ *bytes.Buffer, err error) {
+	ctx, span := tracing.StartSpan(ctx, "render resource", tracing.SpanKindInternal)
+	span.SetAttribute("resource.type", resourceConfig.Type)
+	span.SetAttribute("resource.name", resourceConfig.Name)
+	defer func() { span.Finish(err) }()
+
+	s.logger(ctx).Infof("Creating %s %s", resourceConfig.Type, resourceConfig.Name)
+	s.logger(ctx).Debugln("Downloading resource")
+	resF, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solutionPath, solutionReq.Branch, resourceConfig.Name))
 	if err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
 		return nil, fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 
-	s.log.Debugln("Setting envs to resource config")
+	s.logger(ctx).Debugln("Setting envs to resource config")
 	resTmpl, err := template.New("res").Parse(string(resF))
 	if err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
 		return nil, fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 
 	var resParsed bytes.Buffer
 	err = resTmpl.Execute(&resParsed, solutionConfig.Env)
 	if err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
 		return nil, fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 	return &resParsed, nil
@@ -97,14 +118,15 @@ func parseResource(ctx context.Context, s *serverImpl, resourceConfig *server.Co
 
 func createDeployment(ctx context.Context, s *serverImpl, resourceConfig *server.ConfigFile, solutionName, solutionNamespace string, parsedRes bytes.Buffer) error {
 	var parsedDeploy kube_types.Deployment
-	err := jsoniter.Unmarshal(parsedRes.Bytes(), &parsedDeploy)
+	err := json.Unmarshal(parsedRes.Bytes(), &parsedDeploy)
 	if err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
 		return fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 	parsedDeploy.SolutionID = solutionName
 	if err = s.svc.ResourceClient.CreateDeployment(ctx, solutionNamespace, parsedDeploy); err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
+		metrics.ResourceCreateFailures.Inc(resourceConfig.Type)
 		return fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 	return err
@@ -112,32 +134,61 @@ func createDeployment(ctx context.Context, s *serverImpl, resourceConfig *server
 
 func createService(ctx context.Context, s *serverImpl, resourceConfig *server.ConfigFile, solutionName, solutionNamespace string, parsedRes bytes.Buffer) error {
 	var parsedService kube_types.Service
-	err := jsoniter.Unmarshal(parsedRes.Bytes(), &parsedService)
+	err := json.Unmarshal(parsedRes.Bytes(), &parsedService)
 	if err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
 		return fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 	parsedService.SolutionID = solutionName
 	if err = s.svc.ResourceClient.CreateService(ctx, solutionNamespace, parsedService); err != nil {
-		s.log.Debugln(err)
+		s.logger(ctx).Debugln(err)
+		metrics.ResourceCreateFailures.Inc(resourceConfig.Type)
 		return fmt.Errorf(unableToCreate, resourceConfig.Type, resourceConfig.Name, err)
 	}
 	return err
 }
 
-func rollbackSolution(ctx context.Context, s *serverImpl, solutionName, solutionNamespace string) {
-	s.log.Infoln("No resources was created. Deleting solution...")
+// rollbackSolution deletes solution created by request.
+// Solution with the same name created by concurrent request after deletion of this one is kept.
+func rollbackSolution(ctx context.Context, s *serverImpl, solutionName, solutionNamespace, solutionUUID string) {
+	s.logger(ctx).Infoln("No resources was created. Deleting solution...")
 	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-		err := tx.CompletelyDeleteSolution(ctx, solutionNamespace, solutionName)
-		return err
+		if err := lockSameSolution(ctx, tx, solutionNamespace, solutionName, solutionUUID); err != nil {
+			return err
+		}
+		return tx.CompletelyDeleteSolution(ctx, solutionNamespace, solutionName)
 	}); err != nil {
-		s.log.Errorln(err)
+		s.logger(ctx).Errorln(err)
 	}
 }
 
-func (s *serverImpl) RunSolution(ctx context.Context, solutionReq kube_types.Solution) (*kube_types.RunSolutionResponse, error) {
-	s.log.Infoln("Running solution ", solutionReq.Name)
-	s.log.Debugln("Getting template info from DB")
+func (s *serverImpl) RunSolution(ctx context.Context, runReq model.RunSolutionRequest) (_ *kube_types.RunSolutionResponse, err error) {
+	solutionReq := runReq.Solution
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventRunSolution, Namespace: solutionReq.Namespace, Solution: solutionReq.Name, Template: solutionReq.Template}, err)
+		if err != nil {
+			metrics.SolutionRuns.Inc(solutionReq.Template, metrics.ResultFailure)
+		} else {
+			metrics.SolutionRuns.Inc(solutionReq.Template, metrics.ResultSuccess)
+		}
+	}()
+	s.logger(ctx).Infoln("Running solution ", solutionReq.Name)
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, solutionReq.Namespace, solutionReq.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var expiresAt *time.Time
+	if runReq.IsSet() {
+		expiry, err := runReq.Time(time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		expiresAt = &expiry
+	}
+
+	s.logger(ctx).Debugln("Getting template info from DB")
 	solutionTemplate, err := s.svc.DB.GetTemplate(ctx, solutionReq.Template)
 	if err = s.handleDBError(err); err != nil {
 		return nil, err
@@ -150,7 +201,7 @@ func (s *serverImpl) RunSolution(ctx context.Context, solutionReq kube_types.Sol
 
 	solutionPath := solutionURL.Path[1:]
 
-	s.log.Debugln("Parsing solution config")
+	s.logger(ctx).Debugln("Parsing solution config")
 	solutionConfig, err := parseSolutionConfig(ctx, s, solutionPath, solutionReq)
 	if err != nil {
 		return nil, err
@@ -158,7 +209,7 @@ func (s *serverImpl) RunSolution(ctx context.Context, solutionReq kube_types.Sol
 
 	solutionUUID := uuid.New().String()
 
-	err = createSolution(ctx, s, solutionConfig, solutionTemplate.ID, solutionUUID, solutionReq)
+	err = createSolution(ctx, s, solutionConfig, solutionTemplate.ID, solutionUUID, solutionReq, expiresAt, runReq.TrackBranch)
 	if err != nil {
 		return nil, err
 	}
@@ -169,69 +220,103 @@ func (s *serverImpl) RunSolution(ctx context.Context, solutionReq kube_types.Sol
 		NotCreated: 0,
 	}
 
-	s.log.Debugln("Creating solution resources")
+	s.logger(ctx).Debugln("Creating solution resources")
 	for _, f := range solutionConfig.Run {
-		parsedRes, err := parseResource(ctx, s, &f, solutionConfig, solutionPath, solutionReq)
+		res, err := renderResource(ctx, s, f, solutionConfig, solutionPath, solutionReq)
 		if err != nil {
 			ret.Errors = append(ret.Errors, fmt.Sprintf(unableToCreate, f.Type, f.Name, err))
 			continue
 		}
-		switch f.Type {
-		case "deployment":
-			if err := createDeployment(ctx, s, &f, solutionReq.Name, solutionReq.Namespace, *parsedRes); err != nil {
-				ret.Errors = append(ret.Errors, fmt.Sprintf(unableToCreate, f.Type, f.Name, err))
-				continue
-			}
-		case "service":
-			if err := createService(ctx, s, &f, solutionReq.Name, solutionReq.Namespace, *parsedRes); err != nil {
-				ret.Errors = append(ret.Errors, fmt.Sprintf(unableToCreate, f.Type, f.Name, err))
-				continue
-			}
-		default:
+		if res == nil {
 			ret.Errors = append(ret.Errors, fmt.Sprintf("Unknown resource type: %v. Skipping.", f.Type))
 			continue
 		}
+		if err := createResource(ctx, s, *res, solutionReq); err != nil {
+			recordResource(ctx, s, solutionReq.Namespace, solutionReq.Name, res.record(model.ResourceFailed))
+			ret.Errors = append(ret.Errors, fmt.Sprintf(unableToCreate, f.Type, f.Name, err))
+			continue
+		}
+		recordResource(ctx, s, solutionReq.Namespace, solutionReq.Name, res.record(model.ResourceCreated))
 		ret.Created++
 	}
 
 	if ret.Created == 0 {
-		rollbackSolution(ctx, s, solutionReq.Name, solutionReq.Namespace)
+		rollbackSolution(ctx, s, solutionReq.Name, solutionReq.Namespace, solutionUUID)
 		return nil, solerrors.ErrUnableCreateSolution().AddDetails(ret.Errors...)
 	}
 
 	ret.NotCreated = len(ret.Errors)
 
-	s.log.Infoln("Solution has been created")
+	s.logger(ctx).Infoln("Solution has been created")
 	return &ret, nil
 }
 
-func (s *serverImpl) DeleteSolution(ctx context.Context, namespace, solutionName string) error {
-	s.log.Infoln("Deleting solution ", solutionName)
+// deleteLabeledResources deletes all deployments and services labelled with solution name
+func deleteLabeledResources(ctx context.Context, s *serverImpl, namespace, solutionName string) error {
+	if err := s.svc.ResourceClient.DeleteDeployments(ctx, namespace, solutionName); err != nil {
+		return err
+	}
+	return s.svc.ResourceClient.DeleteServices(ctx, namespace, solutionName)
+}
+
+// deleteSolutionResources deletes resources recorded as created for solution in reverse creation order
+func deleteSolutionResources(ctx context.Context, s *serverImpl, namespace, solutionName string) error {
+	resources, err := solutionResources(ctx, s, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		if res.Status != model.ResourceCreated {
+			continue
+		}
+		if err := deleteResource(ctx, s, namespace, res); err != nil {
+			return err
+		}
+		res.Status = model.ResourceDeleted
+		recordResource(ctx, s, namespace, solutionName, res)
+	}
+	return nil
+}
+
+func (s *serverImpl) DeleteSolution(ctx context.Context, namespace, solutionName string) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventDeleteSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	s.logger(ctx).Infoln("Deleting solution ", solutionName)
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
 	if err := s.handleDBError(err); err != nil {
 		return err
 	}
-
-	if err := s.svc.ResourceClient.DeleteDeployments(ctx, solution.Namespace, solution.Name); err != nil {
+	// precondition is checked before resources deletion because it can't be rolled back
+	if err := checkPrecondition(ctx, solution.ETag); err != nil {
 		return err
 	}
 
-	if err := s.svc.ResourceClient.DeleteServices(ctx, solution.Namespace, solution.Name); err != nil {
+	if err := deleteSolutionResources(ctx, s, solution.Namespace, solution.Name); err != nil {
 		return err
 	}
 
-	s.log.Debugln("Deleting solution")
+	s.logger(ctx).Debugln("Deleting solution")
 	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := lockSameSolution(ctx, tx, solution.Namespace, solution.Name, solution.ID); err != nil {
+			return err
+		}
 		return tx.DeleteSolution(ctx, solution.Namespace, solution.Name)
 	}); err != nil {
 		return s.handleDBError(err)
 	}
 
-	s.log.Debugln("Solution deleted")
+	s.logger(ctx).Debugln("Solution deleted")
 	return nil
 }
 
-func (s *serverImpl) GetSolutionsList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsList, error) {
+func (s *serverImpl) GetSolutionsList(ctx context.Context, isAdmin bool) (*model.SolutionsList, error) {
 	resp, err := s.svc.DB.GetSolutionsList(ctx, httputil.MustGetUserID(ctx))
 	if err != nil {
 		return nil, err
@@ -246,7 +331,7 @@ func (s *serverImpl) GetSolutionsList(ctx context.Context, isAdmin bool) (*kube_
 	return resp, nil
 }
 
-func (s *serverImpl) GetNamespaceSolutionsList(ctx context.Context, namespace string, isAdmin bool) (*kube_types.SolutionsList, error) {
+func (s *serverImpl) GetNamespaceSolutionsList(ctx context.Context, namespace string, isAdmin bool) (*model.SolutionsList, error) {
 	resp, err := s.svc.DB.GetNamespaceSolutionsList(ctx, namespace)
 	if err != nil {
 		return nil, err
@@ -261,7 +346,7 @@ func (s *serverImpl) GetNamespaceSolutionsList(ctx context.Context, namespace st
 	return resp, nil
 }
 
-func (s *serverImpl) GetSolution(ctx context.Context, namespace, solutionName string, isAdmin bool) (*kube_types.Solution, error) {
+func (s *serverImpl) GetSolution(ctx context.Context, namespace, solutionName string, isAdmin bool) (*model.Solution, error) {
 	resp, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
 	if err != nil {
 		return nil, err
@@ -302,24 +387,90 @@ func (s *serverImpl) GetSolutionServices(ctx context.Context, namespace, solutio
 	return usersvc, nil
 }
 
-func (s *serverImpl) DeleteSolutions(ctx context.Context) error {
-	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-		return tx.CompletelyDeleteSolutions(ctx, httputil.MustGetUserID(ctx))
-	}); err != nil {
-		return s.handleDBError(err)
+// deleteSolutions deletes resources of every solution using at most bulkDeleteConcurrency parallel workers.
+// Solution is removed from DB only if its resources were deleted.
+func deleteSolutions(ctx context.Context, s *serverImpl, solutions []model.Solution) (*model.DeleteSolutionsResponse, error) {
+	errs := make([]error, len(solutions))
+	sem := make(chan struct{}, bulkDeleteConcurrency)
+	var wg sync.WaitGroup
+dispatch:
+	for i := range solutions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// remaining solutions are not deleted and reported as failed
+			for j := i; j < len(solutions); j++ {
+				errs[j] = ctx.Err()
+			}
+			break dispatch
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			sol := solutions[i]
+			defer func() {
+				s.recordEvent(ctx, model.Event{Action: model.EventDeleteSolution, Namespace: sol.Namespace, Solution: sol.Name, Template: sol.Template}, errs[i])
+			}()
+			s.logger(ctx).Infoln("Deleting solution ", sol.Name)
+			ctx, unlock, err := s.svc.Locker.LockSolution(ctx, sol.Namespace, sol.Name)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer unlock()
+			if err := deleteSolutionResources(ctx, s, sol.Namespace, sol.Name); err != nil {
+				s.logger(ctx).WithError(err).Warnf("Unable to delete solution %s resources", sol.Name)
+				errs[i] = err
+				return
+			}
+			if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+				if err := lockSameSolution(ctx, tx, sol.Namespace, sol.Name, sol.ID); err != nil {
+					return err
+				}
+				return tx.CompletelyDeleteSolution(ctx, sol.Namespace, sol.Name)
+			}); err != nil {
+				errs[i] = s.handleDBError(err)
+			}
+		}(i)
 	}
+	wg.Wait()
 
-	s.log.Debugln("Solutions deleted")
-	return nil
+	ret := model.DeleteSolutionsResponse{
+		Solutions: make([]model.DeleteSolutionResult, 0, len(solutions)),
+	}
+	for i, sol := range solutions {
+		if errs[i] != nil {
+			ret.DeleteFailed(sol.Name, sol.Namespace, errs[i])
+			continue
+		}
+		ret.DeleteSuccessful(sol.Name, sol.Namespace)
+	}
+
+	if ret.Deleted == 0 && ret.NotDeleted > 0 {
+		return nil, solerrors.ErrUnableDeleteSolution().AddDetails(ret.Errors()...)
+	}
+
+	s.logger(ctx).Debugf("Solutions deleted: %v, not deleted: %v", ret.Deleted, ret.NotDeleted)
+	return &ret, nil
 }
 
-func (s *serverImpl) DeleteNamespaceSolutions(ctx context.Context, namespace string) error {
-	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-		return tx.CompletelyDeleteNamespaceSolutions(ctx, namespace)
-	}); err != nil {
-		return s.handleDBError(err)
+func (s *serverImpl) DeleteSolutions(ctx context.Context) (*model.DeleteSolutionsResponse, error) {
+	solutions, err := s.svc.DB.GetSolutionsList(ctx, httputil.MustGetUserID(ctx))
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
 	}
 
-	s.log.Debugln("Solutions deleted")
-	return nil
+	return deleteSolutions(ctx, s, solutions.Solutions)
+}
+
+func (s *serverImpl) DeleteNamespaceSolutions(ctx context.Context, namespace string) (*model.DeleteSolutionsResponse, error) {
+	solutions, err := s.svc.DB.GetNamespaceSolutionsList(ctx, namespace)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	return deleteSolutions(ctx, s, solutions.Solutions)
 }
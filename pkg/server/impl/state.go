@@ -0,0 +1,200 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/utils"
+)
+
+func (s *serverImpl) StopSolution(ctx context.Context, namespace, solutionName string) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventStopSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	s.logger(ctx).Infoln("Stopping solution ", solutionName)
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return err
+	}
+
+	deploys, err := s.svc.KubeAPIClient.GetUserDeployments(ctx, solution.Namespace, solution.Name)
+	if err != nil {
+		return err
+	}
+
+	replicas := make(map[string]int, len(deploys.Deployments))
+	for _, d := range deploys.Deployments {
+		replicas[d.Name] = d.Replicas
+	}
+
+	// state is committed before scaling, so transaction doesn't wait for resource service.
+	// Solution lock is held until the end, so state is reverted if scaling failed.
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if _, err := lockSolution(ctx, tx, solution.Namespace, solution.Name); err != nil {
+			return err
+		}
+		return tx.StopSolution(ctx, solution.Namespace, solution.Name, replicas)
+	})
+	if err != nil {
+		return s.handleDBError(err)
+	}
+
+	if err := s.scaleDeployments(ctx, solution.Namespace, scaledTo(replicas, 0), replicas); err != nil {
+		s.revertSolutionState(ctx, solution.Namespace, solution.Name, func(ctx context.Context, tx db.DB) error {
+			_, err := tx.StartSolution(ctx, solution.Namespace, solution.Name)
+			return err
+		})
+		return err
+	}
+
+	s.logger(ctx).Debugln("Solution stopped")
+	return nil
+}
+
+func (s *serverImpl) StartSolution(ctx context.Context, namespace, solutionName string) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventStartSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	s.logger(ctx).Infoln("Starting solution ", solutionName)
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return err
+	}
+
+	var replicas map[string]int
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if _, err := lockSolution(ctx, tx, solution.Namespace, solution.Name); err != nil {
+			return err
+		}
+		replicas, err = tx.StartSolution(ctx, solution.Namespace, solution.Name)
+		return err
+	})
+	if err != nil {
+		return s.handleDBError(err)
+	}
+
+	if err := s.scaleDeployments(ctx, solution.Namespace, replicas, scaledTo(replicas, 0)); err != nil {
+		s.revertSolutionState(ctx, solution.Namespace, solution.Name, func(ctx context.Context, tx db.DB) error {
+			return tx.StopSolution(ctx, solution.Namespace, solution.Name, replicas)
+		})
+		return err
+	}
+
+	s.logger(ctx).Debugln("Solution started")
+	return nil
+}
+
+// scaledTo returns replicas of the same deployments set to count
+func scaledTo(replicas map[string]int, count int) map[string]int {
+	ret := make(map[string]int, len(replicas))
+	for deploy := range replicas {
+		ret[deploy] = count
+	}
+	return ret
+}
+
+// scaleDeployments sets deployments replicas to "to". If scaling of some deployment failed,
+// already scaled deployments are set back to "from" replicas, so solution isn't left partially scaled.
+func (s *serverImpl) scaleDeployments(ctx context.Context, namespace string, to, from map[string]int) error {
+	var scaled []string
+	for deploy, count := range to {
+		if err := s.svc.ResourceClient.SetDeploymentReplicas(ctx, namespace, deploy, count); err != nil {
+			s.logger(ctx).WithError(err).Errorf("Unable to scale deployment %s", deploy)
+			for _, d := range scaled {
+				if rerr := s.svc.ResourceClient.SetDeploymentReplicas(ctx, namespace, d, from[d]); rerr != nil {
+					s.logger(ctx).WithError(rerr).Errorf("Unable to restore deployment %s replicas", d)
+				}
+			}
+			return err
+		}
+		scaled = append(scaled, deploy)
+	}
+	return nil
+}
+
+// revertSolutionState restores solution state committed before failed scaling
+func (s *serverImpl) revertSolutionState(ctx context.Context, namespace, solutionName string, revert func(ctx context.Context, tx db.DB) error) {
+	err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if _, err := lockSolution(ctx, tx, namespace, solutionName); err != nil {
+			return err
+		}
+		return revert(ctx, tx)
+	})
+	if err != nil {
+		s.logger(ctx).WithError(err).Errorf("Unable to revert solution %s state", solutionName)
+	}
+}
+
+func (s *serverImpl) SetSolutionSchedule(ctx context.Context, namespace, solutionName string, schedule model.SolutionSchedule) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventScheduleSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if _, err := lockSolution(ctx, tx, namespace, solutionName); err != nil {
+			return err
+		}
+		return tx.SetSolutionSchedule(ctx, namespace, solutionName, schedule)
+	})
+	return s.handleDBError(err)
+}
+
+// scheduledStatus returns status solution should have according to its schedule
+// if start or stop was scheduled in (since, now]
+func scheduledStatus(solution model.Solution, since, now time.Time) (string, bool) {
+	var lastStart, lastStop time.Time
+	var started, stopped bool
+	if cron, err := utils.ParseCron(solution.StartSchedule); err == nil {
+		lastStart, started = cron.LastBetween(since, now)
+	}
+	if cron, err := utils.ParseCron(solution.StopSchedule); err == nil {
+		lastStop, stopped = cron.LastBetween(since, now)
+	}
+	switch {
+	case started && (!stopped || lastStart.After(lastStop)):
+		return model.SolutionRunning, true
+	case stopped:
+		return model.SolutionStopped, true
+	}
+	return "", false
+}
+
+func (s *serverImpl) ApplySolutionsSchedule(ctx context.Context, since, now time.Time) error {
+	solutions, err := s.svc.DB.GetAllSolutionsList(ctx)
+	if err := s.handleDBError(err); err != nil {
+		return err
+	}
+
+	for _, sol := range solutions.Solutions {
+		status, ok := scheduledStatus(sol, since, now)
+		if !ok || status == sol.Status {
+			continue
+		}
+		switch status {
+		case model.SolutionRunning:
+			err = s.StartSolution(ctx, sol.Namespace, sol.Name)
+		case model.SolutionStopped:
+			err = s.StopSolution(ctx, sol.Namespace, sol.Name)
+		}
+		if err != nil {
+			s.logger(ctx).WithError(err).Errorf("Unable to apply solution %s schedule", sol.Name)
+		}
+	}
+	return nil
+}
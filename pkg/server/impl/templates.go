@@ -2,15 +2,18 @@ package impl
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"net/url"
 
 	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
 	"git.containerum.net/ch/solutions/pkg/server"
 	kube_types "github.com/containerum/kube-client/pkg/model"
-	"github.com/json-iterator/go"
 )
 
+// defaultBranch is a template branch used when branch is not specified
+const defaultBranch = "master"
+
 func (s *serverImpl) GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsTemplatesList, error) {
 	resp, err := s.svc.DB.GetTemplatesList(ctx, false)
 	if err := s.handleDBError(err); err != nil {
@@ -27,6 +30,20 @@ func (s *serverImpl) GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_
 	return resp, nil
 }
 
+func (s *serverImpl) GetTemplate(ctx context.Context, name string, isAdmin bool) (*model.Template, error) {
+	resp, err := s.svc.DB.GetTemplate(ctx, name)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		resp.ID = ""
+		resp.Active = false
+	}
+
+	return resp, nil
+}
+
 func (s *serverImpl) GetTemplatesEnvList(ctx context.Context, name string, branch string) (*kube_types.SolutionEnv, error) {
 	solution, err := s.svc.DB.GetTemplate(ctx, name)
 	if err := s.handleDBError(err); err != nil {
@@ -38,14 +55,14 @@ func (s *serverImpl) GetTemplatesEnvList(ctx context.Context, name string, branc
 		return nil, err
 	}
 
-	solutionJSON, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/.containerum.json", solurl.Path[1:], branch))
+	solutionJSON, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solurl.Path[1:], branch, ".containerum.json"))
 	if err != nil {
 		return nil, err
 	}
 
 	var solutionStr server.Solution
 
-	if err = jsoniter.Unmarshal(solutionJSON, &solutionStr); err != nil {
+	if err = json.Unmarshal(solutionJSON, &solutionStr); err != nil {
 		return nil, err
 	}
 
@@ -65,13 +82,13 @@ func (s *serverImpl) GetTemplatesResourcesList(ctx context.Context, name string,
 		return nil, err
 	}
 
-	solutionJSON, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/.containerum.json", solurl.Path[1:], branch))
+	solutionJSON, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solurl.Path[1:], branch, ".containerum.json"))
 	if err != nil {
 		return nil, err
 	}
 
 	var solutionStr server.Solution
-	err = jsoniter.Unmarshal(solutionJSON, &solutionStr)
+	err = json.Unmarshal(solutionJSON, &solutionStr)
 	if err != nil {
 		return nil, err
 	}
@@ -85,18 +102,20 @@ func (s *serverImpl) GetTemplatesResourcesList(ctx context.Context, name string,
 	return &resp, nil
 }
 
-func (s *serverImpl) AddTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
-	var err error
+func (s *serverImpl) AddTemplate(ctx context.Context, solution kube_types.SolutionTemplate) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventAddTemplate, Template: solution.Name}, err)
+	}()
 	oldTmpl, err := s.svc.DB.GetTemplate(ctx, solution.Name)
 	if err == nil {
 		if !oldTmpl.Active {
 			if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-				return s.svc.DB.UpdateTemplate(ctx, solution)
+				return tx.UpdateTemplate(ctx, solution)
 			}); err != nil {
 				return s.handleDBError(err)
 			}
 			if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-				return s.svc.DB.ActivateTemplate(ctx, solution.Name)
+				return tx.ActivateTemplate(ctx, solution.Name)
 			}); err != nil {
 				return s.handleDBError(err)
 			}
@@ -104,53 +123,70 @@ func (s *serverImpl) AddTemplate(ctx context.Context, solution kube_types.Soluti
 		}
 	}
 	if err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
-		return s.svc.DB.CreateTemplate(ctx, solution)
+		return tx.CreateTemplate(ctx, solution)
 	}); err != nil {
 		return s.handleDBError(err)
 	}
 	return nil
 }
 
-func (s *serverImpl) UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
-	err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+func (s *serverImpl) UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventUpdateTemplate, Template: solution.Name}, err)
+	}()
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := lockTemplate(ctx, tx, solution.Name); err != nil {
+			return err
+		}
 		return tx.UpdateTemplate(ctx, solution)
 	})
 	return s.handleDBError(err)
 }
 
-func (s *serverImpl) ActivateTemplate(ctx context.Context, solution string) error {
-	err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+func (s *serverImpl) ActivateTemplate(ctx context.Context, solution string) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventActivateTemplate, Template: solution}, err)
+	}()
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
 		return tx.ActivateTemplate(ctx, solution)
 	})
 	return s.handleDBError(err)
 }
 
-func (s *serverImpl) DeactivateTemplate(ctx context.Context, solution string) error {
-	err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+func (s *serverImpl) DeactivateTemplate(ctx context.Context, solution string) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventDeactivateTemplate, Template: solution}, err)
+	}()
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
 		return tx.DeactivateTemplate(ctx, solution)
 	})
 	return s.handleDBError(err)
 }
 
 func (s *serverImpl) ValidateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error {
-	solurl, err := url.Parse(solution.URL)
+	return validateTemplate(ctx, s, solution.URL, defaultBranch)
+}
+
+// validateTemplate checks that template config and all its resources exist at git ref (branch or commit)
+func validateTemplate(ctx context.Context, s *serverImpl, templateURL, ref string) error {
+	solurl, err := url.Parse(templateURL)
 	if err != nil {
 		return err
 	}
 
-	solutionJSON, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/master/.containerum.json", solurl.Path[1:]))
+	solutionJSON, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solurl.Path[1:], ref, ".containerum.json"))
 	if err != nil {
 		return err
 	}
 
 	var solutionStr server.Solution
-	err = jsoniter.Unmarshal(solutionJSON, &solutionStr)
+	err = json.Unmarshal(solutionJSON, &solutionStr)
 	if err != nil {
 		return err
 	}
 
 	for _, r := range solutionStr.Run {
-		if _, err := s.svc.DownloadClient.DownloadFile(ctx, fmt.Sprintf("https://raw.githubusercontent.com/%s/master/%s", solurl.Path[1:], r.Name)); err != nil {
+		if _, err := s.svc.DownloadClient.DownloadFile(ctx, s.templateFileURL(solurl.Path[1:], ref, r.Name)); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,131 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/metrics"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+)
+
+// upgradeSolution applies solution template rendered at git ref to solution resources.
+// Resources which manifests differ from recorded ones are updated, missing ones are created.
+// Resources removed from template are deleted.
+func upgradeSolution(ctx context.Context, s *serverImpl, solution model.Solution, ref string) (*model.UpgradeSolutionResponse, error) {
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, solution.Namespace, solution.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	expected, err := renderSolution(ctx, s, solutionAtRef(solution.Solution, ref))
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := solutionResources(ctx, s, solution.Namespace, solution.Name)
+	if err != nil {
+		return nil, err
+	}
+	recorded := resourcesByKey(resources)
+
+	ret := model.UpgradeSolutionResponse{
+		Errors: []string{},
+	}
+	rendered := make(map[string]bool, len(expected))
+	for _, res := range expected {
+		key := resourceKey(res.config.Type, res.name)
+		rendered[key] = true
+		record := recorded[key]
+		exists := record.Status == model.ResourceCreated
+		if exists && record.ManifestHash == res.hash {
+			ret.Unchanged++
+			continue
+		}
+
+		switch res.config.Type {
+		case model.KindDeployment:
+			res.deployment.SolutionID = solution.Name
+			if solution.Status == model.SolutionStopped {
+				res.deployment.Replicas = 0
+			}
+			if exists {
+				err = s.svc.ResourceClient.UpdateDeployment(ctx, solution.Namespace, *res.deployment)
+			} else {
+				err = s.svc.ResourceClient.CreateDeployment(ctx, solution.Namespace, *res.deployment)
+			}
+		case model.KindService:
+			res.service.SolutionID = solution.Name
+			if exists {
+				err = s.svc.ResourceClient.UpdateService(ctx, solution.Namespace, *res.service)
+			} else {
+				err = s.svc.ResourceClient.CreateService(ctx, solution.Namespace, *res.service)
+			}
+		}
+		switch {
+		case err != nil:
+			if !exists {
+				metrics.ResourceCreateFailures.Inc(res.config.Type)
+				recordResource(ctx, s, solution.Namespace, solution.Name, res.record(model.ResourceFailed))
+			}
+			ret.Errors = append(ret.Errors, fmt.Sprintf("unable to upgrade %s %s: %v", res.config.Type, res.name, err))
+		case exists:
+			recordResource(ctx, s, solution.Namespace, solution.Name, res.record(model.ResourceCreated))
+			ret.Updated++
+		default:
+			recordResource(ctx, s, solution.Namespace, solution.Name, res.record(model.ResourceCreated))
+			ret.Created++
+		}
+	}
+
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		if res.Status != model.ResourceCreated || rendered[resourceKey(res.Kind, res.Name)] {
+			continue
+		}
+		if err := deleteResource(ctx, s, solution.Namespace, res); err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("unable to delete %s %s: %v", res.Kind, res.Name, err))
+			continue
+		}
+		res.Status = model.ResourceDeleted
+		recordResource(ctx, s, solution.Namespace, solution.Name, res)
+		ret.Deleted++
+	}
+
+	if ret.Updated+ret.Created+ret.Deleted+ret.Unchanged == 0 && len(ret.Errors) > 0 {
+		return nil, solerrors.ErrUnableUpgradeSolution().AddDetails(ret.Errors...)
+	}
+	return &ret, nil
+}
+
+func (s *serverImpl) UpgradeSolution(ctx context.Context, namespace, solutionName string) (_ *model.UpgradeSolutionResponse, err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventUpgradeSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	s.logger(ctx).Infoln("Upgrading solution ", solutionName)
+	solution, err := s.svc.DB.GetSolution(ctx, namespace, solutionName)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	return upgradeSolution(ctx, s, *solution, solution.Branch)
+}
+
+func (s *serverImpl) SetSolutionTracking(ctx context.Context, namespace, solutionName string, tracking model.SolutionTracking) (err error) {
+	defer func() {
+		s.recordEvent(ctx, model.Event{Action: model.EventTrackSolution, Namespace: namespace, Solution: solutionName}, err)
+	}()
+	ctx, unlock, err := s.svc.Locker.LockSolution(ctx, namespace, solutionName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		if _, err := lockSolution(ctx, tx, namespace, solutionName); err != nil {
+			return err
+		}
+		return tx.SetSolutionTrackBranch(ctx, namespace, solutionName, tracking.TrackBranch)
+	})
+	return s.handleDBError(err)
+}
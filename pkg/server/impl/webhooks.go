@@ -0,0 +1,187 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookDeliveriesLimit = 100
+	webhookDeliveryLease   = 5 * time.Minute
+	maxWebhookBackoff      = time.Hour
+)
+
+// signWebhookPayload returns payload signature in "sha256=<hex HMAC-SHA256>" format
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns delay before next delivery attempt
+func webhookBackoff(backoff time.Duration, attempts int) time.Duration {
+	for i := 1; i < attempts && backoff < maxWebhookBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxWebhookBackoff {
+		return maxWebhookBackoff
+	}
+	return backoff
+}
+
+// enqueueWebhooks creates deliveries of event for all subscribed webhooks.
+// Payload is signed at this point, so redeliveries send exactly the same request.
+func (s *serverImpl) enqueueWebhooks(ctx context.Context, event model.Event) error {
+	webhooks, err := s.svc.DB.GetEventWebhooks(ctx, event.Namespace)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks.Webhooks {
+		if !webhook.Matches(event) {
+			continue
+		}
+		secret, err := s.svc.Secrets.Open(webhook.Secret)
+		if err != nil {
+			s.logger(ctx).WithError(err).Errorf("Unable to sign webhook %s delivery", webhook.ID)
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		if err := s.svc.DB.AddWebhookDelivery(ctx, model.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			Action:    event.Action,
+			URL:       webhook.URL,
+			Payload:   payload,
+			Signature: signWebhookPayload(secret, payload),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *serverImpl) CreateWebhook(ctx context.Context, namespace string, webhook model.Webhook) (*model.Webhook, error) {
+	s.logger(ctx).Infoln("Creating webhook in namespace ", namespace)
+	if err := s.svc.WebhookClient.CheckURL(ctx, webhook.URL); err != nil {
+		return nil, solerrors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
+	webhook.ID = uuid.New().String()
+	webhook.Namespace = namespace
+	stored := webhook
+	var err error
+	if stored.Secret, err = s.svc.Secrets.Seal(webhook.Secret); err != nil {
+		return nil, err
+	}
+	err = s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.CreateWebhook(ctx, stored)
+	})
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return &webhook, nil
+}
+
+func (s *serverImpl) GetWebhooksList(ctx context.Context, namespace string) (*model.WebhooksList, error) {
+	webhooks, err := s.svc.DB.GetWebhooksList(ctx, namespace)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks.Webhooks {
+		webhooks.Webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *serverImpl) DeleteWebhook(ctx context.Context, namespace, webhookID string) error {
+	if _, err := uuid.Parse(webhookID); err != nil {
+		return solerrors.ErrWebhookNotExist()
+	}
+	err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.DeleteWebhook(ctx, namespace, webhookID)
+	})
+	return s.handleDBError(err)
+}
+
+func (s *serverImpl) GetWebhookDeliveries(ctx context.Context, namespace, webhookID string) (*model.WebhookDeliveriesList, error) {
+	if _, err := uuid.Parse(webhookID); err != nil {
+		return nil, solerrors.ErrWebhookNotExist()
+	}
+	if _, err := s.svc.DB.GetWebhook(ctx, namespace, webhookID); err != nil {
+		return nil, s.handleDBError(err)
+	}
+
+	deliveries, err := s.svc.DB.GetWebhookDeliveries(ctx, webhookID, webhookDeliveriesLimit)
+	if err := s.handleDBError(err); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (s *serverImpl) RedeliverWebhook(ctx context.Context, namespace, webhookID, deliveryID string) error {
+	if _, err := uuid.Parse(webhookID); err != nil {
+		return solerrors.ErrWebhookNotExist()
+	}
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		return solerrors.ErrWebhookDeliveryNotExist()
+	}
+	if _, err := s.svc.DB.GetWebhook(ctx, namespace, webhookID); err != nil {
+		return s.handleDBError(err)
+	}
+
+	err := s.svc.DB.Transactional(ctx, func(ctx context.Context, tx db.DB) error {
+		return tx.RedeliverWebhook(ctx, webhookID, deliveryID)
+	})
+	return s.handleDBError(err)
+}
+
+func (s *serverImpl) DeliverWebhooks(ctx context.Context, now time.Time, maxAttempts int, backoff time.Duration) error {
+	deliveries, err := s.svc.DB.ClaimWebhookDeliveries(ctx, now, webhookDeliveryLease, webhookDeliveriesLimit)
+	if err := s.handleDBError(err); err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries.Deliveries {
+		headers := map[string]string{
+			model.WebhookEventHeader:     delivery.Action,
+			model.WebhookDeliveryHeader:  delivery.ID,
+			model.WebhookSignatureHeader: delivery.Signature,
+		}
+		attempts := delivery.Attempts + 1
+		status, lastError, nextAttemptAt := model.DeliveryDelivered, "", time.Now()
+		// client checks destination policy again on connection, because URL may resolve to other addresses now
+		if err := s.svc.WebhookClient.SendWebhook(ctx, delivery.URL, headers, delivery.Payload); err != nil {
+			s.logger(ctx).WithError(err).Warnf("Unable to deliver webhook %s (attempt %d)", delivery.ID, attempts)
+			lastError = err.Error()
+			if attempts < maxAttempts {
+				status = model.DeliveryPending
+				nextAttemptAt = nextAttemptAt.Add(webhookBackoff(backoff, attempts))
+			} else {
+				status = model.DeliveryFailed
+			}
+		}
+
+		if err := s.svc.DB.SetWebhookDeliveryResult(ctx, delivery.ID, status, attempts, lastError, nextAttemptAt); err != nil {
+			s.logger(ctx).WithError(err).Errorf("Unable to save webhook %s delivery result", delivery.ID)
+		}
+	}
+	return nil
+}
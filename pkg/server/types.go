@@ -4,8 +4,12 @@ import (
 	"context"
 
 	"io"
+	"time"
 
 	"git.containerum.net/ch/solutions/pkg/db"
+	"git.containerum.net/ch/solutions/pkg/lock"
+	"git.containerum.net/ch/solutions/pkg/model"
+	"git.containerum.net/ch/solutions/pkg/utils"
 	kube_types "github.com/containerum/kube-client/pkg/model"
 
 	"git.containerum.net/ch/solutions/pkg/clients"
@@ -16,30 +20,80 @@ type SolutionsService interface {
 	AddTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error
 	UpdateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error
 	GetTemplatesList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsTemplatesList, error)
+	GetTemplate(ctx context.Context, name string, isAdmin bool) (*model.Template, error)
 	GetTemplatesEnvList(ctx context.Context, name, branch string) (*kube_types.SolutionEnv, error)
 	GetTemplatesResourcesList(ctx context.Context, name, branch string) (*kube_types.SolutionResources, error)
 	ActivateTemplate(ctx context.Context, solution string) error
 	DeactivateTemplate(ctx context.Context, solution string) error
 	ValidateTemplate(ctx context.Context, solution kube_types.SolutionTemplate) error
+	// RefreshTemplate validates template on pushed branch, refreshes its metadata and upgrades solutions tracking branch
+	RefreshTemplate(ctx context.Context, templateName string, push model.TemplatePush) (*model.TemplatePushResult, error)
+	// RunTrackingUpgrades upgrades solutions queued by RefreshTemplate one by one until ctx is done
+	RunTrackingUpgrades(ctx context.Context)
 
-	GetSolutionsList(ctx context.Context, isAdmin bool) (*kube_types.SolutionsList, error)
-	GetNamespaceSolutionsList(ctx context.Context, namespace string, isAdmin bool) (*kube_types.SolutionsList, error)
-	GetSolution(ctx context.Context, namespace, solutionName string, isAdmin bool) (*kube_types.Solution, error)
+	GetSolutionsList(ctx context.Context, isAdmin bool) (*model.SolutionsList, error)
+	GetNamespaceSolutionsList(ctx context.Context, namespace string, isAdmin bool) (*model.SolutionsList, error)
+	GetSolution(ctx context.Context, namespace, solutionName string, isAdmin bool) (*model.Solution, error)
 	GetSolutionDeployments(ctx context.Context, namespace, solutionName string) (*kube_types.DeploymentsList, error)
 	GetSolutionServices(ctx context.Context, namespace, solutionName string) (*kube_types.ServicesList, error)
-	RunSolution(ctx context.Context, solutionReq kube_types.Solution) (*kube_types.RunSolutionResponse, error)
+	// GetSolutionResources returns resources created for solution with their statuses
+	GetSolutionResources(ctx context.Context, namespace, solutionName string) (*model.SolutionResourcesList, error)
+	RunSolution(ctx context.Context, solutionReq model.RunSolutionRequest) (*kube_types.RunSolutionResponse, error)
 	DeleteSolution(ctx context.Context, namespace, solution string) error
-	DeleteSolutions(ctx context.Context) error
-	DeleteNamespaceSolutions(ctx context.Context, namespace string) error
+	ExportSolution(ctx context.Context, namespace, solutionName string, redact bool) (*model.SolutionBundle, error)
+	ImportSolution(ctx context.Context, namespace string, req model.SolutionImportRequest) (*kube_types.ImportResponse, error)
+	CloneSolution(ctx context.Context, namespace, solutionName string, req model.SolutionCloneRequest) (*kube_types.RunSolutionResponse, error)
+	StopSolution(ctx context.Context, namespace, solutionName string) error
+	StartSolution(ctx context.Context, namespace, solutionName string) error
+	SetSolutionSchedule(ctx context.Context, namespace, solutionName string, schedule model.SolutionSchedule) error
+	// ApplySolutionsSchedule starts and stops solutions which were scheduled in (since, now]
+	ApplySolutionsSchedule(ctx context.Context, since, now time.Time) error
+	UpgradeSolution(ctx context.Context, namespace, solutionName string) (*model.UpgradeSolutionResponse, error)
+	SetSolutionTracking(ctx context.Context, namespace, solutionName string, tracking model.SolutionTracking) error
+	ExtendSolution(ctx context.Context, namespace, solutionName string, expiry model.SolutionExpiry) error
+	// ExpireSolutions deletes solutions expired at "now" and warns about solutions expiring within "warnBefore"
+	ExpireSolutions(ctx context.Context, now time.Time, warnBefore time.Duration) error
+	DeleteSolutions(ctx context.Context) (*model.DeleteSolutionsResponse, error)
+	DeleteNamespaceSolutions(ctx context.Context, namespace string) (*model.DeleteSolutionsResponse, error)
+
+	ReconcileSolutions(ctx context.Context, heal bool) (*model.SolutionsDriftReport, error)
+	GetSolutionsDrift(ctx context.Context) (*model.SolutionsDriftReport, error)
+	GetSolutionDrift(ctx context.Context, namespace, solutionName string) (*model.SolutionDrift, error)
+	CollectOrphanResources(ctx context.Context, dryRun bool) (*model.OrphanResourcesReport, error)
+	GetEvents(ctx context.Context, filter model.EventsFilter) (*model.EventsList, error)
+
+	CreateWebhook(ctx context.Context, namespace string, webhook model.Webhook) (*model.Webhook, error)
+	GetWebhooksList(ctx context.Context, namespace string) (*model.WebhooksList, error)
+	DeleteWebhook(ctx context.Context, namespace, webhookID string) error
+	GetWebhookDeliveries(ctx context.Context, namespace, webhookID string) (*model.WebhookDeliveriesList, error)
+	RedeliverWebhook(ctx context.Context, namespace, webhookID, deliveryID string) error
+	// DeliverWebhooks sends pending webhook deliveries.
+	// Failed deliveries are retried with exponential backoff until maxAttempts is reached.
+	DeliverWebhooks(ctx context.Context, now time.Time, maxAttempts int, backoff time.Duration) error
+
+	// CheckReadiness checks service dependencies required to serve requests, every check is limited by timeout
+	CheckReadiness(ctx context.Context, timeout time.Duration) *model.HealthReport
+	// CheckStatus checks required dependencies and template source, every check is limited by timeout
+	CheckStatus(ctx context.Context, timeout time.Duration) *model.HealthReport
 	io.Closer
 }
 
 // Services is a collection of resources needed for server functionality.
+// DefaultTemplateSource is a server template files are downloaded from if Services.TemplateSource is not set
+const DefaultTemplateSource = "https://raw.githubusercontent.com"
+
 type Services struct {
 	DB             db.DB
 	DownloadClient clients.DownloadClient
 	ResourceClient clients.ResourceClient
 	KubeAPIClient  clients.KubeAPIClient
+	WebhookClient  clients.WebhookClient
+	// Locker takes solution locks shared by service replicas
+	Locker *lock.Locker
+	// Secrets encrypts secrets stored in DB, nil keeps them in plaintext
+	Secrets *utils.SecretBox
+	// TemplateSource is a server serving template files as {source}/{owner}/{repo}/{ref}/{file}
+	TemplateSource string
 }
 
 type Solution struct {
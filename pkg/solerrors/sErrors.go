@@ -261,6 +261,294 @@ func ErrTemplateValidationFailed(params ...func(*cherry.Err)) *cherry.Err {
 	}
 	return err
 }
+
+func ErrUnableReconcileSolutions(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to reconcile solutions", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x17}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrDriftReportNotExist(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Solutions drift report doesn't exist", StatusHTTP: 404, ID: cherry.ErrID{SID: "Solutions", Kind: 0x18}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableCollectOrphans(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to collect orphan resources", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x19}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableExportSolution(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to export solution", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x1a}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableImportSolution(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to import solution", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x1b}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableCloneSolution(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to clone solution", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x1c}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrInvalidSolutionState(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Solution is already in requested state", StatusHTTP: 409, ID: cherry.ErrID{SID: "Solutions", Kind: 0x1d}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableChangeSolutionState(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to change solution state", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x1e}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableExtendSolution(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to extend solution", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x1f}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableGetEvents(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to get events", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x20}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrWebhookNotExist(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Webhook doesn't exist", StatusHTTP: 404, ID: cherry.ErrID{SID: "Solutions", Kind: 0x21}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrWebhookDeliveryNotExist(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Webhook delivery doesn't exist", StatusHTTP: 404, ID: cherry.ErrID{SID: "Solutions", Kind: 0x22}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableCreateWebhook(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to create webhook", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x23}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableGetWebhooks(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to get webhooks", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x24}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableDeleteWebhook(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to delete webhook", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x25}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableRedeliverWebhook(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to redeliver webhook", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x26}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableUpgradeSolution(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to upgrade solution", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x27}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrInvalidGitHookSignature(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Invalid git webhook signature", StatusHTTP: 403, ID: cherry.ErrID{SID: "Solutions", Kind: 0x28}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUnableRefreshTemplate(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Unable to refresh template", StatusHTTP: 500, ID: cherry.ErrID{SID: "Solutions", Kind: 0x29}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrUpstreamUnavailable(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Upstream service is unavailable", StatusHTTP: 503, ID: cherry.ErrID{SID: "Solutions", Kind: 0x2a}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrPreconditionFailed(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Precondition failed", StatusHTTP: 412, ID: cherry.ErrID{SID: "Solutions", Kind: 0x2b}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrSolutionLocked(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Solution is locked by other operation, retry later", StatusHTTP: 409, ID: cherry.ErrID{SID: "Solutions", Kind: 0x2c}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrInvalidToken(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Invalid or missing bearer token", StatusHTTP: 401, ID: cherry.ErrID{SID: "Solutions", Kind: 0x2d}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
+func ErrRequestTooLarge(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Request body is too large", StatusHTTP: 413, ID: cherry.ErrID{SID: "Solutions", Kind: 0x2e}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
 func renderTemplate(templText string) string {
 	buf := &bytes.Buffer{}
 	templ, err := template.New("").Parse(templText)
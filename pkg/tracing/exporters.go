@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type stdoutSpan struct {
+	Service    string            `json:"service"`
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	Name       string            `json:"name"`
+	Kind       SpanKind          `json:"kind"`
+	Start      string            `json:"start"`
+	Duration   string            `json:"duration"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type stdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutExporter returns exporter writing spans to w as JSON lines.
+// It's useful for debugging.
+func NewStdoutExporter(w io.Writer) Exporter {
+	return &stdoutExporter{w: w}
+}
+
+func (e *stdoutExporter) Export(ctx context.Context, service string, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	enc := json.NewEncoder(e.w)
+	for _, span := range spans {
+		out := stdoutSpan{
+			Service:    service,
+			TraceID:    span.Context.TraceID.String(),
+			SpanID:     span.Context.SpanID.String(),
+			Name:       span.Name,
+			Kind:       span.Kind,
+			Start:      span.StartTime.UTC().Format(time.RFC3339Nano),
+			Duration:   span.EndTime.Sub(span.StartTime).String(),
+			Attributes: span.Attributes,
+			Error:      span.Error,
+		}
+		if span.ParentID.IsValid() {
+			out.ParentID = span.ParentID.String()
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OTLP/HTTP JSON encoding of spans, see https://github.com/open-telemetry/opentelemetry-proto
+type (
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              SpanKind        `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+)
+
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+
+	scopeName = "git.containerum.net/ch/solutions"
+)
+
+func otlpAttributes(attrs map[string]string) []otlpAttribute {
+	ret := make([]otlpAttribute, 0, len(attrs))
+	for k, v := range attrs {
+		ret = append(ret, otlpAttribute{Key: k, Value: otlpValue{StringValue: v}})
+	}
+	return ret
+}
+
+type otlpExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOTLPExporter returns exporter sending spans to OTLP/HTTP collector endpoint (i.e. http://collector:4318/v1/traces) in JSON encoding
+func NewOTLPExporter(endpoint string, timeout time.Duration) Exporter {
+	return &otlpExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (e *otlpExporter) Export(ctx context.Context, service string, spans []*Span) error {
+	scopeSpans := otlpScopeSpans{
+		Scope: otlpScope{Name: scopeName},
+		Spans: make([]otlpSpan, 0, len(spans)),
+	}
+	for _, span := range spans {
+		out := otlpSpan{
+			TraceID:           span.Context.TraceID.String(),
+			SpanID:            span.Context.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+			Status:            otlpStatus{Code: otlpStatusOK},
+		}
+		if span.ParentID.IsValid() {
+			out.ParentSpanID = span.ParentID.String()
+		}
+		if span.Error != "" {
+			out.Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, out)
+	}
+
+	body, err := json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: otlpAttributes(map[string]string{"service.name": service})},
+			ScopeSpans: []otlpScopeSpans{scopeSpans},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("collector responded with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is a W3C trace context header
+const TraceParentHeader = "traceparent"
+
+// FormatTraceParent returns traceparent header value for span context
+func FormatTraceParent(sc SpanContext) string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{byte(sc.TraceFlags)})
+}
+
+// ParseTraceParent parses traceparent header value.
+// Headers of future versions are parsed as version 00 headers, fields appended after flags are ignored.
+func ParseTraceParent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, false
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.TraceID) {
+		return sc, false
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.SpanID) {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return sc, false
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.TraceFlags = TraceFlags(flags[0])
+	return sc, sc.IsValid()
+}
+
+// Inject sets traceparent header of current span
+func Inject(ctx context.Context, header http.Header) {
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		header.Set(TraceParentHeader, FormatTraceParent(sc))
+	}
+}
+
+// Extract returns context with remote parent span from traceparent header (if it's valid)
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if sc, ok := ParseTraceParent(header.Get(TraceParentHeader)); ok {
+		return ContextWithRemoteParent(ctx, sc)
+	}
+	return ctx
+}
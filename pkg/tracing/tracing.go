@@ -0,0 +1,313 @@
+// Package tracing implements distributed tracing compatible with W3C trace context.
+// Finished spans are exported in batches by configured exporter (see NewStdoutExporter and NewOTLPExporter).
+// If tracing is not initialized spans are not created and all span methods are no-op.
+//
+// Tracer is implemented here instead of using OpenTelemetry SDK, because SDK requires newer Go
+// than the service is built with (Go 1.10). Exported spans are in OTLP/HTTP JSON format,
+// so they are accepted by OpenTelemetry collector and compatible backends.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SpanKind describes relationship between span and remote side (values are same as in OTLP)
+type SpanKind int
+
+// Span kinds
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+)
+
+// TraceID identifies trace
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid returns false for zero trace ID
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies span in trace
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid returns false for zero span ID
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// TraceFlags are W3C trace context flags
+type TraceFlags byte
+
+// TraceFlagsSampled is set if caller may record trace
+const TraceFlagsSampled TraceFlags = 0x01
+
+// SpanContext is a part of span propagated to child spans and remote services
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	TraceFlags TraceFlags
+}
+
+// IsValid checks if both trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Span is a single timed operation in trace
+type Span struct {
+	mu     sync.Mutex
+	tracer *Tracer
+	ended  bool
+
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	ParentID   SpanID
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]string
+	Error      string
+}
+
+// SetName changes span name. It's useful if name is known only after operation completed.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Name = name
+}
+
+// SetAttribute sets span attribute
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetError marks span failed. Nil error is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Error = err.Error()
+}
+
+// Finish sets error (if any) and ends span. It's convenient for deferred calls with named error result.
+func (s *Span) Finish(err error) {
+	s.SetError(err)
+	s.End()
+}
+
+// End ends span and passes it to exporter. Subsequent calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// SpanFromContext returns current span or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns context with span context received from remote service
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns span context of current span or remote parent
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.Context, true
+	}
+	sc, ok := ctx.Value(remoteKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// StartSpan starts span which is a child of span from ctx (if any).
+// Returns nil span and ctx as is if tracing is not initialized.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	tracer := globalTracer()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer:     tracer,
+		Name:       name,
+		Kind:       kind,
+		StartTime:  time.Now(),
+		Attributes: make(map[string]string),
+	}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.Context.TraceID = parent.TraceID
+		span.Context.TraceFlags = parent.TraceFlags
+		span.ParentID = parent.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+		span.Context.TraceFlags = TraceFlagsSampled
+	}
+	rand.Read(span.Context.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Exporter sends finished spans to tracing backend
+type Exporter interface {
+	Export(ctx context.Context, service string, spans []*Span) error
+}
+
+const (
+	queueSize      = 2048
+	batchSize      = 512
+	exportInterval = 5 * time.Second
+	exportTimeout  = 10 * time.Second
+)
+
+// Tracer collects finished spans and exports them in batches
+type Tracer struct {
+	service  string
+	exporter Exporter
+	log      *logrus.Entry
+
+	mu     sync.RWMutex // guards queue closing, so spans ended concurrently with Shutdown are dropped
+	closed bool
+	queue  chan *Span
+	done   chan struct{}
+}
+
+var (
+	tracerMu sync.RWMutex
+	tracer   *Tracer
+)
+
+func globalTracer() *Tracer {
+	tracerMu.RLock()
+	defer tracerMu.RUnlock()
+	return tracer
+}
+
+// Init enables tracing with exporter. Service name is sent with every batch of spans.
+func Init(service string, exporter Exporter) {
+	t := &Tracer{
+		service:  service,
+		exporter: exporter,
+		log:      logrus.WithField("component", "tracing"),
+		queue:    make(chan *Span, queueSize),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	tracer = t
+}
+
+// Shutdown disables tracing and exports remaining spans
+func Shutdown(ctx context.Context) error {
+	tracerMu.Lock()
+	t := tracer
+	tracer = nil
+	tracerMu.Unlock()
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	t.closed = true
+	close(t.queue)
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.queue <- span:
+	default:
+		t.log.Debugln("Spans queue is full, dropping span ", span.Name)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	for {
+		select {
+		case span, ok := <-t.queue:
+			if !ok {
+				t.export(batch)
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				t.export(batch)
+				batch = make([]*Span, 0, batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				t.export(batch)
+				batch = make([]*Span, 0, batchSize)
+			}
+		}
+	}
+}
+
+func (t *Tracer) export(spans []*Span) {
+	if len(spans) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	if err := t.exporter.Export(ctx, t.service, spans); err != nil {
+		t.log.WithError(err).Warnf("Unable to export %d spans", len(spans))
+	}
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type discardExporter struct{}
+
+func (discardExporter) Export(ctx context.Context, service string, spans []*Span) error {
+	return nil
+}
+
+func TestTraceParent(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00":       true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1":        false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":          false,
+	} {
+		sc, ok := ParseTraceParent(value)
+		if ok != expected {
+			t.Errorf("Header %s: expected valid %v, got %v", value, expected, ok)
+		}
+		if ok && FormatTraceParent(sc) != "00"+value[2:55] {
+			t.Errorf("Header %s is formatted as %s", value, FormatTraceParent(sc))
+		}
+	}
+}
+
+func TestTraceFlagsPropagation(t *testing.T) {
+	Init("test", discardExporter{})
+	defer Shutdown(context.Background())
+
+	_, root := StartSpan(context.Background(), "root", SpanKindServer)
+	if root.Context.TraceFlags != TraceFlagsSampled {
+		t.Fatalf("Root span is not sampled")
+	}
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, child := StartSpan(ContextWithRemoteParent(context.Background(), parent), "child", SpanKindServer)
+	if child.Context.TraceFlags != 0 {
+		t.Fatalf("Trace flags of remote parent are not propagated: %v", child.Context.TraceFlags)
+	}
+}
+
+func TestShutdownWithEndingSpans(t *testing.T) {
+	Init("test", discardExporter{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		_, span := StartSpan(context.Background(), "span", SpanKindInternal)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				span.tracer.enqueue(span)
+			}
+		}()
+	}
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type transport struct {
+	component string
+	base      http.RoundTripper
+}
+
+// NewTransport returns http.RoundTripper which creates client span for every request
+// and propagates trace context to remote service.
+// http.DefaultTransport is used if base is nil.
+func NewTransport(component string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		component: component,
+		base:      base,
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := StartSpan(req.Context(), t.component+" "+req.Method, SpanKindClient)
+	if span == nil {
+		return t.base.RoundTrip(req)
+	}
+	span.SetAttribute("component", t.component)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.host", req.URL.Host)
+	span.SetAttribute("http.path", req.URL.Path)
+
+	// RoundTripper must not modify request, so headers are copied
+	req = req.WithContext(ctx)
+	header := make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		header[k] = v
+	}
+	Inject(ctx, header)
+	req.Header = header
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.Finish(err)
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetError(fmt.Errorf("response status %s", resp.Status))
+	}
+	span.End()
+	return resp, nil
+}
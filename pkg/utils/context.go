@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ServiceUserID is an user ID used by background jobs
+	ServiceUserID = "00000000-0000-0000-0000-000000000000"
+
+	roleAdmin = "admin"
+)
+
+// AdminContext returns context for calls made outside of user requests (background jobs, etc.).
+// Context contains admin identity headers in the same way as SaveHeaders and PrepareContext middlewares do.
+// New request ID is generated if ctx doesn't contain one, so every job run has own ID in logs and other services.
+func AdminContext(ctx context.Context) context.Context {
+	requestID, _ := ctx.Value(httputil.RequestIDContextKey).(string)
+	if requestID == "" {
+		requestID = uuid.New().String()
+		ctx = ContextWithLogEntry(ctx, logrus.WithField(RequestIDField, requestID))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(ctx)
+	req.Header.Set(httputil.UserIDXHeader, ServiceUserID)
+	req.Header.Set(httputil.UserRoleXHeader, roleAdmin)
+	req.Header.Set(httputil.RequestIDXHeader, requestID)
+	gctx := &gin.Context{Request: req}
+	httputil.SaveHeaders(gctx)
+	httputil.PrepareContext(gctx)
+	return gctx.Request.Context()
+}
+
+type ifMatchKey struct{}
+
+// ContextWithIfMatch returns context with value of "If-Match" request header.
+// Operations supporting optimistic concurrency control compare it with entity tag of modified entity.
+func ContextWithIfMatch(ctx context.Context, ifMatch string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, ifMatch)
+}
+
+// IfMatch returns value of "If-Match" request header saved in context, empty string if request has no precondition
+func IfMatch(ctx context.Context) string {
+	ifMatch, _ := ctx.Value(ifMatchKey{}).(string)
+	return ifMatch
+}
+
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// DetachedContext returns context with values of ctx, which is not cancelled with ctx and has no deadline.
+// It's used for operations which must be done even if request is cancelled, i.e. recording of request outcome.
+func DetachedContext(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCron is returned if cron expression can't be parsed
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+type cronField struct {
+	min, max int
+}
+
+// minute, hour, day of month, month, day of week
+var cronFields = []cronField{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// Cron is a parsed standard 5-field cron expression ("minute hour day-of-month month day-of-week").
+// Supported syntax: "*", numbers, ranges "a-b", steps "*/n" and "a-b/n", lists "a,b,c".
+type Cron struct {
+	fields [5]map[int]bool
+	// day of month and day of week are combined using OR if both are restricted
+	domAny, dowAny bool
+}
+
+// ParseCron parses cron expression
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%v: expected %d fields, got %d", ErrInvalidCron, len(cronFields), len(parts))
+	}
+	var ret Cron
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%v: %q: %v", ErrInvalidCron, part, err)
+		}
+		ret.fields[i] = values
+	}
+	// sunday may be written both as 0 and 7
+	if ret.fields[4][7] {
+		ret.fields[4][0] = true
+	}
+	ret.domAny = parts[2] == "*"
+	ret.dowAny = parts[4] == "*"
+	return &ret, nil
+}
+
+func parseCronField(field string, bounds cronField) (map[int]bool, error) {
+	ret := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q", item[i+1:])
+			}
+			rng = item[:i]
+		}
+
+		from, to := bounds.min, bounds.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			bs := strings.SplitN(rng, "-", 2)
+			var err1, err2 error
+			from, err1 = strconv.Atoi(bs[0])
+			to, err2 = strconv.Atoi(bs[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", rng)
+			}
+			from, to = v, v
+			if strings.Contains(item, "/") {
+				to = bounds.max
+			}
+		}
+
+		if from < bounds.min || to > bounds.max || from > to {
+			return nil, fmt.Errorf("value out of range [%d, %d]", bounds.min, bounds.max)
+		}
+		for v := from; v <= to; v += step {
+			ret[v] = true
+		}
+	}
+	return ret, nil
+}
+
+// Match returns true if cron fires at minute containing t
+func (c *Cron) Match(t time.Time) bool {
+	if !c.fields[0][t.Minute()] || !c.fields[1][t.Hour()] || !c.fields[3][int(t.Month())] {
+		return false
+	}
+	dom, dow := c.fields[2][t.Day()], c.fields[4][int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// LastBetween returns last minute in (from, to] when cron fires
+func (c *Cron) LastBetween(from, to time.Time) (time.Time, bool) {
+	from = from.Truncate(time.Minute)
+	for t := to.Truncate(time.Minute); t.After(from); t = t.Add(-time.Minute) {
+		if c.Match(t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
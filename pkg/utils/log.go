@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDField is a name of log field containing request ID
+const RequestIDField = "request_id"
+
+type logEntryKey struct{}
+
+// ContextWithLogEntry returns context with request-scoped log entry.
+// Its fields (i.e. request ID) are added to every line logged with LogEntry.
+func ContextWithLogEntry(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, logEntryKey{}, entry)
+}
+
+// LogEntry returns base entry with fields of request-scoped log entry from context
+func LogEntry(ctx context.Context, base *logrus.Entry) *logrus.Entry {
+	if entry, ok := ctx.Value(logEntryKey{}).(*logrus.Entry); ok {
+		return base.WithFields(entry.Data)
+	}
+	return base
+}
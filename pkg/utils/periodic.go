@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// RunPeriodically calls f every interval until ctx is done.
+// Does nothing if interval is not positive.
+func RunPeriodically(ctx context.Context, interval time.Duration, f func(ctx context.Context)) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f(ctx)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// sealedPrefix marks encrypted values, values without it are stored in plaintext
+const sealedPrefix = "enc:v1:"
+
+// SecretBox encrypts secrets stored in database (i.e. webhook secrets) with AES-256-GCM,
+// so database dumps and backups don't expose them. Key is derived from passphrase with SHA-256.
+//
+// Nil SecretBox stores secrets in plaintext. Plaintext values stored before encryption was enabled are still opened,
+// they are encrypted when secret is set again.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox returns SecretBox encrypting with passphrase or nil if passphrase is empty
+func NewSecretBox(passphrase string) (*SecretBox, error) {
+	if passphrase == "" {
+		return nil, nil
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal returns encrypted secret
+func (b *SecretBox) Seal(secret string) (string, error) {
+	if b == nil {
+		return secret, nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open returns secret decrypted from value returned by Seal
+func (b *SecretBox) Open(value string) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+	if b == nil {
+		return "", errors.New("secret is encrypted, but encryption key is not set")
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", errors.New("malformed encrypted secret")
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	secret, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("unable to decrypt secret, encryption key may be changed")
+	}
+	return string(secret), nil
+}
@@ -1,9 +1,14 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
 
+	"git.containerum.net/ch/solutions/pkg/model"
 	"git.containerum.net/ch/solutions/pkg/solerrors"
+	"git.containerum.net/ch/solutions/pkg/utils"
 	"github.com/containerum/cherry"
 	kube_types "github.com/containerum/kube-client/pkg/model"
 )
@@ -42,3 +47,106 @@ func ValidateSolution(solution kube_types.Solution) *cherry.Err {
 	}
 	return nil
 }
+
+func ValidateSolutionImport(req model.SolutionImportRequest) *cherry.Err {
+	valerrs := []error{}
+	if req.Bundle.Template == "" {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "Bundle.Template"))
+	}
+	if req.Name == "" && req.Bundle.Name == "" {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "Name"))
+	}
+	if len(req.Bundle.Resources) == 0 {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "Bundle.Resources"))
+	}
+	if len(valerrs) > 0 {
+		return solerrors.ErrRequestValidationFailed().AddDetailsErr(valerrs...)
+	}
+	return nil
+}
+
+func ValidateSolutionClone(req model.SolutionCloneRequest) *cherry.Err {
+	valerrs := []error{}
+	if req.Name == "" {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "Name"))
+	}
+	if req.Namespace == "" {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "Namespace"))
+	}
+	if len(valerrs) > 0 {
+		return solerrors.ErrRequestValidationFailed().AddDetailsErr(valerrs...)
+	}
+	return nil
+}
+
+func ValidateSolutionSchedule(schedule model.SolutionSchedule) *cherry.Err {
+	valerrs := []error{}
+	for _, expr := range []string{schedule.Start, schedule.Stop} {
+		if expr == "" {
+			continue
+		}
+		if _, err := utils.ParseCron(expr); err != nil {
+			valerrs = append(valerrs, err)
+		}
+	}
+	if len(valerrs) > 0 {
+		return solerrors.ErrRequestValidationFailed().AddDetailsErr(valerrs...)
+	}
+	return nil
+}
+
+func ValidateSolutionExpiry(expiry model.SolutionExpiry) *cherry.Err {
+	valerrs := []error{}
+	switch {
+	case expiry.TTL != "" && expiry.ExpiresAt != "":
+		valerrs = append(valerrs, errors.New("only one of TTL and ExpiresAt should be provided"))
+	case expiry.TTL != "":
+		if ttl, err := time.ParseDuration(expiry.TTL); err != nil {
+			valerrs = append(valerrs, err)
+		} else if ttl <= 0 {
+			valerrs = append(valerrs, errors.New("TTL should be positive"))
+		}
+	case expiry.ExpiresAt != "":
+		if expiresAt, err := time.Parse(time.RFC3339, expiry.ExpiresAt); err != nil {
+			valerrs = append(valerrs, err)
+		} else if !expiresAt.After(time.Now()) {
+			valerrs = append(valerrs, errors.New("ExpiresAt should be in future"))
+		}
+	default:
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "TTL or ExpiresAt"))
+	}
+	if len(valerrs) > 0 {
+		return solerrors.ErrRequestValidationFailed().AddDetailsErr(valerrs...)
+	}
+	return nil
+}
+
+func ValidateWebhook(webhook model.Webhook) *cherry.Err {
+	valerrs := []error{}
+	if webhook.URL == "" {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "URL"))
+	} else if u, err := url.Parse(webhook.URL); err != nil {
+		valerrs = append(valerrs, err)
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		valerrs = append(valerrs, errors.New("URL should be absolute http or https URL"))
+	}
+	if webhook.Secret == "" {
+		valerrs = append(valerrs, fmt.Errorf(fieldShouldExist, "Secret"))
+	}
+	for _, action := range webhook.Events {
+		known := false
+		for _, a := range model.EventActions {
+			if a == action {
+				known = true
+				break
+			}
+		}
+		if !known {
+			valerrs = append(valerrs, fmt.Errorf("unknown event %q", action))
+		}
+	}
+	if len(valerrs) > 0 {
+		return solerrors.ErrRequestValidationFailed().AddDetailsErr(valerrs...)
+	}
+	return nil
+}